@@ -8,19 +8,30 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"net/url"
 	"path"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
+	"github.com/oapi-codegen/runtime"
 )
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// List policy rules whose enrichment changed
+	// (GET /v1/changes)
+	GetV1Changes(c *gin.Context, params GetV1ChangesParams)
 	// Enrich telemetry attributes with compliance control data
 	// (POST /v1/enrich)
-	PostV1Enrich(c *gin.Context)
+	PostV1Enrich(c *gin.Context, params PostV1EnrichParams)
+	// Enrich several policy rules in one request
+	// (POST /v1/enrich/batch)
+	PostV1EnrichBatch(c *gin.Context)
+	// Export the complete policy rule to compliance table
+	// (GET /v1/snapshot)
+	GetV1Snapshot(c *gin.Context)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -32,9 +43,103 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(c *gin.Context)
 
+// GetV1Changes operation middleware
+func (siw *ServerInterfaceWrapper) GetV1Changes(c *gin.Context) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetV1ChangesParams
+
+	// ------------- Optional query parameter "since" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "since", c.Request.URL.Query(), &params.Since)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter since: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "epoch" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "epoch", c.Request.URL.Query(), &params.Epoch)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter epoch: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "wait" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "wait", c.Request.URL.Query(), &params.Wait)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter wait: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetV1Changes(c, params)
+}
+
 // PostV1Enrich operation middleware
 func (siw *ServerInterfaceWrapper) PostV1Enrich(c *gin.Context) {
 
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostV1EnrichParams
+
+	headers := c.Request.Header
+
+	// ------------- Optional header parameter "If-None-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-None-Match")]; found {
+		var IfNoneMatch string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandler(c, fmt.Errorf("Expected one value for If-None-Match, got %d", n), http.StatusBadRequest)
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-None-Match", valueList[0], &IfNoneMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter If-None-Match: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		params.IfNoneMatch = &IfNoneMatch
+
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostV1Enrich(c, params)
+}
+
+// PostV1EnrichBatch operation middleware
+func (siw *ServerInterfaceWrapper) PostV1EnrichBatch(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostV1EnrichBatch(c)
+}
+
+// GetV1Snapshot operation middleware
+func (siw *ServerInterfaceWrapper) GetV1Snapshot(c *gin.Context) {
+
 	for _, middleware := range siw.HandlerMiddlewares {
 		middleware(c)
 		if c.IsAborted() {
@@ -42,7 +147,7 @@ func (siw *ServerInterfaceWrapper) PostV1Enrich(c *gin.Context) {
 		}
 	}
 
-	siw.Handler.PostV1Enrich(c)
+	siw.Handler.GetV1Snapshot(c)
 }
 
 // GinServerOptions provides options for the Gin server.
@@ -72,40 +177,80 @@ func RegisterHandlersWithOptions(router gin.IRouter, si ServerInterface, options
 		ErrorHandler:       errorHandler,
 	}
 
+	router.GET(options.BaseURL+"/v1/changes", wrapper.GetV1Changes)
 	router.POST(options.BaseURL+"/v1/enrich", wrapper.PostV1Enrich)
+	router.POST(options.BaseURL+"/v1/enrich/batch", wrapper.PostV1EnrichBatch)
+	router.GET(options.BaseURL+"/v1/snapshot", wrapper.GetV1Snapshot)
 }
 
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/7RYXXPbuhH9Kxi0M21nKFlO+jV681WSuZ7pvVYt377EfoDAlYQYBBjsUo6a8X/vLAiK",
-	"H6LsZNL7JhFY4Ozu2d1DfpXaF6V34Ajl/KtEvYNCxZ8LX5TWKKeB/6k8N2S8U3YZfAmBDKCcb5RFyGQO",
-	"qIMpeV3OO4YiB1LGotgEX4ibxeqDWIGugqGDWHhHwVuxDH5jLExlJsvOyQwsbuCffwywkXP5h4sW7EVC",
-	"etHelk6Uz5kEF4zeFeBoRYqqeF4fZP1c+I2gHQjdQm5NRRm8BsS5WFWaf2TiN1eosoQ8E0sVyCjLjx6d",
-	"f3KZ8EGsHg2vsi/gqkLOP8pkKjPZ2MpMJuP4MFrLTCZb+ZBJ+KKK0gKjPFrToeQHSMG4Lbu4CaqAJx8e",
-	"8dsj9KG1ec5kMPj47ba3vPuZzeBzZQLk7F2Tox6ckfA/ZJIMRZc6tDp65defQBNjOs3maeYaBqXLhXEb",
-	"HwrFy2LjQzeZChEQGcgJvVRZWqPV2lhDh9Nb3ru9Cd6xKYp4qCP4QiiedhBA0M7gEUA8CqLjTeY+ymXw",
-	"eaXjaRmzbct5e8ikISgigJOMpgcqBHXg/1qRsn57nZ+i+82ZzxUIk4MjszEQouPMZBxGJ53CPhxz1EUq",
-	"b1bL1eSnMYppRbD1YSQ6i7QST1WFsQdBO0XjCNZgvduiIN+79ypSu+kDY/ebH/N8DcZtEwVi2Q18/vfV",
-	"ZPaP6exy7OoABeQmcupd9/4hnM5i00sCaF8U4HLIRecYgRQ4aocEuOVPD9ktFH4PInhPokIIQtVhUi4X",
-	"hvc0namEIK6vfhGlt0bX7Bt4MahVwyFoOdVJ78OLhfih12jOtvojuSLUdHEE26nPkyrcvHD4LWwrqyjR",
-	"zLi8QgoHgaRcrkKOKcGwV7ZSBPmg+Pvl+Ov16m7yz9ls8re3XI83i8mb76vGjkcvB6Ln+pGmaQYyQVqf",
-	"hx70IV8tJszNxeLv08vvwTrIe68z97x4Oe+3aTycd9TgY6fDvphnC3sY6eV8h4hrfJDXJubxydBOOO8m",
-	"/WSmiboIhoyO0/Nns93JTP4CuakKmcl/+SeZyesWh7L9eZoMXi6UGutYcN4f59otfK4AaYy0cUGU6mC9",
-	"qjlJwGXL3FVEwawr6sqMk1jFij68NpmX9a4h+GT8GnosvUM4k16gniIqgFSuSAnjtK1yZmzTYlnRGLfF",
-	"rEPqbEiMaZfWteBsdWVH5Q0m8vkR2hmM7fRqh9XpZDH5SM8/1+J/oAWPKs+OiOt3u+6/sw2q33aGTaGj",
-	"4lKF1RR/ZloM5XQ37N+m+Ub03nFplGEh+BApPLg6H6Haz3d3S27mLMPjjg5L/jqbZbKuYjmXxtHbN23Z",
-	"GkewhcAXFoCotiOHRySiWX6t4tP1zfYx15bHojxTMLDnZq9BsNiKvb4uRQFuaxzU9BmVpoK8t3imDbyP",
-	"1r+qYsRJftqojt5ltRgrIXAIIY8b0pCJQiUI4B6payarpsQ6+mh5NaaK6ktuKwvfJ0oTuFBZOBnaLZi+",
-	"RMvBHSZcgxOuwVdTeBKtAdrTnPIJPLJOHbkSGhwFZc1/IR/thAhhDyGFOXhOPSYvJ+QnXZOmQ967pml2",
-	"W2XUSv12iTFs6TCFEyan4O0b659wKu5YOjIAo6EGgkLhveNAq4p2PhhSZPYg0FeBlVn/nahxIhPg1Npy",
-	"Nm5KcHfHIaW9taDJh5qznnYQ7h0ekPWHIJ9m15FyLbFgH/HH+d3ItGEM04tUdu9Cq+/aOXIMiLYK0WyM",
-	"jkfj9J4Z2n2JVIhilcJwtbyWmdxDwDqDs+nldMaM9SU4VRo5l2+nsyl31lLRLtbXxf7yovakrraxac6T",
-	"pCQUnHHiUnuEw9g4R/FnmG6nWUwjiet3WRMdpwrI6jFy/e4v7B47TlVwKNpvKp0ATQLYWBidw9cKuVKc",
-	"4NYXeH/uC2Uc9xqjp/cucgJcXnrDQgzjxvgGQl6sQWhlLeRifRDKDbK9aLL9JxS6QuLGVX/38KEOOjel",
-	"mIQ4cJce6T+XtZZIghKQfvL5oRnnrGnacc6GF5+wfnmqR8xrA+hUZj33y51CBfFBLWNiOt/MZr8LgKSU",
-	"IoLBh4h6rm8qaw+pJnppk9FioypL/z9kccKOgKkcfClBM3Eg7ckkVkWhoiSqPRqnbizXQYVyn+ImwVc9",
-	"/y8AAP//owzEwCUUAAA=",
+	"H4sIAAAAAAAC/+w7/W8bOXb/CjEt0DtgJEv+SuL9pY7jdF3sblzblyu6WkDUzJPEyww5ITmy1UX+9+I9",
+	"kvOhoWRnN9tegQMCxNIM+b6/n35NMlVWSoK0Jrn4NVkDz0HTn1c8W8OVklarAj/nYDItKiuUTC7oqZAr",
+	"lgsNmRUbMCkTMivqHL+1a2AlfxrxFbCl0uxxLbI1favBVEoafLxlC/xcG8iTNDHZGkqOgOy2guQiMVYL",
+	"uUq+fEmT6we+iqCgpAVp2Qa0EUoytSQIGbe8UCvDuMwZbHhRczzAqoJLwxAas4pVWuV1Bj2cDmLxJTwk",
+	"5rzlNltfSy2ydQnS3sHnGowd4ugfsIpvC8VzYgbQMWSTgQ1oXrBKFSITYBi3TMkMEam0qkBbAQQuvIB/",
+	"Cwsl/fHPGpbJRfJPR60IjzyKR7d4YJt8SZOSP924E9PJZJImpZDhcxqI5FrzLXFaw+daaMiTi59boL80",
+	"L6rF3yCzeO2AAZ6FESmVVSG4zICVYHnOLXdc4NnaEb5lQjLOFngj045hqEzhb6Z0Dno8YIoGUxf25Tzp",
+	"YVsXRMZBBgQAMfqv1lyu4D1APiTZMZ/pugDDHtfKAIMGNsvoZM740oJmnGnYCNTfIYHuTbMXAMiVkEB6",
+	"jrDYJ9juh5ekX6s5fd6kCVQqWw+RuclBWrEUYJz5eas0oDeQf8dEwMCwR2HXDFV+y6p6UQizhrwhP0kT",
+	"eOJlVSDMN8vX5/nk9fT169PsVX5+lqS79oiSMhCxuL+uQTKra0gdOh4218CksuyTVI+SWMaLgmXo4/Iu",
+	"t8raWPRLuTAZ1znkXbyWvDDQoLJQqgAuHS6eiKH611rTvbyq0OTDmymzCuFU3KBH4obNjZAZzJmShLiE",
+	"JxssoIvDSZoslS65TS4SIe35acsbIS2sQA/02Amug2VgXtqoWFTFG8tFsnieC6SJF7cdJfUc2WvyOVgu",
+	"CsOWWpXsw9X9e3YPWa2F3TIfWtitVktRQET929hzSGFbaP5G0tVGoveW2zpiQ+77Jmi0KLdHMUhkYMwF",
+	"u68z/CNlf5EoSMhTdsu1FbzAr0inUqY0u/8k8CnSArIukfn+aJIm4WySJv4wfUmnkzTxZ5NfOsLunB7o",
+	"PzxlQMREiLusKq02qNnNS4xTmCbfG+wU2Y+mULUOa8yu2yNksJxZrldgmZLFlvGqKraou7AROSC/+ELV",
+	"ltk1t/7F8UtdTSu5BmbM7yw1L+FR6U9fceH79gwagzCfXn72Dt/etaGgjD10InqGhiQsya69MTloXXtz",
+	"rMZUgqyEdKYvlCQ5drSWGwPGICIDO0KRiYwvRCHsdgjlWm6EVhKPGkaXSgtPFiMJaGB2LUyrLHgVmK4/",
+	"+jm5pVTKOsdyb/kKFfSXjg4MVHdXwj5lu4nE0r9I8bkGJkKQ0Y0Cm13u+FuQhkZGvajy4f72fvQ2ZksZ",
+	"t7BSehvLc90TupWXoth6VY9hsIBCyZVhVvXgXpINB4cXgy9+H+ULwNDiVADyIc3/cTmavBpPpvEwWkIu",
+	"SKfedeHvotN5GJymhkyVJcicwnhzDTNWI9e2HuFWf3qY3UGpNsC0UpbVBjTjjk3okAS+E1xwBZrdXP7Y",
+	"ZMlDKnZsVSALWp3qiPdwmGu90H6Hiq6k9aqoFY9cbLxw+D6vGrNJvE/H8hfFeICGXG6A9bgXdGBkgZf/",
+	"6r8fZ6qMh4pKaDD7kqUuEGasqoxz83i6C/N4cnw+mpyMTqYPk8kF/fuvpJOO5NzCyIoSfr+K80EA62Fy",
+	"/Z+j48nx2Wg6GU32qTU3MUH+db3dIfiRmwZcD8gPsOLZlhJZkQHDQJHXBbgiLifdF8YIJR2fhl6OouHe",
+	"7N093kFGGFaIUlgqUr/beei9r4u+oJsrHlGKqhTW7lCwEnZdL1ArjpReHWmo1MuMp1HPhpGtEh22ofe9",
+	"YL03L2wctKteHHwy+E6MG1jN8sDld7CqC269qxYyr43VW2YslznXufFO0jcEIN8JoP2Q9tPN/cPo9WQy",
+	"OjvBmPbhanT8dRGtQ9FhRvRIb+zAJ8wo/pbmXQr6KF9ejdAQrq7Ox9OvwXVH/L3spkfFYbnf+RRrP6Ho",
+	"ONss5aCcC9hAJB9CGIye4UUqEyRHSlKlkqO+MH36faWFFRml2t+LFVZBP0IuanSTP6jHJE1uWjx40U++",
+	"/YHD9uJwjTHnN7aHLGDoQ93l1mqxqG23Jom3hrYvrepjLZ7ts9gf7O2A7ZVPTZOn7QaGoOhrYJN2lDrd",
+	"VYxxV61/Tdqbd0rCnax2fxraSS7bDLBN+IbZmcgjedO+NOl3pDHRMrVT8fW9XffTXgfVdzu7TqFTCXkL",
+	"cypOzc3d2rvL9pfVTZGaqXn0rIZhP+7FvUPOjJCrAqL9Q6essXbCbyEp/SYGlj7LC60VJYO7OOcRs/v+",
+	"4eGWGde/oDc6FnOKPd5ug+jkONIgSpMSjOGryOWECQuPn/N+Hnx4PUbabcO/Pc6j6SRg8UZxr+r2N50p",
+	"RUtdZpUqzB6XeE2nf+JlhEj8NlQxPWCuuKtAIwtDAt7OEKh7v1Q6c1bNs0Fm+uH2MpYLOiB3dQFfV+R2",
+	"aohBAtMi08/6cpDbkVbKjtAfPSvCAbd2sI3J9F7yyqxVNKIZVWygJ7BORw3pcrmra8R2O09xiwUbkd97",
+	"XhhweS/VXEuxqjXkFGFAs4xLqSwrhLFMWNOFYlJmFDOqBPeRGsOUx/eLHeogRzq9vr390c2cvtFQCt8w",
+	"nqVUj+jARTSHnnDP8tPp6eSYL7LTxTF/db5482r6Jn8znU6mr7KzN8fR8k9aLWLlX+uB2WK7YwhhqoD8",
+	"0qhui+aRROuxyHx8gd28e2nDL6jNtbQ6OmJwjmsvcz/2mdqwLFdZHdQLHV+HYdMXecMVSNBoVJcRlX4Q",
+	"JfThoYiaIy+ugI1YSW5rDS9l031zYJCl9/g00Mo+QWlrSK0uHLJqJ55D2hILwgds+O816g4ZPezBhkcM",
+	"6+G+GhjQghfiv9385t/vP/xExYiqndeZNwKf+/zPbsezYbXDi5XSwq7LQ9Dbl9ra5jo/PjubvknS5Pr+",
+	"+Ow8SZM7+r9XxLQvDRTyE2xj0eh7eGIgMbLnrNKwFE/B4O6/vxwdn52zXKxoLuu+fXd9176Pc70MJ5EH",
+	"53lv+PESOJ9kZ2c8n0yj8z10khGBvOUGzk8bgK1RdcxwsbXPpy5djjpOBJhDVcGzWLBGGoMsA2m1V4NY",
+	"HUSDUO0TC60w2QkRaWTVqHsk1EczGUqmbqFEHrlfLBmyRH8ZNyPkCcPXl4V6NGP2sBambWAhIoZxM5PU",
+	"YqvtGhnAaSxkVK0z2J0qBCJSBpIvCsw/PlQgH5oSNVNFAZlV2kU2ZdegZ9JsDYYDalSR02hiSxv5YEP4",
+	"U/UemjS7PPSjiHQmddvdaavIhiFZwY0RS5HR1cYZWXcMw41h954Nl7c3KOkQY5LJeDqeoMKpCiSvRHKR",
+	"nIwnY6yrKm7XZKJHm+lRZxofbevdga21D+bVbxjQ+4UAPL4SG5DtqHgmOc4VHK/weeaHyuENFDQ0n5iQ",
+	"mQZuwLglC4xHLHBBAzYaDBPWzOThrITA5WK5hO4EG7k7k6FxrMFP1ttBO1Hhh9mpnxO4LscjF9awukK9",
+	"mOOHOTOQKZk7NVYSZvJPRGmliuLPYxagNJfTrd07OwsEARG8ADTzQ1m82PuhmQwrFy0zjOXapmyuwYCd",
+	"M7IW6wqOQpCC0kKA3waI7wwQQ+48Fi6hzFQtEbcKdBgiU94ZLuWFUTT6J1rmNKCf499bVnBjmeGPrJX5",
+	"THp2OjnT222x0F+7CKQFY5d5wzySp7CEiAbqnpiZxBfDypbhZXuTWSMlwnoxcBkAG7+0MFywWKpaukQR",
+	"35xJhO5Rx2NSWbJurnExpLnQiZPwYbkC0+xpoCxMkI0zalWBJh2lds6/gf04vfJ2ieaK3tLS/trPQ/P0",
+	"alKhwqjaFFumyWRdbktzKpB5pYQMNPueeupG3zHLQ7LCLTT3RlCfa9AY/yTVngkxoLdb9oIFjkHyRax6",
+	"Ce4dxWGN3lxKVrtNA890YqpTnSXsxz2sjhzYzotMrcmkrSKDD4MxkpIr2aSSzm1UILFBOGZXtBjhSPGB",
+	"Su9DCS/dy03K70shRYkp0iTCWeqSuR4R+fLjySR0FkHaTmcRyTn6mx8itdAOprDtUhilDDulYc9Dxp1Y",
+	"QqeW3LfDvglWrrcUQaiW8FRBhvDBv5Mmpi5LTo3RH4SxvdJ5/24ZHsQY6R65Hkys34291gr9H7lZtcSI",
+	"GGt4G/YnGK/GKaU6lt28SwMiqASpa7TevPsz+reZdKZgWLui1EkiRhoKapd0Ll9wAzmjSGlB4/u5KrmQ",
+	"2IES2XgmH3oGJQy9SHNut7WV8aJw+srlTkZ0FTKifzEsq41VZYgASvtI4bWPZVxrumGOC65zloMWoejv",
+	"OfWdZgJGcEyUOtF7JnfDd9r1A5zNaZ135Lvc87CdO2ZXIc5x9CkbXggsYtFiKc4hdx2+SK1xBuuilkNa",
+	"SDa/WY5+UhJGP2ILdv4dgjuZnM67ztFZvtsLpjxiJgN1a+5Dg1OmmKO/VcZ+nLoC9DlHj2ghp3jXWw4X",
+	"/TpLv+Rl3OZz62Z6NB30gL7vDsa+Vfn225ntYHz0pV/CWF3Dlz/Qm0UmQBEn4ucVy7ootp6/PWNL0sFS",
+	"+ejq8Gaff/+ot4HeWQM/dIbeITRPJqeRXg714vbpQptUkW33VIByQyuKIuQA/2eE/b3EB6cfcfdNXmen",
+	"ksN6FovJnWhxRIOb/THDd5TNgT3NyD55J7ym4TOlt26ZfCaD36tR7JZZFZqu9GoujBUy24l/5FVaeglx",
+	"ZtUK7Brizr0B670lZcQ7rthLmHaAW7bMU/+rCVrFmclm6cNtpbc0+nnXM17zrXdjf4Sn2vNjiP9ld7Xv",
+	"Fwkv9lmOvVEV+oetO1vv/WAlWIWQTMmWVcG6TWdG9GzDJDoi6kDx4yKrugnQMOUJc5OZ5CsupLGxfMlV",
+	"0QE9JkzIrzoVSLcQ9k/dT4dC98zbs1rG4ojvTQlD7cmQ/ITSXJjuyMrlZzPZ4INHMMf6BNsxCx1q9CfS",
+	"/y7BO1n3MyZRQUFDUuov+sGXJmfo4e0tnMPdyR9olA2MmBUGipt1Uie8ptPUt7r/p4bzVClt29gFtj/U",
+	"taob0yxfFOSxvvzPAMAQQvSZNwAA",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
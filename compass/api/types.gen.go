@@ -3,6 +3,10 @@
 // Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.5.0 DO NOT EDIT.
 package api
 
+import (
+	"time"
+)
+
 // Defines values for ComplianceEnrichmentStatus.
 const (
 	Partial  ComplianceEnrichmentStatus = "Partial"
@@ -21,6 +25,38 @@ const (
 	Medium        ComplianceRiskLevel = "Medium"
 )
 
+// Defines values for SnapshotSignatureAlgorithm.
+const (
+	ES256   SnapshotSignatureAlgorithm = "ES256"
+	Ed25519 SnapshotSignatureAlgorithm = "Ed25519"
+	RS256   SnapshotSignatureAlgorithm = "RS256"
+)
+
+// BatchEnrichmentRequest Request payload for enriching several policies at once
+type BatchEnrichmentRequest struct {
+	Policies []Policy `json:"policies"`
+}
+
+// BatchEnrichmentResponse Compliance metadata for each policy in a batch request, in request order.
+type BatchEnrichmentResponse struct {
+	Results []EnrichmentResult `json:"results"`
+}
+
+// ChangeFeed Policy rules whose enrichment changed after a revision.
+type ChangeFeed struct {
+	// Changes Policy engine and rule keys whose enrichment changed
+	Changes []Policy `json:"changes"`
+
+	// Epoch Identifies the content served; it changes with every published revision
+	Epoch string `json:"epoch"`
+
+	// Reset When true, the changes are not known and all cached enrichment must be discarded
+	Reset bool `json:"reset"`
+
+	// Revision Current mapping revision, to be passed as `since` on the next request
+	Revision int64 `json:"revision"`
+}
+
 // Compliance Compliance details from OCSF Security Control Profile.
 type Compliance struct {
 	// Control Security control information for compliance assessment
@@ -29,6 +65,9 @@ type Compliance struct {
 	// EnrichmentStatus Status of the compliance enrichment process: Success, Unmapped, Partial, Unknown, or Skipped.
 	EnrichmentStatus ComplianceEnrichmentStatus `json:"enrichmentStatus"`
 
+	// Exceptions Approved exceptions active for the control and policy rule. Exceptions with a target only apply to evidence about that target.
+	Exceptions *[]ComplianceException `json:"exceptions,omitempty"`
+
 	// Frameworks Compliance framework and requirement information
 	Frameworks ComplianceFrameworks `json:"frameworks"`
 
@@ -57,6 +96,24 @@ type ComplianceControl struct {
 	RemediationDescription *string `json:"remediationDescription,omitempty"`
 }
 
+// ComplianceException Approved risk exception or waiver for a control and policy rule
+type ComplianceException struct {
+	// Approver Who approved the exception
+	Approver string `json:"approver"`
+
+	// Expires When the exception stops applying
+	Expires time.Time `json:"expires"`
+
+	// Id Unique identifier for the approved exception
+	Id string `json:"id"`
+
+	// Reason Why the exception was approved
+	Reason string `json:"reason"`
+
+	// Target Policy target the exception is limited to; the exception applies to every target when omitted
+	Target *string `json:"target,omitempty"`
+}
+
 // ComplianceFrameworks Compliance framework and requirement information
 type ComplianceFrameworks struct {
 	// Frameworks Regulatory or industry standards being evaluated for compliance
@@ -87,6 +144,15 @@ type EnrichmentResponse struct {
 	Compliance Compliance `json:"compliance"`
 }
 
+// EnrichmentResult Compliance metadata for a single policy in a batch response.
+type EnrichmentResult struct {
+	// Compliance Compliance details from OCSF Security Control Profile.
+	Compliance Compliance `json:"compliance"`
+
+	// Policy Complete evidence log from policy engines and compliance assessment tools
+	Policy Policy `json:"policy"`
+}
+
 // Error defines model for Error.
 type Error struct {
 	// Code HTTP status code
@@ -105,5 +171,71 @@ type Policy struct {
 	PolicyRuleId string `json:"policyRuleId"`
 }
 
+// Snapshot Resolved compliance enrichment for every known policy rule.
+type Snapshot struct {
+	// Complete False when a configured mapper cannot list its policy rules, so some rules are missing
+	Complete bool `json:"complete"`
+
+	// ContentVersion Content version of the catalogs and evaluation plans the snapshot was resolved from
+	ContentVersion string `json:"contentVersion"`
+
+	// Entries Enrichment by policy engine and rule, sorted by engine name then rule ID
+	Entries []SnapshotEntry `json:"entries"`
+
+	// FormatVersion Version of the snapshot document format
+	FormatVersion int32 `json:"formatVersion"`
+
+	// GeneratedAt Time the snapshot was generated
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	// Signature Signature over the snapshot serialized as JSON without its `signature` property.
+	Signature *SnapshotSignature `json:"signature,omitempty"`
+}
+
+// SnapshotEntry Enrichment for a single policy rule.
+type SnapshotEntry struct {
+	// Compliance Compliance details from OCSF Security Control Profile.
+	Compliance Compliance `json:"compliance"`
+
+	// Policy Complete evidence log from policy engines and compliance assessment tools
+	Policy Policy `json:"policy"`
+}
+
+// SnapshotSignature Signature over the snapshot serialized as JSON without its `signature` property.
+type SnapshotSignature struct {
+	// Algorithm Signature algorithm
+	Algorithm SnapshotSignatureAlgorithm `json:"algorithm"`
+
+	// KeyId Hex encoded prefix of the SHA-256 digest of the DER encoded public key
+	KeyId string `json:"keyId"`
+
+	// Value Base64 encoded signature
+	Value []byte `json:"value"`
+}
+
+// SnapshotSignatureAlgorithm Signature algorithm
+type SnapshotSignatureAlgorithm string
+
+// GetV1ChangesParams defines parameters for GetV1Changes.
+type GetV1ChangesParams struct {
+	// Since Revision previously returned by this endpoint. When omitted, only the current revision is returned.
+	Since *int64 `form:"since,omitempty" json:"since,omitempty"`
+
+	// Epoch Epoch previously returned by this endpoint along with `since`. An unknown epoch resets the feed.
+	Epoch *string `form:"epoch,omitempty" json:"epoch,omitempty"`
+
+	// Wait Seconds to wait for a change when none are pending. Capped by the server.
+	Wait *int32 `form:"wait,omitempty" json:"wait,omitempty"`
+}
+
+// PostV1EnrichParams defines parameters for PostV1Enrich.
+type PostV1EnrichParams struct {
+	// IfNoneMatch ETag of a previously cached enrichment response
+	IfNoneMatch *string `json:"If-None-Match,omitempty"`
+}
+
 // PostV1EnrichJSONRequestBody defines body for PostV1Enrich for application/json ContentType.
 type PostV1EnrichJSONRequestBody = EnrichmentRequest
+
+// PostV1EnrichBatchJSONRequestBody defines body for PostV1EnrichBatch for application/json ContentType.
+type PostV1EnrichBatchJSONRequestBody = BatchEnrichmentRequest
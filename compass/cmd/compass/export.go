@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/complytime/complybeacon/compass/cmd/compass/server"
+	"github.com/complytime/complybeacon/compass/internal/logging"
+	"github.com/complytime/complybeacon/compass/internal/snapshot"
+)
+
+// runExport writes a snapshot of every resolved policy rule to a file or
+// stdout, the same document served by GET /v1/snapshot.
+func runExport(args []string) int {
+	var catalogPath, configPath, logLevel, outputPath, signingKey string
+	var unsigned bool
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.StringVar(&catalogPath, "catalog", "./hack/sampledata/osps.yaml", "Path to Layer 2 catalog")
+	fs.StringVar(&configPath, "config", "./docs/config.yaml", "Path to compass config file")
+	fs.StringVar(&logLevel, "log-level", "error", "Log level: debug|info|warn|error")
+	fs.StringVar(&outputPath, "output", "-", "Path to write the snapshot to, or - for stdout")
+	fs.StringVar(&signingKey, "signing-key", "", "Path to a PEM private key to sign the snapshot, overriding snapshotSigningKey in the config")
+	fs.BoolVar(&unsigned, "unsigned", false, "Do not sign the snapshot, even when a signing key is configured")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: compass export [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if _, err := logging.InitWithWriter(os.Stderr, logLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize logging: %v\n", err)
+		return 2
+	}
+
+	scope, err := server.NewScopeFromCatalogPath(catalogPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load catalog %s: %v\n", catalogPath, err)
+		return 1
+	}
+
+	cfg, err := server.LoadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config file %s: %v\n", configPath, err)
+		return 1
+	}
+
+	set, err := server.NewMapperSet(&cfg, scope)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize plugin mappers: %v\n", err)
+		return 1
+	}
+
+	registry, err := server.NewExceptions(&cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load exceptions: %v\n", err)
+		return 1
+	}
+
+	version, err := server.ContentVersion(catalogPath, &cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to compute content version: %v\n", err)
+		return 1
+	}
+
+	now := time.Now()
+	resolved := snapshot.Build(set, scope, version, now)
+	registry.ApplyAll(resolved.Entries, now)
+
+	if signingKey == "" {
+		signingKey = cfg.SnapshotSigningKey
+	}
+	if signingKey != "" && !unsigned {
+		signer, err := snapshot.LoadSigner(signingKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load signing key %s: %v\n", signingKey, err)
+			return 1
+		}
+		if err := signer.Sign(&resolved); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+	}
+
+	if err := writeSnapshot(outputPath, resolved); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write snapshot: %v\n", err)
+		return 1
+	}
+
+	if !resolved.Complete {
+		fmt.Fprintln(os.Stderr, "warning: some mappers cannot list their policy rules; the snapshot is incomplete")
+	}
+	return 0
+}
+
+func writeSnapshot(outputPath string, resolved any) error {
+	var w io.Writer = os.Stdout
+	if outputPath != "-" {
+		f, err := os.Create(filepath.Clean(outputPath))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(resolved)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/complytime/complybeacon/compass/api"
+	"github.com/complytime/complybeacon/compass/cmd/compass/server"
+	"github.com/complytime/complybeacon/compass/internal/logging"
+	"github.com/complytime/complybeacon/compass/mapper"
+	"github.com/complytime/complybeacon/compass/mapper/plugins/basic"
+)
+
+// lookupResult is the document printed by the lookup subcommand.
+type lookupResult struct {
+	Policy     api.Policy     `json:"policy"`
+	Mapper     mapper.ID      `json:"mapper"`
+	Fallback   bool           `json:"fallback"`
+	Compliance api.Compliance `json:"compliance"`
+	Explain    []string       `json:"explain"`
+}
+
+// runLookup resolves a single policy engine and rule ID against the
+// configured catalog and plans without starting the server. It exits
+// non-zero when the rule does not map successfully.
+func runLookup(args []string) int {
+	var catalogPath, configPath, logLevel string
+
+	fs := flag.NewFlagSet("lookup", flag.ContinueOnError)
+	fs.StringVar(&catalogPath, "catalog", "./hack/sampledata/osps.yaml", "Path to Layer 2 catalog")
+	fs.StringVar(&configPath, "config", "./docs/config.yaml", "Path to compass config file")
+	fs.StringVar(&logLevel, "log-level", "error", "Log level: debug|info|warn|error")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: compass lookup [flags] <policy-engine-name> <policy-rule-id>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	if _, err := logging.InitWithWriter(os.Stderr, logLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize logging: %v\n", err)
+		return 2
+	}
+
+	scope, err := server.NewScopeFromCatalogPath(catalogPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load catalog %s: %v\n", catalogPath, err)
+		return 1
+	}
+
+	cfg, err := server.LoadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config file %s: %v\n", configPath, err)
+		return 1
+	}
+
+	set, err := server.NewMapperSet(&cfg, scope)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize plugin mappers: %v\n", err)
+		return 1
+	}
+
+	policy := api.Policy{
+		PolicyEngineName: fs.Arg(0),
+		PolicyRuleId:     fs.Arg(1),
+	}
+	result := explain(set, scope, policy)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write result: %v\n", err)
+		return 1
+	}
+
+	if result.Compliance.EnrichmentStatus != api.Success {
+		return 1
+	}
+	return 0
+}
+
+// explain selects the mapper for the policy the same way the enrichment
+// service does and records how the result was produced.
+func explain(set mapper.Set, scope mapper.Scope, policy api.Policy) lookupResult {
+	var trace []string
+
+	mapperPlugin, ok := set[mapper.ID(policy.PolicyEngineName)]
+	if !ok {
+		mapperPlugin = basic.NewBasicMapper()
+		trace = append(trace, fmt.Sprintf("policy engine %q not found in mapper set; using %s mapper fallback",
+			policy.PolicyEngineName, mapperPlugin.PluginName()))
+	}
+
+	var compliance api.Compliance
+	if explainer, canExplain := mapperPlugin.(mapper.Explainer); canExplain {
+		var steps []string
+		compliance, steps = explainer.Explain(policy, scope)
+		trace = append(trace, steps...)
+	} else {
+		compliance = mapperPlugin.Map(policy, scope)
+		trace = append(trace, fmt.Sprintf("mapper %s does not support explain", mapperPlugin.PluginName()))
+	}
+
+	return lookupResult{
+		Policy:     policy,
+		Mapper:     mapperPlugin.PluginName(),
+		Fallback:   !ok,
+		Compliance: compliance,
+		Explain:    trace,
+	}
+}
@@ -2,18 +2,31 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 
-	"github.com/goccy/go-yaml"
-
 	"github.com/complytime/complybeacon/compass/cmd/compass/server"
 	"github.com/complytime/complybeacon/compass/internal/logging"
+	"github.com/complytime/complybeacon/compass/internal/snapshot"
 	compass "github.com/complytime/complybeacon/compass/service"
 )
 
+// commands maps offline subcommands to their entry points. When no
+// subcommand is given, compass runs the enrichment server.
+var commands = map[string]func(args []string) int{
+	"export":   runExport,
+	"lookup":   runLookup,
+	"validate": runValidate,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
 
 	var (
 		port, catalogPath, configPath string
@@ -28,6 +41,11 @@ func main() {
 	// TODO: This needs to become Layer 3 policy and complete resolution on startup
 	flag.StringVar(&catalogPath, "catalog", "./hack/sampledata/osps.yaml", "Path to Layer 2 catalog")
 	flag.StringVar(&configPath, "config", "./docs/config.yaml", "Path to compass config file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [export|lookup|validate] [flags]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(flag.CommandLine.Output(), "Without a subcommand, compass runs the enrichment server.")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	_, err := logging.Init(logLevel)
@@ -50,27 +68,48 @@ func main() {
 		os.Exit(1)
 	}
 
-	var cfg server.Config
 	configPath = filepath.Clean(configPath)
-	content, err := os.ReadFile(configPath)
+	cfg, err := server.LoadConfig(configPath)
 	if err != nil {
-		slog.Error("failed to read config file", "path", configPath, "err", err)
+		slog.Error("failed to load config file", "path", configPath, "err", err)
 		os.Exit(1)
 	}
 
-	err = yaml.Unmarshal(content, &cfg)
+	transformers, err := server.NewMapperSet(&cfg, scope)
 	if err != nil {
-		slog.Error("failed to parse config file", "path", configPath, "err", err)
+		slog.Error("failed to initialize plugin mappers", "err", err)
 		os.Exit(1)
 	}
 
-	transformers, err := server.NewMapperSet(&cfg)
+	registry, err := server.NewExceptions(&cfg)
 	if err != nil {
-		slog.Error("failed to initialize plugin mappers", "err", err)
+		slog.Error("failed to load exceptions", "path", cfg.ExceptionsFile, "err", err)
 		os.Exit(1)
 	}
 
-	service := compass.NewService(transformers, scope)
+	version, err := server.ContentVersion(catalogPath, &cfg)
+	if err != nil {
+		slog.Error("failed to compute content version", "err", err)
+		os.Exit(1)
+	}
+	slog.Info("mapping content loaded", slog.String("content_version", version))
+
+	opts := []compass.Option{
+		compass.WithContentVersion(version),
+		compass.WithCacheMaxAge(cfg.CacheMaxAge),
+		compass.WithExceptions(registry),
+	}
+	if cfg.SnapshotSigningKey != "" {
+		signer, err := snapshot.LoadSigner(cfg.SnapshotSigningKey)
+		if err != nil {
+			slog.Error("failed to load snapshot signing key", "path", cfg.SnapshotSigningKey, "err", err)
+			os.Exit(1)
+		}
+		opts = append(opts, compass.WithSnapshotSigner(signer))
+	}
+
+	service := compass.NewService(transformers, scope, opts...)
+	go reloadOnSignal(service, catalogPath, configPath)
 
 	s := server.NewGinServer(service, port)
 
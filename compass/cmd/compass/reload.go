@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/complytime/complybeacon/compass/cmd/compass/server"
+	compass "github.com/complytime/complybeacon/compass/service"
+)
+
+// reloadOnSignal reloads the catalog, config, exceptions and evaluation plans each
+// time the process receives SIGHUP. A reload that fails keeps serving the
+// previous content.
+func reloadOnSignal(service *compass.Service, catalogPath, configPath string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		slog.Info("reloading mapping content", slog.String("catalog", catalogPath), slog.String("config", configPath))
+		if err := reload(service, catalogPath, configPath); err != nil {
+			slog.Error("failed to reload mapping content", "err", err)
+		}
+	}
+}
+
+func reload(service *compass.Service, catalogPath, configPath string) error {
+	scope, err := server.NewScopeFromCatalogPath(catalogPath)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := server.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	transformers, err := server.NewMapperSet(&cfg, scope)
+	if err != nil {
+		return err
+	}
+
+	registry, err := server.NewExceptions(&cfg)
+	if err != nil {
+		return err
+	}
+
+	version, err := server.ContentVersion(catalogPath, &cfg)
+	if err != nil {
+		return err
+	}
+
+	service.Reload(transformers, scope, registry, version)
+	return nil
+}
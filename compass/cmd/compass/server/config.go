@@ -1,16 +1,24 @@
 package server
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/goccy/go-yaml"
 	"github.com/ossf/gemara/layer2"
 	"github.com/ossf/gemara/layer4"
 
+	"github.com/complytime/complybeacon/compass/internal/exceptions"
+	"github.com/complytime/complybeacon/compass/internal/planlint"
 	"github.com/complytime/complybeacon/compass/mapper"
 	"github.com/complytime/complybeacon/compass/mapper/factory"
 )
@@ -42,6 +50,18 @@ func NewScopeFromCatalogPath(catalogPath string) (mapper.Scope, error) {
 type Config struct {
 	Plugins     []PluginConfig `json:"plugins"`
 	Certificate CertConfig     `json:"certConfig"`
+	// PlanValidation is either "lenient" (default) or "strict". Strict
+	// mode fails plan loading on any lint warning.
+	PlanValidation string `json:"planValidation"`
+	// CacheMaxAge is the Cache-Control max-age returned with enrichment
+	// responses. Zero uses compass.DefaultCacheMaxAge.
+	CacheMaxAge time.Duration `json:"cacheMaxAge"`
+	// SnapshotSigningKey is the path to a PEM encoded private key used to
+	// sign exported snapshots. Snapshots are unsigned when it is empty.
+	SnapshotSigningKey string `json:"snapshotSigningKey"`
+	// ExceptionsFile is the path to the registry of approved exceptions.
+	// No exceptions are returned when it is empty.
+	ExceptionsFile string `json:"exceptionsFile"`
 }
 
 type CertConfig struct {
@@ -54,10 +74,30 @@ type PluginConfig struct {
 	EvaluationsDir string `json:"evaluations-dir"`
 }
 
-func NewMapperSet(config *Config) (mapper.Set, error) {
+// LoadConfig reads and parses the compass configuration file at configPath.
+func LoadConfig(configPath string) (Config, error) {
+	var cfg Config
+	content, err := os.ReadFile(filepath.Clean(configPath))
+	if err != nil {
+		return cfg, err
+	}
+
+	err = yaml.Unmarshal(content, &cfg)
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func NewMapperSet(config *Config, scope mapper.Scope) (mapper.Set, error) {
 	pluginSet := make(mapper.Set)
 	slog.Debug("loading plugins", slog.Int("count", len(config.Plugins)))
 
+	mode, err := planlint.ParseMode(config.PlanValidation)
+	if err != nil {
+		return pluginSet, err
+	}
+
 	for _, pluginConf := range config.Plugins {
 		transformerId := mapper.ID(pluginConf.Id)
 		if pluginConf.EvaluationsDir == "" {
@@ -67,29 +107,133 @@ func NewMapperSet(config *Config) (mapper.Set, error) {
 			continue
 		}
 
-		info, err := os.Stat(pluginConf.EvaluationsDir)
+		linter := planlint.New(scope, mode)
+		tfmr, err := NewMapperFromConfig(pluginConf, linter)
+		logFindings(transformerId, linter.Findings())
 		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return pluginSet, fmt.Errorf("evaluations directory %s for plugin %s: %w", pluginConf.EvaluationsDir, pluginConf.Id, err)
-			}
 			return pluginSet, err
 		}
+		pluginSet[transformerId] = tfmr
+	}
+	slog.Debug("plugins loaded", slog.Int("count", len(pluginSet)))
+	return pluginSet, nil
+}
+
+// NewExceptions loads the exceptions file referenced by config, or returns
+// a nil registry when there is none.
+func NewExceptions(config *Config) (*exceptions.Registry, error) {
+	if config.ExceptionsFile == "" {
+		return nil, nil
+	}
+	registry, err := exceptions.Load(config.ExceptionsFile)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("exceptions loaded",
+		slog.String("path", config.ExceptionsFile),
+		slog.Int("count", registry.Len()),
+	)
+	return registry, nil
+}
+
+// ContentVersion returns a digest of the catalog, the exceptions file and
+// every evaluation plan file referenced by config. It changes whenever the
+// mapping content served by compass changes, and is used as the enrichment
+// ETag.
+func ContentVersion(catalogPath string, config *Config) (string, error) {
+	digest := sha256.New()
+	if err := hashFile(digest, filepath.Clean(catalogPath)); err != nil {
+		return "", err
+	}
+	if config.ExceptionsFile != "" {
+		_, _ = fmt.Fprintln(digest, "exceptions")
+		if err := hashFile(digest, filepath.Clean(config.ExceptionsFile)); err != nil {
+			return "", err
+		}
+	}
 
-		if !info.IsDir() {
-			return pluginSet, fmt.Errorf("evaluations directory %s for plugin %s is not a directory", pluginConf.EvaluationsDir, pluginConf.Id)
+	for _, pluginConf := range config.Plugins {
+		if pluginConf.EvaluationsDir == "" {
+			continue
 		}
+		_, _ = fmt.Fprintf(digest, "plugin:%s\n", pluginConf.Id)
 
-		tfmr, err := NewMapperFromDir(transformerId, pluginConf.EvaluationsDir)
+		err := filepath.WalkDir(pluginConf.EvaluationsDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !planlint.IsSupportedFile(path) {
+				return nil
+			}
+			rel, err := filepath.Rel(pluginConf.EvaluationsDir, path)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(digest, "file:%s\n", filepath.ToSlash(rel))
+			return hashFile(digest, path)
+		})
 		if err != nil {
-			return pluginSet, fmt.Errorf("unable to load configuration for %s: %w", pluginConf.Id, err)
+			return "", err
 		}
-		pluginSet[transformerId] = tfmr
 	}
-	slog.Debug("plugins loaded", slog.Int("count", len(pluginSet)))
-	return pluginSet, nil
+
+	return hex.EncodeToString(digest.Sum(nil))[:32], nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// logFindings reports plan lint findings for a plugin.
+func logFindings(pluginID mapper.ID, findings []planlint.Finding) {
+	for _, finding := range findings {
+		level := slog.LevelWarn
+		if finding.Severity == planlint.Error {
+			level = slog.LevelError
+		}
+		slog.Log(context.Background(), level, "evaluation plan validation",
+			slog.String("plugin_id", string(pluginID)),
+			slog.String("path", finding.Path),
+			slog.String("finding", finding.Message),
+		)
+	}
+}
+
+// NewMapperFromConfig loads the mapper for a single plugin from its
+// evaluations directory, checking each plan with linter.
+func NewMapperFromConfig(pluginConf PluginConfig, linter *planlint.Linter) (mapper.Mapper, error) {
+	info, err := os.Stat(pluginConf.EvaluationsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("evaluations directory %s for plugin %s: %w", pluginConf.EvaluationsDir, pluginConf.Id, err)
+		}
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("evaluations directory %s for plugin %s is not a directory", pluginConf.EvaluationsDir, pluginConf.Id)
+	}
+
+	tfmr, err := NewMapperFromDir(mapper.ID(pluginConf.Id), pluginConf.EvaluationsDir, linter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load configuration for %s: %w", pluginConf.Id, err)
+	}
+	return tfmr, nil
 }
 
-func NewMapperFromDir(pluginID mapper.ID, evaluationsPath string) (mapper.Mapper, error) {
+// NewMapperFromDir loads every evaluation plan under evaluationsPath into a
+// new mapper. Plans are checked with linter, or with a lenient linter
+// without catalog checks when linter is nil.
+func NewMapperFromDir(pluginID mapper.ID, evaluationsPath string, linter *planlint.Linter) (mapper.Mapper, error) {
+	if linter == nil {
+		linter = planlint.New(nil, planlint.Lenient)
+	}
+
 	mpr := factory.MapperByID(pluginID)
 	err := filepath.Walk(evaluationsPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -100,6 +244,10 @@ func NewMapperFromDir(pluginID mapper.ID, evaluationsPath string) (mapper.Mapper
 			return nil
 		}
 
+		if !linter.SupportedFile(path) {
+			return nil
+		}
+
 		content, err := os.ReadFile(path)
 		if err != nil {
 			return err
@@ -108,8 +256,10 @@ func NewMapperFromDir(pluginID mapper.ID, evaluationsPath string) (mapper.Mapper
 		var evaluation layer4.EvaluationPlan
 		err = yaml.Unmarshal(content, &evaluation)
 		if err != nil {
-			return err
+			linter.Errorf(path, "failed to parse evaluation plan: %v", err)
+			return nil
 		}
+		linter.CheckEvaluationPlan(path, evaluation)
 
 		// Extract reference-ids from Assessment Plans to determine the
 		// control source.
@@ -124,6 +274,9 @@ func NewMapperFromDir(pluginID mapper.ID, evaluationsPath string) (mapper.Mapper
 	if err != nil {
 		return mpr, err
 	}
+	if err := linter.Err(); err != nil {
+		return mpr, fmt.Errorf("evaluation plans failed validation: %w", err)
+	}
 	slog.Info("plugin evaluations loaded",
 		slog.String("plugin_id", string(pluginID)),
 		slog.String("dir", evaluationsPath),
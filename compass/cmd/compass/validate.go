@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/complytime/complybeacon/compass/cmd/compass/server"
+	"github.com/complytime/complybeacon/compass/internal/exceptions"
+	"github.com/complytime/complybeacon/compass/internal/logging"
+	"github.com/complytime/complybeacon/compass/internal/planlint"
+	"github.com/complytime/complybeacon/compass/internal/snapshot"
+)
+
+// diagnostics collects the findings reported by the validate subcommand.
+type diagnostics struct {
+	errors   []string
+	warnings []string
+}
+
+func (d *diagnostics) errorf(format string, args ...any) {
+	d.errors = append(d.errors, fmt.Sprintf(format, args...))
+}
+
+func (d *diagnostics) warnf(format string, args ...any) {
+	d.warnings = append(d.warnings, fmt.Sprintf(format, args...))
+}
+
+func (d *diagnostics) write(w io.Writer) {
+	for _, msg := range d.errors {
+		_, _ = fmt.Fprintf(w, "error: %s\n", msg)
+	}
+	for _, msg := range d.warnings {
+		_, _ = fmt.Fprintf(w, "warning: %s\n", msg)
+	}
+	_, _ = fmt.Fprintf(w, "%d error(s), %d warning(s)\n", len(d.errors), len(d.warnings))
+}
+
+// runValidate loads the config, catalog and evaluation plans the way the
+// server does, but keeps going after a failure so every problem is reported.
+func runValidate(args []string) int {
+	var catalogPath, configPath, logLevel string
+	var strict bool
+
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.StringVar(&catalogPath, "catalog", "./hack/sampledata/osps.yaml", "Path to Layer 2 catalog")
+	fs.StringVar(&configPath, "config", "./docs/config.yaml", "Path to compass config file")
+	fs.StringVar(&logLevel, "log-level", "error", "Log level: debug|info|warn|error")
+	fs.BoolVar(&strict, "strict", false, "Treat evaluation plan warnings as errors, overriding planValidation in the config")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: compass validate [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if _, err := logging.InitWithWriter(os.Stderr, logLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize logging: %v\n", err)
+		return 2
+	}
+
+	var diags diagnostics
+	validate(&diags, catalogPath, configPath, strict)
+	diags.write(os.Stdout)
+
+	if len(diags.errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func validate(diags *diagnostics, catalogPath, configPath string, strict bool) {
+	scope, err := server.NewScopeFromCatalogPath(catalogPath)
+	if err != nil {
+		diags.errorf("catalog %s: %v", catalogPath, err)
+	} else {
+		for catalogId := range scope {
+			if catalogId == "" {
+				diags.warnf("catalog %s: metadata.id is empty; plans cannot reference it", catalogPath)
+			}
+		}
+	}
+
+	cfg, err := server.LoadConfig(configPath)
+	if err != nil {
+		diags.errorf("config %s: %v", configPath, err)
+		return
+	}
+
+	mode, err := planlint.ParseMode(cfg.PlanValidation)
+	if err != nil {
+		diags.errorf("config %s: %v", configPath, err)
+	}
+	if strict {
+		mode = planlint.Strict
+	}
+
+	if cfg.Certificate.PublicKey == "" || cfg.Certificate.PrivateKey == "" {
+		diags.warnf("config %s: certConfig is incomplete; the server will only start with --skip-tls", configPath)
+	}
+
+	if cfg.SnapshotSigningKey != "" {
+		if _, err := snapshot.LoadSigner(cfg.SnapshotSigningKey); err != nil {
+			diags.errorf("config %s: snapshotSigningKey %s: %v", configPath, cfg.SnapshotSigningKey, err)
+		}
+	}
+
+	if cfg.ExceptionsFile != "" {
+		if _, err := exceptions.Load(cfg.ExceptionsFile); err != nil {
+			diags.errorf("config %s: exceptionsFile: %v", configPath, err)
+		}
+	}
+
+	if len(cfg.Plugins) == 0 {
+		diags.warnf("config %s: no plugins configured; every request will use the basic mapper fallback", configPath)
+	}
+
+	seen := make(map[string]bool)
+	for i, pluginConf := range cfg.Plugins {
+		if pluginConf.Id == "" {
+			diags.warnf("plugin #%d: id is empty and will never match a policy engine name", i)
+		}
+		if pluginConf.EvaluationsDir == "" {
+			diags.warnf("plugin %q: no evaluations-dir; plugin will be skipped", pluginConf.Id)
+			continue
+		}
+
+		if seen[pluginConf.Id] {
+			diags.warnf("plugin %q: configured more than once; the last entry wins", pluginConf.Id)
+		}
+		seen[pluginConf.Id] = true
+
+		linter := planlint.New(scope, mode)
+		_, err := server.NewMapperFromConfig(pluginConf, linter)
+		for _, finding := range linter.Findings() {
+			if finding.Severity == planlint.Error {
+				diags.errorf("plugin %q: %s", pluginConf.Id, finding)
+			} else {
+				diags.warnf("plugin %q: %s", pluginConf.Id, finding)
+			}
+		}
+		// Lint failures are already reported as findings above.
+		if err != nil && linter.Err() == nil {
+			diags.errorf("plugin %q: %v", pluginConf.Id, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package exceptions loads the registry of approved risk exceptions and
+// waivers, and attaches the active ones to enrichment results.
+package exceptions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/complytime/complybeacon/compass/api"
+)
+
+// File is the exceptions file format.
+type File struct {
+	Exceptions []Exception `json:"exceptions"`
+}
+
+// Exception is an approved exception for a control and policy rule,
+// optionally limited to a single policy target.
+type Exception struct {
+	Id       string `json:"id"`
+	Control  string `json:"control"`
+	Rule     string `json:"rule"`
+	Target   string `json:"target"`
+	Approver string `json:"approver"`
+	Reason   string `json:"reason"`
+	// Expires is an RFC 3339 timestamp or a date, which expires at the
+	// start of that day in UTC.
+	Expires string `json:"expires"`
+}
+
+// key identifies the exceptions for a control and policy rule.
+type key struct {
+	control string
+	rule    string
+}
+
+// Registry holds approved exceptions by control and policy rule. A nil
+// Registry has no exceptions.
+type Registry struct {
+	exceptions map[key][]api.ComplianceException
+}
+
+// Load reads and validates the exceptions file at path.
+func Load(path string) (*Registry, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var file File
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse exceptions file %s: %w", path, err)
+	}
+	registry, err := New(file.Exceptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exceptions file %s: %w", path, err)
+	}
+	return registry, nil
+}
+
+// New creates a Registry from exceptions, checking that each is complete
+// and that identifiers are unique.
+func New(exceptions []Exception) (*Registry, error) {
+	registry := &Registry{exceptions: make(map[key][]api.ComplianceException)}
+	seen := make(map[string]bool, len(exceptions))
+
+	var errs []error
+	for i, exception := range exceptions {
+		if exception.Id == "" || exception.Control == "" || exception.Rule == "" ||
+			exception.Approver == "" || exception.Reason == "" || exception.Expires == "" {
+			errs = append(errs, fmt.Errorf("exception %d needs an id, control, rule, approver, reason and expires", i))
+			continue
+		}
+		if seen[exception.Id] {
+			errs = append(errs, fmt.Errorf("exception %s is defined more than once", exception.Id))
+			continue
+		}
+		seen[exception.Id] = true
+
+		expires, err := parseExpiry(exception.Expires)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("exception %s: %w", exception.Id, err))
+			continue
+		}
+
+		compliance := api.ComplianceException{
+			Id:       exception.Id,
+			Approver: exception.Approver,
+			Reason:   exception.Reason,
+			Expires:  expires,
+		}
+		if exception.Target != "" {
+			target := exception.Target
+			compliance.Target = &target
+		}
+		k := key{control: exception.Control, rule: exception.Rule}
+		registry.exceptions[k] = append(registry.exceptions[k], compliance)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return registry, nil
+}
+
+func parseExpiry(expires string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, expires); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, expires)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expires %q is neither an RFC 3339 timestamp nor a date", expires)
+	}
+	return t, nil
+}
+
+// Len returns the number of exceptions in the registry.
+func (r *Registry) Len() int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, exceptions := range r.exceptions {
+		n += len(exceptions)
+	}
+	return n
+}
+
+// Active returns the exceptions for a control and policy rule that have
+// not expired at now.
+func (r *Registry) Active(controlID, ruleID string, now time.Time) []api.ComplianceException {
+	if r == nil {
+		return nil
+	}
+	var active []api.ComplianceException
+	for _, exception := range r.exceptions[key{control: controlID, rule: ruleID}] {
+		if now.Before(exception.Expires) {
+			active = append(active, exception)
+		}
+	}
+	return active
+}
+
+// Apply attaches the exceptions active at now for the mapped control and
+// the policy rule to compliance.
+func (r *Registry) Apply(policy api.Policy, compliance *api.Compliance, now time.Time) {
+	active := r.Active(compliance.Control.Id, policy.PolicyRuleId, now)
+	if len(active) == 0 {
+		compliance.Exceptions = nil
+		return
+	}
+	compliance.Exceptions = &active
+}
+
+// ApplyAll attaches the active exceptions to every snapshot entry.
+func (r *Registry) ApplyAll(entries []api.SnapshotEntry, now time.Time) {
+	for i := range entries {
+		r.Apply(entries[i].Policy, &entries[i].Compliance, now)
+	}
+}
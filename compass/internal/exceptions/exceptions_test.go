@@ -0,0 +1,136 @@
+package exceptions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/complytime/complybeacon/compass/api"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exceptions.yaml")
+	content := `exceptions:
+  - id: EX-1
+    control: AC-1
+    rule: deny-root
+    approver: security-team
+    reason: Legacy workload is being migrated
+    expires: 2030-01-01
+  - id: EX-2
+    control: AC-1
+    rule: deny-root
+    target: cluster-a
+    approver: security-team
+    reason: Break glass access
+    expires: 2030-01-01T12:00:00Z
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	registry, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, registry.Len())
+
+	active := registry.Active("AC-1", "deny-root", time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.Len(t, active, 2)
+	assert.Equal(t, "EX-1", active[0].Id)
+	assert.Nil(t, active[0].Target)
+	assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), active[0].Expires)
+	require.NotNil(t, active[1].Target)
+	assert.Equal(t, "cluster-a", *active[1].Target)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	valid := Exception{
+		Id:       "EX-1",
+		Control:  "AC-1",
+		Rule:     "deny-root",
+		Approver: "security-team",
+		Reason:   "Legacy workload",
+		Expires:  "2030-01-01",
+	}
+
+	tests := []struct {
+		name        string
+		exceptions  []Exception
+		expectedErr string
+	}{
+		{
+			name:       "valid",
+			exceptions: []Exception{valid},
+		},
+		{
+			name: "missing approver",
+			exceptions: []Exception{func() Exception {
+				e := valid
+				e.Approver = ""
+				return e
+			}()},
+			expectedErr: "exception 0 needs an id, control, rule, approver, reason and expires",
+		},
+		{
+			name:        "duplicate id",
+			exceptions:  []Exception{valid, valid},
+			expectedErr: "exception EX-1 is defined more than once",
+		},
+		{
+			name: "invalid expiry",
+			exceptions: []Exception{func() Exception {
+				e := valid
+				e.Expires = "next year"
+				return e
+			}()},
+			expectedErr: `exception EX-1: expires "next year" is neither an RFC 3339 timestamp nor a date`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := New(tt.exceptions)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.exceptions), registry.Len())
+		})
+	}
+}
+
+func TestRegistry_Apply(t *testing.T) {
+	registry, err := New([]Exception{
+		{Id: "EX-1", Control: "AC-1", Rule: "deny-root", Approver: "a", Reason: "r", Expires: "2030-01-01"},
+		{Id: "EX-2", Control: "AC-1", Rule: "deny-root", Approver: "a", Reason: "r", Expires: "2020-01-01"},
+	})
+	require.NoError(t, err)
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	policy := api.Policy{PolicyEngineName: "engine", PolicyRuleId: "deny-root"}
+
+	compliance := api.Compliance{Control: api.ComplianceControl{Id: "AC-1"}}
+	registry.Apply(policy, &compliance, now)
+	require.NotNil(t, compliance.Exceptions)
+	require.Len(t, *compliance.Exceptions, 1, "expired exceptions should not be returned")
+	assert.Equal(t, "EX-1", (*compliance.Exceptions)[0].Id)
+
+	compliance = api.Compliance{Control: api.ComplianceControl{Id: "AC-2"}}
+	registry.Apply(policy, &compliance, now)
+	assert.Nil(t, compliance.Exceptions)
+
+	compliance = api.Compliance{Control: api.ComplianceControl{Id: "AC-1"}}
+	registry.Apply(policy, &compliance, time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, compliance.Exceptions)
+
+	var nilRegistry *Registry
+	nilRegistry.Apply(policy, &compliance, now)
+	assert.Nil(t, compliance.Exceptions)
+	assert.Zero(t, nilRegistry.Len())
+}
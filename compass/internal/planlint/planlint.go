@@ -0,0 +1,261 @@
+package planlint
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ossf/gemara/layer4"
+
+	"github.com/complytime/complybeacon/compass/mapper"
+)
+
+// Severity classifies a Finding.
+type Severity string
+
+const (
+	// Error findings always fail plan loading.
+	Error Severity = "error"
+	// Warning findings fail plan loading only in Strict mode.
+	Warning Severity = "warning"
+)
+
+// Mode controls how warnings affect plan loading.
+type Mode string
+
+const (
+	// Lenient reports warnings but continues loading.
+	Lenient Mode = "lenient"
+	// Strict promotes every warning to an error.
+	Strict Mode = "strict"
+)
+
+// ParseMode parses a configured validation mode. An empty value
+// defaults to Lenient.
+func ParseMode(mode string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(mode))) {
+	case Lenient, "":
+		return Lenient, nil
+	case Strict:
+		return Strict, nil
+	default:
+		return "", fmt.Errorf("invalid plan validation mode %q: must be %q or %q", mode, Lenient, Strict)
+	}
+}
+
+// supportedExtensions lists the file types that are parsed as evaluation plans.
+var supportedExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+}
+
+// Finding describes a single problem found in an evaluation plan.
+type Finding struct {
+	Severity Severity
+	Path     string
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Path, f.Message)
+}
+
+// procedureLocation records where a procedure ID was declared.
+type procedureLocation struct {
+	path          string
+	catalogId     string
+	controlID     string
+	requirementID string
+}
+
+// Linter checks the evaluation plans loaded for a single mapper. Procedure
+// IDs are tracked across every plan passed to the same Linter.
+type Linter struct {
+	scope      mapper.Scope
+	mode       Mode
+	findings   []Finding
+	procedures map[string]procedureLocation
+}
+
+// New returns a Linter that checks references against the catalogs in
+// scope. A nil scope disables reference checks.
+func New(scope mapper.Scope, mode Mode) *Linter {
+	if mode == "" {
+		mode = Lenient
+	}
+	return &Linter{
+		scope:      scope,
+		mode:       mode,
+		procedures: make(map[string]procedureLocation),
+	}
+}
+
+// Errorf records an error finding for path.
+func (l *Linter) Errorf(path, format string, args ...any) {
+	l.report(Error, path, format, args...)
+}
+
+// Warnf records a warning finding for path. In Strict mode it is
+// recorded as an error.
+func (l *Linter) Warnf(path, format string, args ...any) {
+	severity := Warning
+	if l.mode == Strict {
+		severity = Error
+	}
+	l.report(severity, path, format, args...)
+}
+
+func (l *Linter) report(severity Severity, path, format string, args ...any) {
+	l.findings = append(l.findings, Finding{
+		Severity: severity,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// IsSupportedFile reports whether path has a file type that is parsed as
+// an evaluation plan.
+func IsSupportedFile(path string) bool {
+	return supportedExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// SupportedFile reports whether path should be parsed as an evaluation
+// plan, recording a finding for files that are skipped.
+func (l *Linter) SupportedFile(path string) bool {
+	if IsSupportedFile(path) {
+		return true
+	}
+	l.Warnf(path, "unsupported file type %q; skipping", strings.ToLower(filepath.Ext(path)))
+	return false
+}
+
+// CheckEvaluationPlan checks a parsed evaluation plan file for empty plans,
+// duplicate procedure IDs and references that do not resolve in scope.
+func (l *Linter) CheckEvaluationPlan(path string, evaluation layer4.EvaluationPlan) {
+	if len(evaluation.Plans) == 0 {
+		l.Warnf(path, "evaluation plan contains no plans")
+		return
+	}
+
+	for i, plan := range evaluation.Plans {
+		catalogId := plan.Control.ReferenceId
+		if catalogId == "" {
+			l.Warnf(path, "plan #%d (control %q) has no control reference-id; skipping", i, plan.Control.EntryId)
+			continue
+		}
+		l.checkControl(path, catalogId, plan.Control.EntryId)
+
+		procedureCount := 0
+		for _, assessment := range plan.Assessments {
+			requirementCatalogId := assessment.Requirement.ReferenceId
+			if requirementCatalogId == "" {
+				requirementCatalogId = catalogId
+			}
+			if requirementCatalogId != catalogId {
+				l.checkCatalog(path, requirementCatalogId, "requirement", assessment.Requirement.EntryId)
+			}
+			l.checkRequirement(path, requirementCatalogId, plan.Control.EntryId, assessment.Requirement.EntryId)
+
+			for _, procedure := range assessment.Procedures {
+				procedureCount++
+				l.checkProcedure(path, catalogId, plan.Control.EntryId, assessment.Requirement.EntryId, procedure.Id)
+			}
+		}
+
+		if procedureCount == 0 {
+			l.Warnf(path, "plan for control %q has no procedures", plan.Control.EntryId)
+		}
+	}
+}
+
+func (l *Linter) checkCatalog(path, catalogId, kind, entryID string) {
+	if l.scope == nil {
+		return
+	}
+	if _, ok := l.scope[catalogId]; !ok {
+		l.Warnf(path, "%s %q references catalog %q which is not loaded", kind, entryID, catalogId)
+	}
+}
+
+func (l *Linter) checkControl(path, catalogId, controlID string) {
+	l.checkCatalog(path, catalogId, "control", controlID)
+	catalog, ok := l.scope[catalogId]
+	if !ok {
+		return
+	}
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			if control.Id == controlID {
+				return
+			}
+		}
+	}
+	l.Warnf(path, "control %q not found in catalog %q", controlID, catalogId)
+}
+
+func (l *Linter) checkRequirement(path, catalogId, controlID, requirementID string) {
+	// Catalogs that are not loaded are reported by checkCatalog.
+	catalog, ok := l.scope[catalogId]
+	if !ok {
+		return
+	}
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			for _, requirement := range control.AssessmentRequirements {
+				if requirement.Id == requirementID {
+					if control.Id != controlID {
+						l.Warnf(path, "requirement %q belongs to control %q in catalog %q, not %q", requirementID, control.Id, catalogId, controlID)
+					}
+					return
+				}
+			}
+		}
+	}
+	l.Warnf(path, "requirement %q not found in catalog %q", requirementID, catalogId)
+}
+
+func (l *Linter) checkProcedure(path, catalogId, controlID, requirementID, procedureID string) {
+	if procedureID == "" {
+		l.Warnf(path, "procedure under requirement %q has no id", requirementID)
+		return
+	}
+
+	if previous, ok := l.procedures[procedureID]; ok {
+		where := "in the same file"
+		if previous.path != path {
+			where = "previously declared in " + previous.path
+		}
+		if previous.catalogId == catalogId {
+			l.Warnf(path, "duplicate procedure %q for catalog %q (%s under control %q, requirement %q); the last definition wins",
+				procedureID, catalogId, where, previous.controlID, previous.requirementID)
+		} else {
+			l.Warnf(path, "procedure %q is mapped in both catalog %q and catalog %q (%s); lookups are ambiguous",
+				procedureID, previous.catalogId, catalogId, where)
+		}
+	}
+	l.procedures[procedureID] = procedureLocation{
+		path:          path,
+		catalogId:     catalogId,
+		controlID:     controlID,
+		requirementID: requirementID,
+	}
+}
+
+// Findings returns every finding recorded so far.
+func (l *Linter) Findings() []Finding {
+	return l.findings
+}
+
+// Err returns an error summarizing the error findings, or nil if there
+// are none.
+func (l *Linter) Err() error {
+	var errs []error
+	for _, finding := range l.findings {
+		if finding.Severity == Error {
+			errs = append(errs, errors.New(finding.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
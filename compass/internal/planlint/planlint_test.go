@@ -0,0 +1,205 @@
+package planlint
+
+import (
+	"testing"
+
+	"github.com/ossf/gemara/layer2"
+	"github.com/ossf/gemara/layer4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/complytime/complybeacon/compass/mapper"
+)
+
+func testScope() mapper.Scope {
+	return mapper.Scope{
+		"test-catalog": layer2.Catalog{
+			Metadata: layer2.Metadata{Id: "test-catalog"},
+			ControlFamilies: []layer2.ControlFamily{
+				{
+					Title: "Access Control",
+					Controls: []layer2.Control{
+						{
+							Id:                     "AC-1",
+							AssessmentRequirements: []layer2.AssessmentRequirement{{Id: "AC-1.1"}},
+						},
+						{
+							Id:                     "AC-2",
+							AssessmentRequirements: []layer2.AssessmentRequirement{{Id: "AC-2.1"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testPlan(catalogId, controlId, requirementId string, procedureIds ...string) layer4.AssessmentPlan {
+	var procedures []layer4.AssessmentProcedure
+	for _, id := range procedureIds {
+		procedures = append(procedures, layer4.AssessmentProcedure{Id: id})
+	}
+	return layer4.AssessmentPlan{
+		Control: layer4.Mapping{ReferenceId: catalogId, EntryId: controlId},
+		Assessments: []layer4.Assessment{
+			{
+				Requirement: layer4.Mapping{ReferenceId: catalogId, EntryId: requirementId},
+				Procedures:  procedures,
+			},
+		},
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Mode
+		expectError bool
+	}{
+		{input: "", expected: Lenient},
+		{input: "lenient", expected: Lenient},
+		{input: " Strict ", expected: Strict},
+		{input: "pedantic", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			mode, err := ParseMode(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mode)
+		})
+	}
+}
+
+func TestLinter_SupportedFile(t *testing.T) {
+	linter := New(nil, Lenient)
+
+	assert.True(t, linter.SupportedFile("plans/plan.yaml"))
+	assert.True(t, linter.SupportedFile("plans/plan.YML"))
+	assert.True(t, linter.SupportedFile("plans/plan.json"))
+	assert.False(t, linter.SupportedFile("plans/README.md"))
+
+	findings := linter.Findings()
+	require.Len(t, findings, 1)
+	assert.Equal(t, Warning, findings[0].Severity)
+	assert.Equal(t, "plans/README.md", findings[0].Path)
+	assert.NoError(t, linter.Err())
+}
+
+func TestLinter_CheckEvaluationPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]layer4.EvaluationPlan
+		expected []string
+	}{
+		{
+			name: "valid plan",
+			files: map[string]layer4.EvaluationPlan{
+				"a.yaml": {Plans: []layer4.AssessmentPlan{testPlan("test-catalog", "AC-1", "AC-1.1", "proc-1")}},
+			},
+		},
+		{
+			name: "empty evaluation plan",
+			files: map[string]layer4.EvaluationPlan{
+				"a.yaml": {},
+			},
+			expected: []string{"evaluation plan contains no plans"},
+		},
+		{
+			name: "missing control reference-id",
+			files: map[string]layer4.EvaluationPlan{
+				"a.yaml": {Plans: []layer4.AssessmentPlan{testPlan("", "AC-1", "AC-1.1", "proc-1")}},
+			},
+			expected: []string{"has no control reference-id"},
+		},
+		{
+			name: "plan without procedures",
+			files: map[string]layer4.EvaluationPlan{
+				"a.yaml": {Plans: []layer4.AssessmentPlan{testPlan("test-catalog", "AC-1", "AC-1.1")}},
+			},
+			expected: []string{`plan for control "AC-1" has no procedures`},
+		},
+		{
+			name: "dangling references",
+			files: map[string]layer4.EvaluationPlan{
+				"a.yaml": {Plans: []layer4.AssessmentPlan{
+					testPlan("test-catalog", "AC-9", "AC-9.1", "proc-1"),
+					testPlan("test-catalog", "AC-1", "AC-2.1", "proc-2"),
+					testPlan("other-catalog", "AC-1", "AC-1.1", "proc-3"),
+				}},
+			},
+			expected: []string{
+				`control "AC-9" not found in catalog "test-catalog"`,
+				`requirement "AC-9.1" not found in catalog "test-catalog"`,
+				`requirement "AC-2.1" belongs to control "AC-2"`,
+				`control "AC-1" references catalog "other-catalog" which is not loaded`,
+			},
+		},
+		{
+			name: "duplicate procedure within a plan",
+			files: map[string]layer4.EvaluationPlan{
+				"a.yaml": {Plans: []layer4.AssessmentPlan{
+					testPlan("test-catalog", "AC-1", "AC-1.1", "proc-1", "proc-1"),
+				}},
+			},
+			expected: []string{`duplicate procedure "proc-1" for catalog "test-catalog" (in the same file`},
+		},
+		{
+			name: "duplicate procedure across plans",
+			files: map[string]layer4.EvaluationPlan{
+				"a.yaml": {Plans: []layer4.AssessmentPlan{testPlan("test-catalog", "AC-1", "AC-1.1", "proc-1")}},
+				"b.yaml": {Plans: []layer4.AssessmentPlan{testPlan("test-catalog", "AC-2", "AC-2.1", "proc-1")}},
+			},
+			expected: []string{`duplicate procedure "proc-1" for catalog "test-catalog" (previously declared in a.yaml`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linter := New(testScope(), Lenient)
+			for _, path := range []string{"a.yaml", "b.yaml"} {
+				if evaluation, ok := tt.files[path]; ok {
+					linter.CheckEvaluationPlan(path, evaluation)
+				}
+			}
+
+			findings := linter.Findings()
+			require.Len(t, findings, len(tt.expected), "findings: %v", findings)
+			for i, msg := range tt.expected {
+				assert.Contains(t, findings[i].Message, msg)
+				assert.Equal(t, Warning, findings[i].Severity)
+			}
+			assert.NoError(t, linter.Err())
+		})
+	}
+}
+
+func TestLinter_StrictMode(t *testing.T) {
+	linter := New(testScope(), Strict)
+	linter.CheckEvaluationPlan("a.yaml", layer4.EvaluationPlan{})
+	linter.Errorf("b.yaml", "failed to parse evaluation plan")
+
+	findings := linter.Findings()
+	require.Len(t, findings, 2)
+	assert.Equal(t, Error, findings[0].Severity)
+	assert.Equal(t, Error, findings[1].Severity)
+
+	err := linter.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a.yaml: evaluation plan contains no plans")
+	assert.Contains(t, err.Error(), "b.yaml: failed to parse evaluation plan")
+}
+
+func TestLinter_NilScopeSkipsReferenceChecks(t *testing.T) {
+	linter := New(nil, Strict)
+	linter.CheckEvaluationPlan("a.yaml", layer4.EvaluationPlan{
+		Plans: []layer4.AssessmentPlan{testPlan("missing-catalog", "AC-9", "AC-9.1", "proc-1")},
+	})
+
+	assert.Empty(t, linter.Findings())
+	assert.NoError(t, linter.Err())
+}
@@ -0,0 +1,208 @@
+package snapshot
+
+import (
+	"cmp"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/complytime/complybeacon/compass/api"
+	"github.com/complytime/complybeacon/compass/mapper"
+)
+
+// FormatVersion is the version of the snapshot document format.
+const FormatVersion = 1
+
+// Resolve maps every policy rule known to the mappers in set, sorted by
+// policy engine name and rule ID. The second return value is false when a
+// mapper cannot enumerate its rules, in which case the entries are
+// incomplete.
+func Resolve(set mapper.Set, scope mapper.Scope) ([]api.SnapshotEntry, bool) {
+	entries := []api.SnapshotEntry{}
+	complete := true
+	for id, mapperPlugin := range set {
+		enumerator, ok := mapperPlugin.(mapper.Enumerator)
+		if !ok {
+			complete = false
+			continue
+		}
+		for _, ruleID := range enumerator.PolicyRuleIDs() {
+			policy := api.Policy{PolicyEngineName: string(id), PolicyRuleId: ruleID}
+			entries = append(entries, api.SnapshotEntry{
+				Policy:     policy,
+				Compliance: mapperPlugin.Map(policy, scope),
+			})
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b api.SnapshotEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.Policy.PolicyEngineName, b.Policy.PolicyEngineName),
+			cmp.Compare(a.Policy.PolicyRuleId, b.Policy.PolicyRuleId),
+		)
+	})
+	return entries, complete
+}
+
+// Build resolves a snapshot of the mappings in set at the given content version.
+func Build(set mapper.Set, scope mapper.Scope, contentVersion string, now time.Time) api.Snapshot {
+	entries, complete := Resolve(set, scope)
+	return api.Snapshot{
+		FormatVersion:  FormatVersion,
+		ContentVersion: contentVersion,
+		GeneratedAt:    now.UTC(),
+		Complete:       complete,
+		Entries:        entries,
+	}
+}
+
+// Payload returns the bytes covered by a snapshot signature: the snapshot
+// serialized as JSON without its signature.
+func Payload(snapshot api.Snapshot) ([]byte, error) {
+	snapshot.Signature = nil
+	return json.Marshal(snapshot)
+}
+
+// Signer signs snapshots with a private key.
+type Signer struct {
+	key       crypto.Signer
+	algorithm api.SnapshotSignatureAlgorithm
+	keyID     string
+}
+
+// NewSigner returns a Signer for an Ed25519, ECDSA P-256 or RSA private key.
+func NewSigner(key crypto.Signer) (*Signer, error) {
+	var algorithm api.SnapshotSignatureAlgorithm
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		algorithm = api.Ed25519
+	case *ecdsa.PrivateKey:
+		if k.Curve.Params().Name != "P-256" {
+			return nil, fmt.Errorf("unsupported ECDSA curve %s: only P-256 is supported", k.Curve.Params().Name)
+		}
+		algorithm = api.ES256
+	case *rsa.PrivateKey:
+		algorithm = api.RS256
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", key)
+	}
+
+	keyID, err := KeyID(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{key: key, algorithm: algorithm, keyID: keyID}, nil
+}
+
+// LoadSigner reads a PEM encoded PKCS #8, SEC 1 or PKCS #1 private key.
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var key any
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing key type %T", key)
+	}
+	return NewSigner(signer)
+}
+
+// KeyID returns the key ID of a public key: the hex encoded first 16
+// bytes of the SHA-256 digest of its DER encoding.
+func KeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	digest := sha256.Sum256(der)
+	return hex.EncodeToString(digest[:16]), nil
+}
+
+// Sign sets the signature of snapshot.
+func (s *Signer) Sign(snapshot *api.Snapshot) error {
+	payload, err := Payload(*snapshot)
+	if err != nil {
+		return err
+	}
+
+	var signature []byte
+	switch s.algorithm {
+	case api.Ed25519:
+		signature, err = s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	default:
+		digest := sha256.Sum256(payload)
+		signature, err = s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sign snapshot: %w", err)
+	}
+
+	snapshot.Signature = &api.SnapshotSignature{
+		Algorithm: s.algorithm,
+		KeyId:     s.keyID,
+		Value:     signature,
+	}
+	return nil
+}
+
+// Verify checks the signature of snapshot against pub.
+func Verify(snapshot api.Snapshot, pub crypto.PublicKey) error {
+	if snapshot.Signature == nil {
+		return errors.New("snapshot is not signed")
+	}
+
+	payload, err := Payload(snapshot)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(payload)
+	signature := snapshot.Signature.Value
+
+	var valid bool
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		valid = snapshot.Signature.Algorithm == api.Ed25519 && ed25519.Verify(key, payload, signature)
+	case *ecdsa.PublicKey:
+		valid = snapshot.Signature.Algorithm == api.ES256 && ecdsa.VerifyASN1(key, digest[:], signature)
+	case *rsa.PublicKey:
+		valid = snapshot.Signature.Algorithm == api.RS256 && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	if !valid {
+		return errors.New("snapshot signature is invalid")
+	}
+	return nil
+}
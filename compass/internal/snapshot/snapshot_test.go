@@ -0,0 +1,192 @@
+package snapshot
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ossf/gemara/layer2"
+	"github.com/ossf/gemara/layer4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/complytime/complybeacon/compass/api"
+	"github.com/complytime/complybeacon/compass/mapper"
+	"github.com/complytime/complybeacon/compass/mapper/plugins/basic"
+)
+
+// opaqueMapper is a mapper that cannot enumerate its policy rules.
+type opaqueMapper struct{}
+
+func (opaqueMapper) PluginName() mapper.ID { return "opaque" }
+
+func (opaqueMapper) Map(api.Policy, mapper.Scope) api.Compliance { return api.Compliance{} }
+
+func (opaqueMapper) AddEvaluationPlan(string, ...layer4.AssessmentPlan) {}
+
+func testSet() (mapper.Set, mapper.Scope) {
+	scope := mapper.Scope{
+		"test-catalog": layer2.Catalog{
+			Metadata: layer2.Metadata{Id: "test-catalog"},
+			ControlFamilies: []layer2.ControlFamily{
+				{Title: "Access Control", Controls: []layer2.Control{{Id: "AC-1"}}},
+			},
+		},
+	}
+
+	newMapper := func(procedures ...string) mapper.Mapper {
+		var assessmentProcedures []layer4.AssessmentProcedure
+		for _, id := range procedures {
+			assessmentProcedures = append(assessmentProcedures, layer4.AssessmentProcedure{Id: id})
+		}
+		m := basic.NewBasicMapper()
+		m.AddEvaluationPlan("test-catalog", layer4.AssessmentPlan{
+			Control: layer4.Mapping{EntryId: "AC-1", ReferenceId: "test-catalog"},
+			Assessments: []layer4.Assessment{
+				{
+					Requirement: layer4.Mapping{EntryId: "AC-1.1", ReferenceId: "test-catalog"},
+					Procedures:  assessmentProcedures,
+				},
+			},
+		})
+		return m
+	}
+
+	return mapper.Set{
+		"engine-b": newMapper("rule-2", "rule-1"),
+		"engine-a": newMapper("rule-3"),
+	}, scope
+}
+
+func TestResolve(t *testing.T) {
+	set, scope := testSet()
+
+	entries, complete := Resolve(set, scope)
+	assert.True(t, complete)
+
+	var keys []api.Policy
+	for _, entry := range entries {
+		keys = append(keys, entry.Policy)
+		assert.Equal(t, api.Success, entry.Compliance.EnrichmentStatus)
+		assert.Equal(t, "AC-1.1", entry.Compliance.Control.Id)
+	}
+	assert.Equal(t, []api.Policy{
+		{PolicyEngineName: "engine-a", PolicyRuleId: "rule-3"},
+		{PolicyEngineName: "engine-b", PolicyRuleId: "rule-1"},
+		{PolicyEngineName: "engine-b", PolicyRuleId: "rule-2"},
+	}, keys)
+
+	set["opaque"] = opaqueMapper{}
+	entries, complete = Resolve(set, scope)
+	assert.False(t, complete)
+	assert.Len(t, entries, 3)
+}
+
+func TestBuild(t *testing.T) {
+	set, scope := testSet()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+
+	snapshot := Build(set, scope, "abc123", now)
+	assert.Equal(t, int32(FormatVersion), snapshot.FormatVersion)
+	assert.Equal(t, "abc123", snapshot.ContentVersion)
+	assert.Equal(t, now.UTC(), snapshot.GeneratedAt)
+	assert.True(t, snapshot.Complete)
+	assert.Len(t, snapshot.Entries, 3)
+	assert.Nil(t, snapshot.Signature)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		key       crypto.Signer
+		algorithm api.SnapshotSignatureAlgorithm
+	}{
+		{name: "ed25519", key: ed25519Key, algorithm: api.Ed25519},
+		{name: "ecdsa", key: ecdsaKey, algorithm: api.ES256},
+		{name: "rsa", key: rsaKey, algorithm: api.RS256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, scope := testSet()
+			snapshot := Build(set, scope, "abc123", time.Now())
+
+			signer, err := NewSigner(tt.key)
+			require.NoError(t, err)
+			require.NoError(t, signer.Sign(&snapshot))
+			require.NotNil(t, snapshot.Signature)
+			assert.Equal(t, tt.algorithm, snapshot.Signature.Algorithm)
+
+			keyID, err := KeyID(tt.key.Public())
+			require.NoError(t, err)
+			assert.Equal(t, keyID, snapshot.Signature.KeyId)
+
+			require.NoError(t, Verify(snapshot, tt.key.Public()))
+
+			snapshot.Entries[0].Compliance.Control.Id = "tampered"
+			assert.Error(t, Verify(snapshot, tt.key.Public()))
+		})
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		assert.Error(t, Verify(api.Snapshot{}, ed25519Key.Public()))
+	})
+
+	t.Run("unsupported curve", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+		_, err = NewSigner(key)
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadSigner(t *testing.T) {
+	dir := t.TempDir()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pkcs8Path := filepath.Join(dir, "ed25519.pem")
+	require.NoError(t, os.WriteFile(pkcs8Path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err = x509.MarshalECPrivateKey(ecdsaKey)
+	require.NoError(t, err)
+	sec1Path := filepath.Join(dir, "ecdsa.pem")
+	require.NoError(t, os.WriteFile(sec1Path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not a key"), 0600))
+
+	signer, err := LoadSigner(pkcs8Path)
+	require.NoError(t, err)
+	assert.Equal(t, api.Ed25519, signer.algorithm)
+
+	signer, err = LoadSigner(sec1Path)
+	require.NoError(t, err)
+	assert.Equal(t, api.ES256, signer.algorithm)
+
+	_, err = LoadSigner(invalidPath)
+	assert.Error(t, err)
+
+	_, err = LoadSigner(filepath.Join(dir, "missing.pem"))
+	assert.Error(t, err)
+}
@@ -15,6 +15,18 @@ type Mapper interface {
 	AddEvaluationPlan(catalogId string, plans ...layer4.AssessmentPlan)
 }
 
+// Explainer is an optional interface for mappers that can report
+// the steps taken to resolve a policy into compliance data.
+type Explainer interface {
+	Explain(policy api.Policy, scope Scope) (api.Compliance, []string)
+}
+
+// Enumerator is an optional interface for mappers that can list every
+// policy rule ID they have mappings for.
+type Enumerator interface {
+	PolicyRuleIDs() []string
+}
+
 // ID represents the identity for a transformer.
 type ID string
 
@@ -1,7 +1,10 @@
 package basic
 
 import (
+	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 
 	"github.com/ossf/gemara/layer2"
 	"github.com/ossf/gemara/layer4"
@@ -27,8 +30,10 @@ type ControlData struct {
 // requirements, and standards using the gemara framework.
 
 var (
-	_  mapper.Mapper = (*Mapper)(nil)
-	ID               = mapper.NewID("basic")
+	_  mapper.Mapper     = (*Mapper)(nil)
+	_  mapper.Explainer  = (*Mapper)(nil)
+	_  mapper.Enumerator = (*Mapper)(nil)
+	ID                   = mapper.NewID("basic")
 )
 
 type Mapper struct {
@@ -57,10 +62,39 @@ func (m *Mapper) PluginName() mapper.ID {
 
 // Map returns static compliance metadata for a policy rule
 func (m *Mapper) Map(policy api.Policy, scope mapper.Scope) api.Compliance {
+	return m.mapPolicy(policy, scope, nil)
+}
+
+// Explain maps a policy rule like Map and additionally returns a trace
+// describing each lookup step, for use by offline tooling.
+func (m *Mapper) Explain(policy api.Policy, scope mapper.Scope) (api.Compliance, []string) {
+	var trace tracer
+	compliance := m.mapPolicy(policy, scope, &trace)
+	return compliance, trace.steps
+}
+
+// tracer collects the lookup steps reported by Explain. Map passes a nil
+// tracer, and callers check for it before formatting a step, so lookups
+// on the enrichment path build no trace.
+type tracer struct {
+	steps []string
+}
+
+func (t *tracer) add(format string, args ...any) {
+	t.steps = append(t.steps, fmt.Sprintf(format, args...))
+}
+
+// mapPolicy maps a policy rule, recording each lookup step in trace when
+// it is not nil.
+func (m *Mapper) mapPolicy(policy api.Policy, scope mapper.Scope, trace *tracer) api.Compliance {
 	var failureReasons []string
 
-	// Process each catalog
-	for catalogId, plans := range m.plans {
+	if len(m.plans) == 0 && trace != nil {
+		trace.add("no evaluation plans loaded")
+	}
+	// Process each catalog in a stable order so results and traces are reproducible
+	for _, catalogId := range slices.Sorted(maps.Keys(m.plans)) {
+		plans := m.plans[catalogId]
 		catalog, ok := scope[catalogId]
 		if !ok {
 			slog.Warn("Catalog not found in scope for policy",
@@ -68,6 +102,9 @@ func (m *Mapper) Map(policy api.Policy, scope mapper.Scope) api.Compliance {
 				slog.String("policy_rule_id", policy.PolicyRuleId),
 			)
 			failureReasons = append(failureReasons, "catalog not found")
+			if trace != nil {
+				trace.add("catalog %q: not found in scope", catalogId)
+			}
 			continue
 		}
 
@@ -79,6 +116,10 @@ func (m *Mapper) Map(policy api.Policy, scope mapper.Scope) api.Compliance {
 
 		// Look up policy in procedures
 		if procedureInfo, ok := proceduresById[policy.PolicyRuleId]; ok {
+			if trace != nil {
+				trace.add("catalog %q: procedure %q found under control %q, requirement %q",
+					catalogId, policy.PolicyRuleId, procedureInfo.ControlID, procedureInfo.RequirementID)
+			}
 
 			// Look up control data
 			if ctrlData, ok := controlData[procedureInfo.ControlID]; ok {
@@ -95,6 +136,11 @@ func (m *Mapper) Map(policy api.Policy, scope mapper.Scope) api.Compliance {
 					},
 					EnrichmentStatus: api.Success,
 				}
+				if trace != nil {
+					trace.add("catalog %q: control %q found in family %q with %d guideline mapping(s)",
+						catalogId, procedureInfo.ControlID, ctrlData.Category, len(ctrlData.Mappings))
+					trace.add("result: %s", compliance.EnrichmentStatus)
+				}
 
 				return compliance
 			} else {
@@ -104,6 +150,9 @@ func (m *Mapper) Map(policy api.Policy, scope mapper.Scope) api.Compliance {
 					slog.String("policy_rule_id", policy.PolicyRuleId),
 				)
 				failureReasons = append(failureReasons, "control data not found")
+				if trace != nil {
+					trace.add("catalog %q: control %q not found in catalog", catalogId, procedureInfo.ControlID)
+				}
 			}
 		} else {
 			slog.Warn("Policy rule not found in procedures for catalog",
@@ -111,6 +160,9 @@ func (m *Mapper) Map(policy api.Policy, scope mapper.Scope) api.Compliance {
 				slog.String("catalog_id", catalogId),
 			)
 			failureReasons = append(failureReasons, "policy rule not found")
+			if trace != nil {
+				trace.add("catalog %q: procedure %q not found in %d plan(s)", catalogId, policy.PolicyRuleId, len(plans))
+			}
 		}
 	}
 
@@ -123,7 +175,7 @@ func (m *Mapper) Map(policy api.Policy, scope mapper.Scope) api.Compliance {
 		)
 	}
 
-	return api.Compliance{
+	compliance := api.Compliance{
 		Control: api.ComplianceControl{
 			Id:        "UNMAPPED",
 			CatalogId: "UNMAPPED",
@@ -135,6 +187,22 @@ func (m *Mapper) Map(policy api.Policy, scope mapper.Scope) api.Compliance {
 			Requirements: []string{},
 		},
 	}
+	if trace != nil {
+		trace.add("result: %s", compliance.EnrichmentStatus)
+	}
+
+	return compliance
+}
+
+// PolicyRuleIDs returns the sorted procedure IDs across all loaded plans.
+func (m *Mapper) PolicyRuleIDs() []string {
+	ids := make(map[string]struct{})
+	for _, plans := range m.plans {
+		for id := range m.buildProceduresMap(plans) {
+			ids[id] = struct{}{}
+		}
+	}
+	return slices.Sorted(maps.Keys(ids))
 }
 
 // buildProceduresMap builds a map of procedure ID to procedure info.
@@ -6,6 +6,7 @@ import (
 	"github.com/ossf/gemara/layer2"
 	"github.com/ossf/gemara/layer4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/complytime/complybeacon/compass/api"
 	"github.com/complytime/complybeacon/compass/mapper"
@@ -166,3 +167,79 @@ func TestBasicMapper_AddEvaluationPlan(t *testing.T) {
 		assert.Equal(t, "AC-2", basicMapper.plans["test-catalog"][1].Control.ReferenceId)
 	})
 }
+
+func TestBasicMapper_Explain(t *testing.T) {
+	basicMapper := NewBasicMapper()
+	basicMapper.AddEvaluationPlan("test-catalog", layer4.AssessmentPlan{
+		Control: layer4.Mapping{EntryId: "AC-1", ReferenceId: "test-catalog"},
+		Assessments: []layer4.Assessment{
+			{
+				Requirement: layer4.Mapping{EntryId: "AC-1-REQ", ReferenceId: "test-catalog"},
+				Procedures:  []layer4.AssessmentProcedure{{Id: "AC-1"}},
+			},
+		},
+	})
+	basicMapper.AddEvaluationPlan("missing-catalog", layer4.AssessmentPlan{
+		Control: layer4.Mapping{EntryId: "AC-2", ReferenceId: "missing-catalog"},
+	})
+
+	scope := mapper.Scope{
+		"test-catalog": layer2.Catalog{
+			Metadata: layer2.Metadata{Id: "test-catalog"},
+			ControlFamilies: []layer2.ControlFamily{
+				{Title: "Access Control", Controls: []layer2.Control{{Id: "AC-1"}}},
+			},
+		},
+	}
+
+	t.Run("mapped policy rule", func(t *testing.T) {
+		policy := api.Policy{PolicyEngineName: "test-policy-engine", PolicyRuleId: "AC-1"}
+		compliance, trace := basicMapper.Explain(policy, scope)
+
+		assert.Equal(t, api.Success, compliance.EnrichmentStatus)
+		assert.Equal(t, basicMapper.Map(policy, scope), compliance)
+		require.Len(t, trace, 4)
+		assert.Contains(t, trace[0], `catalog "missing-catalog": not found in scope`)
+		assert.Contains(t, trace[1], `procedure "AC-1" found under control "AC-1"`)
+		assert.Contains(t, trace[2], `control "AC-1" found in family "Access Control"`)
+		assert.Equal(t, "result: Success", trace[3])
+	})
+
+	t.Run("unmapped policy rule", func(t *testing.T) {
+		policy := api.Policy{PolicyEngineName: "test-policy-engine", PolicyRuleId: "AC-9"}
+		compliance, trace := basicMapper.Explain(policy, scope)
+
+		assert.Equal(t, api.Unmapped, compliance.EnrichmentStatus)
+		assert.Equal(t, basicMapper.Map(policy, scope), compliance)
+		require.Len(t, trace, 3)
+		assert.Contains(t, trace[1], `procedure "AC-9" not found in 1 plan(s)`)
+		assert.Equal(t, "result: Unmapped", trace[2])
+	})
+
+	t.Run("no plans loaded", func(t *testing.T) {
+		policy := api.Policy{PolicyEngineName: "test-policy-engine", PolicyRuleId: "AC-1"}
+		_, trace := NewBasicMapper().Explain(policy, scope)
+
+		assert.Equal(t, []string{"no evaluation plans loaded", "result: Unmapped"}, trace)
+	})
+}
+
+func TestBasicMapper_PolicyRuleIDs(t *testing.T) {
+	basicMapper := NewBasicMapper()
+	assert.Empty(t, basicMapper.PolicyRuleIDs())
+
+	basicMapper.AddEvaluationPlan("catalog-a", layer4.AssessmentPlan{
+		Control: layer4.Mapping{EntryId: "AC-1", ReferenceId: "catalog-a"},
+		Assessments: []layer4.Assessment{
+			{Procedures: []layer4.AssessmentProcedure{{Id: "proc-2"}, {Id: "proc-1"}}},
+		},
+	})
+	basicMapper.AddEvaluationPlan("catalog-b", layer4.AssessmentPlan{
+		Control: layer4.Mapping{EntryId: "AC-1", ReferenceId: "catalog-b"},
+		Assessments: []layer4.Assessment{
+			{Procedures: []layer4.AssessmentProcedure{{Id: "proc-1"}, {Id: "proc-3"}}},
+		},
+	})
+
+	assert.Equal(t, []string{"proc-1", "proc-2", "proc-3"}, basicMapper.PolicyRuleIDs())
+}
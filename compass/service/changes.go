@@ -0,0 +1,275 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+
+	"github.com/complytime/complybeacon/compass/api"
+	"github.com/complytime/complybeacon/compass/internal/exceptions"
+	"github.com/complytime/complybeacon/compass/internal/snapshot"
+	"github.com/complytime/complybeacon/compass/mapper"
+)
+
+const (
+	// maxChangeWait caps how long a change feed request may long poll.
+	maxChangeWait = 60 * time.Second
+	// maxChangeHistory is the number of revisions kept by the change feed.
+	// Clients further behind are told to reset.
+	maxChangeHistory = 64
+)
+
+// policyKey identifies a resolved enrichment by policy engine and rule.
+type policyKey struct {
+	engine string
+	rule   string
+}
+
+// resolveAll maps every policy rule known to the mappers in set. The
+// second return value is false when a mapper cannot enumerate its rules,
+// in which case the table is incomplete.
+func resolveAll(set mapper.Set, scope mapper.Scope, registry *exceptions.Registry, now time.Time) (map[policyKey]api.Compliance, bool) {
+	entries, complete := snapshot.Resolve(set, scope)
+	registry.ApplyAll(entries, now)
+	table := make(map[policyKey]api.Compliance, len(entries))
+	for _, entry := range entries {
+		table[policyKey{engine: entry.Policy.PolicyEngineName, rule: entry.Policy.PolicyRuleId}] = entry.Compliance
+	}
+	return table, complete
+}
+
+// changedKeys returns the keys whose compliance differs between two tables.
+func changedKeys(before, after map[policyKey]api.Compliance) []api.Policy {
+	var changes []api.Policy
+	for key, compliance := range after {
+		if previous, ok := before[key]; !ok || !reflect.DeepEqual(previous, compliance) {
+			changes = append(changes, api.Policy{PolicyEngineName: key.engine, PolicyRuleId: key.rule})
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, api.Policy{PolicyEngineName: key.engine, PolicyRuleId: key.rule})
+		}
+	}
+	return changes
+}
+
+// Reload replaces the mapping content and exceptions served by the Service
+// and publishes the policy rules whose enrichment changed to the change
+// feed.
+func (s *Service) Reload(transformers mapper.Set, scope mapper.Scope, registry *exceptions.Registry, version string) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	s.mu.RLock()
+	oldSet, oldScope, oldRegistry := s.set, s.scope, s.exceptions
+	s.mu.RUnlock()
+
+	now := time.Now()
+	before, completeBefore := resolveAll(oldSet, oldScope, oldRegistry, now)
+	after, completeAfter := resolveAll(transformers, scope, registry, now)
+	changes := changedKeys(before, after)
+	reset := !completeBefore || !completeAfter
+
+	s.mu.Lock()
+	s.set = transformers
+	s.scope = scope
+	s.exceptions = registry
+	s.etag = ""
+	if version != "" {
+		s.etag = strconv.Quote(version)
+	}
+	s.mu.Unlock()
+
+	if len(changes) == 0 && !reset {
+		slog.Info("mappings reloaded without changes", slog.String("content_version", version))
+		return
+	}
+
+	revision := s.feed.publish(changes, reset, version)
+	slog.Info("mappings reloaded",
+		slog.String("content_version", version),
+		slog.Int64("revision", revision),
+		slog.Int("changed", len(changes)),
+		slog.Bool("reset", reset),
+	)
+}
+
+// GetV1Changes handles the GET /v1/changes endpoint.
+// It's a handler function for Gin.
+func (s *Service) GetV1Changes(c *gin.Context, params api.GetV1ChangesParams) {
+	feed, notify := s.feed.since(params.Since, params.Epoch)
+
+	// Long poll only when the client is up to date.
+	if params.Since != nil && params.Wait != nil && *params.Wait > 0 && !feed.Reset && len(feed.Changes) == 0 {
+		wait := min(time.Duration(*params.Wait)*time.Second, maxChangeWait)
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-notify:
+			feed, _ = s.feed.since(params.Since, params.Epoch)
+		case <-timer.C:
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+
+	slog.Debug("change feed request",
+		slog.String("request_id", requestid.Get(c)),
+		slog.String("epoch", feed.Epoch),
+		slog.Int64("revision", feed.Revision),
+		slog.Int("changes", len(feed.Changes)),
+		slog.Bool("reset", feed.Reset),
+	)
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// change records the keys published at a revision.
+type change struct {
+	revision int64
+	// epoch is the epoch the change replaced.
+	epoch string
+	keys  []api.Policy
+	reset bool
+}
+
+// changeFeed keeps a bounded history of mapping changes by revision.
+// Revisions count from 1 in every process, so clients also pass the epoch
+// they last saw. The epoch is the content version being served, so
+// replicas behind the same load balancer and restarted processes agree on
+// it as long as they serve the same content, and each change records the
+// epoch it replaced so a client can be answered from any replica that
+// went through the same change. Without a content version, the epoch is
+// chosen at random.
+type changeFeed struct {
+	mu       sync.Mutex
+	epoch    string
+	revision int64
+	history  []change
+	// notify is closed and replaced on every publish to wake long polls.
+	notify chan struct{}
+}
+
+func newChangeFeed(version string) *changeFeed {
+	return &changeFeed{
+		epoch:    newEpoch(version),
+		revision: 1,
+		notify:   make(chan struct{}),
+	}
+}
+
+// newEpoch returns the epoch of a content version, or a random identifier
+// when the version is not known.
+func newEpoch(version string) string {
+	if version != "" {
+		return version
+	}
+	var epoch [8]byte
+	_, _ = rand.Read(epoch[:])
+	return hex.EncodeToString(epoch[:])
+}
+
+// publish records a new revision for content version and wakes any
+// waiting requests.
+func (f *changeFeed) publish(keys []api.Policy, reset bool, version string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.revision++
+	f.history = append(f.history, change{revision: f.revision, epoch: f.epoch, keys: keys, reset: reset})
+	f.epoch = newEpoch(version)
+	// Results can change without the content, as exceptions expire, and a
+	// client must not take the new revision for the one it has seen.
+	if f.epoch == f.history[len(f.history)-1].epoch {
+		f.epoch = fmt.Sprintf("%s.%d", f.epoch, f.revision)
+	}
+	if len(f.history) > maxChangeHistory {
+		f.history = f.history[len(f.history)-maxChangeHistory:]
+	}
+
+	close(f.notify)
+	f.notify = make(chan struct{})
+	return f.revision
+}
+
+// since returns the changes after the given revision of epoch, and a
+// channel that is closed on the next publish. When epoch is set, the
+// changes are found by epoch and the revision is not used.
+func (f *changeFeed) since(since *int64, epoch *string) (api.ChangeFeed, <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	feed := api.ChangeFeed{
+		Epoch:    f.epoch,
+		Revision: f.revision,
+		Changes:  []api.Policy{},
+	}
+	if since == nil {
+		return feed, f.notify
+	}
+
+	if epoch != nil {
+		if *epoch == f.epoch {
+			return feed, f.notify
+		}
+		// The most recent change away from the client's epoch, if it is
+		// still retained; a restarted compass or a replica serving other
+		// content does not know it.
+		for i := len(f.history) - 1; i >= 0; i-- {
+			if f.history[i].epoch == *epoch {
+				return collect(feed, f.history[i:]), f.notify
+			}
+		}
+		feed.Reset = true
+		return feed, f.notify
+	}
+
+	if *since == f.revision {
+		return feed, f.notify
+	}
+
+	// A revision from the future means compass restarted, and one older
+	// than the retained history cannot be replayed.
+	oldest := f.revision
+	if len(f.history) > 0 {
+		oldest = f.history[0].revision
+	}
+	if *since > f.revision || *since < oldest-1 {
+		feed.Reset = true
+		return feed, f.notify
+	}
+	start := sort.Search(len(f.history), func(i int) bool { return f.history[i].revision > *since })
+	return collect(feed, f.history[start:]), f.notify
+}
+
+// collect adds the distinct keys of changes to feed, or resets it when one
+// of them is a reset.
+func collect(feed api.ChangeFeed, changes []change) api.ChangeFeed {
+	seen := make(map[api.Policy]bool)
+	for _, entry := range changes {
+		if entry.reset {
+			feed.Reset = true
+			feed.Changes = []api.Policy{}
+			return feed
+		}
+		for _, key := range entry.keys {
+			if !seen[key] {
+				seen[key] = true
+				feed.Changes = append(feed.Changes, key)
+			}
+		}
+	}
+	return feed
+}
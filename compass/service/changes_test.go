@@ -0,0 +1,252 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ossf/gemara/layer2"
+	"github.com/ossf/gemara/layer4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/complytime/complybeacon/compass/api"
+	"github.com/complytime/complybeacon/compass/internal/exceptions"
+	"github.com/complytime/complybeacon/compass/mapper"
+	"github.com/complytime/complybeacon/compass/mapper/plugins/basic"
+)
+
+func changesScope() mapper.Scope {
+	return mapper.Scope{
+		"test-catalog": layer2.Catalog{
+			Metadata: layer2.Metadata{Id: "test-catalog"},
+			ControlFamilies: []layer2.ControlFamily{
+				{
+					Title: "Access Control",
+					Controls: []layer2.Control{
+						{Id: "AC-1"},
+						{Id: "AC-2"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// changesSet returns a mapper set for "test-policy-engine" mapping each
+// procedure to the given control.
+func changesSet(procedures map[string]string) mapper.Set {
+	mapperPlugin := basic.NewBasicMapper()
+	for procedureID, controlID := range procedures {
+		mapperPlugin.AddEvaluationPlan("test-catalog", layer4.AssessmentPlan{
+			Control: layer4.Mapping{EntryId: controlID, ReferenceId: "test-catalog"},
+			Assessments: []layer4.Assessment{
+				{
+					Requirement: layer4.Mapping{EntryId: controlID + ".1", ReferenceId: "test-catalog"},
+					Procedures:  []layer4.AssessmentProcedure{{Id: procedureID}},
+				},
+			},
+		})
+	}
+	return mapper.Set{"test-policy-engine": mapperPlugin}
+}
+
+func TestChangeFeed_Since(t *testing.T) {
+	feed := newChangeFeed("")
+	rev := func(r int64) *int64 { return &r }
+	keyA := api.Policy{PolicyEngineName: "engine", PolicyRuleId: "a"}
+	keyB := api.Policy{PolicyEngineName: "engine", PolicyRuleId: "b"}
+
+	current, _ := feed.since(nil, nil)
+	assert.Equal(t, int64(1), current.Revision)
+	assert.Empty(t, current.Changes)
+	assert.False(t, current.Reset)
+
+	assert.Equal(t, int64(2), feed.publish([]api.Policy{keyA}, false, ""))
+	assert.Equal(t, int64(3), feed.publish([]api.Policy{keyA, keyB}, false, ""))
+
+	tests := []struct {
+		name     string
+		since    *int64
+		expected []api.Policy
+		reset    bool
+	}{
+		{name: "no since", since: nil, expected: []api.Policy{}},
+		{name: "up to date", since: rev(3), expected: []api.Policy{}},
+		{name: "one behind", since: rev(2), expected: []api.Policy{keyA, keyB}},
+		{name: "two behind deduplicates", since: rev(1), expected: []api.Policy{keyA, keyB}},
+		{name: "revision from the future", since: rev(10), expected: []api.Policy{}, reset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := feed.since(tt.since, nil)
+			assert.Equal(t, int64(3), result.Revision)
+			assert.Equal(t, tt.expected, result.Changes)
+			assert.Equal(t, tt.reset, result.Reset)
+		})
+	}
+
+	t.Run("history exhausted", func(t *testing.T) {
+		for i := 0; i < maxChangeHistory; i++ {
+			feed.publish([]api.Policy{keyA}, false, "")
+		}
+		result, _ := feed.since(rev(2), nil)
+		assert.True(t, result.Reset)
+		assert.Empty(t, result.Changes)
+	})
+
+	t.Run("other epoch", func(t *testing.T) {
+		current, _ := feed.since(nil, nil)
+		assert.NotEmpty(t, current.Epoch)
+		result, _ := feed.since(&current.Revision, &current.Epoch)
+		assert.False(t, result.Reset)
+
+		other := newChangeFeed("")
+		assert.NotEqual(t, current.Epoch, other.epoch)
+		result, _ = feed.since(&current.Revision, &other.epoch)
+		assert.True(t, result.Reset)
+		assert.Equal(t, current.Epoch, result.Epoch)
+	})
+
+	t.Run("reset entry", func(t *testing.T) {
+		current, _ := feed.since(nil, nil)
+		feed.publish(nil, true, "")
+		result, _ := feed.since(&current.Revision, nil)
+		assert.True(t, result.Reset)
+	})
+}
+
+func TestChangeFeed_SameContent(t *testing.T) {
+	// Two replicas serving the same content, one of them restarted after
+	// the reload, answer a client alternating between them.
+	keyA := api.Policy{PolicyEngineName: "engine", PolicyRuleId: "a"}
+	first := newChangeFeed("v1")
+	second := newChangeFeed("v1")
+
+	current, _ := first.since(nil, nil)
+	assert.Equal(t, "v1", current.Epoch)
+	result, _ := second.since(&current.Revision, &current.Epoch)
+	assert.False(t, result.Reset)
+	assert.Empty(t, result.Changes)
+
+	first.publish([]api.Policy{keyA}, false, "v2")
+	second.publish([]api.Policy{keyA}, false, "v2")
+	restarted := newChangeFeed("v2")
+
+	for _, feed := range []*changeFeed{first, second} {
+		result, _ = feed.since(&current.Revision, &current.Epoch)
+		assert.False(t, result.Reset)
+		assert.Equal(t, []api.Policy{keyA}, result.Changes)
+		assert.Equal(t, "v2", result.Epoch)
+	}
+	for _, feed := range []*changeFeed{first, second, restarted} {
+		result, _ = feed.since(&result.Revision, &result.Epoch)
+		assert.False(t, result.Reset)
+		assert.Empty(t, result.Changes)
+	}
+
+	// A replica that has not seen the client's content yet resets it.
+	stale := newChangeFeed("v1")
+	result, _ = stale.since(&result.Revision, &result.Epoch)
+	assert.True(t, result.Reset)
+
+	// Changes without new content still move to a new epoch.
+	first.publish([]api.Policy{keyA}, false, "v2")
+	result, _ = first.since(nil, nil)
+	assert.NotEqual(t, "v2", result.Epoch)
+	epoch := "v2"
+	result, _ = first.since(&result.Revision, &epoch)
+	assert.Equal(t, []api.Policy{keyA}, result.Changes)
+}
+
+func TestService_Reload(t *testing.T) {
+	scope := changesScope()
+	service := NewService(changesSet(map[string]string{"proc-1": "AC-1", "proc-2": "AC-2"}), scope,
+		WithContentVersion("v1"))
+
+	service.Reload(changesSet(map[string]string{"proc-1": "AC-1", "proc-2": "AC-2"}), scope, nil, "v1")
+	result, _ := service.feed.since(nil, nil)
+	assert.Equal(t, int64(1), result.Revision, "unchanged content should not publish a revision")
+
+	service.Reload(changesSet(map[string]string{"proc-1": "AC-1", "proc-2": "AC-1", "proc-3": "AC-2"}), scope, nil, "v2")
+	assert.Equal(t, `"v2"`, service.etag)
+
+	since := int64(1)
+	result, _ = service.feed.since(&since, nil)
+	assert.Equal(t, int64(2), result.Revision)
+	assert.False(t, result.Reset)
+	assert.ElementsMatch(t, []api.Policy{
+		{PolicyEngineName: "test-policy-engine", PolicyRuleId: "proc-2"},
+		{PolicyEngineName: "test-policy-engine", PolicyRuleId: "proc-3"},
+	}, result.Changes)
+
+	service.Reload(changesSet(map[string]string{"proc-1": "AC-1"}), scope, nil, "v3")
+	since = 2
+	result, _ = service.feed.since(&since, nil)
+	assert.ElementsMatch(t, []api.Policy{
+		{PolicyEngineName: "test-policy-engine", PolicyRuleId: "proc-2"},
+		{PolicyEngineName: "test-policy-engine", PolicyRuleId: "proc-3"},
+	}, result.Changes)
+}
+
+func TestService_ReloadExceptions(t *testing.T) {
+	scope := changesScope()
+	set := changesSet(map[string]string{"proc-1": "AC-1", "proc-2": "AC-2"})
+	service := NewService(set, scope, WithContentVersion("v1"))
+
+	registry, err := exceptions.New([]exceptions.Exception{
+		{Id: "EX-1", Control: "AC-1.1", Rule: "proc-1", Approver: "a", Reason: "r", Expires: "2999-01-01"},
+	})
+	require.NoError(t, err)
+
+	service.Reload(set, scope, registry, "v2")
+	since := int64(1)
+	result, _ := service.feed.since(&since, nil)
+	assert.Equal(t, []api.Policy{{PolicyEngineName: "test-policy-engine", PolicyRuleId: "proc-1"}}, result.Changes,
+		"a new exception should publish the excepted policy rule")
+}
+
+func TestGetV1Changes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := NewService(changesSet(map[string]string{"proc-1": "AC-1"}), changesScope())
+	r := gin.New()
+	api.RegisterHandlers(r, service)
+
+	changes := func(query string) api.ChangeFeed {
+		req := httptest.NewRequest(http.MethodGet, "/v1/changes"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var feed api.ChangeFeed
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &feed))
+		return feed
+	}
+
+	feed := changes("")
+	assert.Equal(t, int64(1), feed.Revision)
+	assert.Empty(t, feed.Changes)
+
+	done := make(chan api.ChangeFeed)
+	go func() {
+		done <- changes(fmt.Sprintf("?since=%d&wait=30", feed.Revision))
+	}()
+
+	// Give the request time to start waiting before publishing.
+	time.Sleep(50 * time.Millisecond)
+	service.Reload(changesSet(map[string]string{"proc-1": "AC-2"}), changesScope(), nil, "v2")
+
+	select {
+	case feed = <-done:
+		assert.Equal(t, int64(2), feed.Revision)
+		assert.Equal(t, []api.Policy{{PolicyEngineName: "test-policy-engine", PolicyRuleId: "proc-1"}}, feed.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("long poll did not return after reload")
+	}
+}
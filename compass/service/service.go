@@ -1,34 +1,100 @@
 package service
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 
 	"github.com/complytime/complybeacon/compass/api"
+	"github.com/complytime/complybeacon/compass/internal/exceptions"
+	"github.com/complytime/complybeacon/compass/internal/snapshot"
 	"github.com/complytime/complybeacon/compass/mapper"
 	"github.com/complytime/complybeacon/compass/mapper/plugins/basic"
 )
 
+// DefaultCacheMaxAge is the Cache-Control max-age returned with
+// enrichment responses when none is configured.
+const DefaultCacheMaxAge = 5 * time.Minute
+
 // Service struct to hold dependencies if needed
 type Service struct {
-	set   mapper.Set
-	scope mapper.Scope
+	// mu guards the mapping content, which is replaced by Reload.
+	mu         sync.RWMutex
+	set        mapper.Set
+	scope      mapper.Scope
+	exceptions *exceptions.Registry
+	etag       string
+	maxAge     time.Duration
+	signer     *snapshot.Signer
+
+	reloadMu sync.Mutex
+	feed     *changeFeed
+}
+
+// Option configures optional Service behavior.
+type Option func(*Service)
+
+// WithContentVersion sets the content version of the loaded catalogs and
+// evaluation plans. It is returned as the ETag of enrichment responses.
+// Without a content version, no caching headers are sent.
+func WithContentVersion(version string) Option {
+	return func(s *Service) {
+		if version != "" {
+			s.etag = strconv.Quote(version)
+		}
+	}
+}
+
+// WithCacheMaxAge sets the Cache-Control max-age returned with enrichment
+// responses. A zero value keeps DefaultCacheMaxAge.
+func WithCacheMaxAge(maxAge time.Duration) Option {
+	return func(s *Service) {
+		if maxAge > 0 {
+			s.maxAge = maxAge
+		}
+	}
+}
+
+// WithSnapshotSigner signs the snapshots returned by GET /v1/snapshot.
+func WithSnapshotSigner(signer *snapshot.Signer) Option {
+	return func(s *Service) {
+		s.signer = signer
+	}
+}
+
+// WithExceptions attaches the active exceptions in registry to enrichment
+// results.
+func WithExceptions(registry *exceptions.Registry) Option {
+	return func(s *Service) {
+		s.exceptions = registry
+	}
 }
 
 // NewService initializes a new Service instance.
-func NewService(transformers mapper.Set, scope mapper.Scope) *Service {
-	return &Service{
-		set:   transformers,
-		scope: scope,
+func NewService(transformers mapper.Set, scope mapper.Scope, opts ...Option) *Service {
+	s := &Service{
+		set:    transformers,
+		scope:  scope,
+		maxAge: DefaultCacheMaxAge,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	version, _ := strconv.Unquote(s.etag)
+	s.feed = newChangeFeed(version)
+	return s
 }
 
 // PostV1Enrich handles the POST /v1/enrich endpoint.
 // It's a handler function for Gin.
-func (s *Service) PostV1Enrich(c *gin.Context) {
+func (s *Service) PostV1Enrich(c *gin.Context, params api.PostV1EnrichParams) {
 	var req api.EnrichmentRequest
 	err := c.Bind(&req)
 	if err != nil {
@@ -46,12 +112,138 @@ func (s *Service) PostV1Enrich(c *gin.Context) {
 		slog.String("policy_engine_name", req.Policy.PolicyEngineName),
 	)
 
-	mapperPlugin, ok := s.set[mapper.ID(req.Policy.PolicyEngineName)]
+	s.mu.RLock()
+	set, scope, registry, etag := s.set, s.scope, s.exceptions, s.etag
+	s.mu.RUnlock()
+
+	if etag != "" {
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", fmt.Sprintf("max-age=%d", int64(s.maxAge/time.Second)))
+
+		// The ETag only depends on the loaded content, so a matching
+		// revalidation can be answered without mapping the policy.
+		if params.IfNoneMatch != nil && etagMatches(*params.IfNoneMatch, etag) {
+			slog.Debug("enrich not modified",
+				slog.String("request_id", requestid.Get(c)),
+				slog.String("etag", etag),
+			)
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	compliance := mapPolicy(c, set, scope, req.Policy)
+	registry.Apply(req.Policy, &compliance, time.Now())
+	enrichedResponse := api.EnrichmentResponse{
+		Compliance: compliance,
+	}
+
+	slog.Debug("enrich result",
+		slog.String("request_id", requestid.Get(c)),
+		slog.String("mapping_status", string(enrichedResponse.Compliance.EnrichmentStatus)),
+		slog.String("compliance_catalog", enrichedResponse.Compliance.Control.CatalogId),
+		slog.String("compliance_control", enrichedResponse.Compliance.Control.Id),
+	)
+
+	c.JSON(http.StatusOK, enrichedResponse)
+}
+
+// GetV1Snapshot handles the GET /v1/snapshot endpoint.
+// It's a handler function for Gin.
+func (s *Service) GetV1Snapshot(c *gin.Context) {
+	s.mu.RLock()
+	set, scope, registry, etag := s.set, s.scope, s.exceptions, s.etag
+	s.mu.RUnlock()
+
+	contentVersion, _ := strconv.Unquote(etag)
+	now := time.Now()
+	resolved := snapshot.Build(set, scope, contentVersion, now)
+	registry.ApplyAll(resolved.Entries, now)
+	if s.signer != nil {
+		if err := s.signer.Sign(&resolved); err != nil {
+			slog.Error("failed to sign snapshot",
+				slog.String("request_id", requestid.Get(c)),
+				slog.String("error", err.Error()),
+			)
+			sendCompassError(c, http.StatusInternalServerError, "Failed to sign snapshot")
+			return
+		}
+	}
+
+	slog.Debug("snapshot exported",
+		slog.String("request_id", requestid.Get(c)),
+		slog.String("content_version", contentVersion),
+		slog.Int("entries", len(resolved.Entries)),
+		slog.Bool("complete", resolved.Complete),
+		slog.Bool("signed", resolved.Signature != nil),
+	)
+
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	c.JSON(http.StatusOK, resolved)
+}
+
+// maxBatchSize is the largest number of policies accepted by a batch
+// enrichment request.
+const maxBatchSize = 1000
+
+// PostV1EnrichBatch handles the POST /v1/enrich/batch endpoint.
+// It's a handler function for Gin.
+func (s *Service) PostV1EnrichBatch(c *gin.Context) {
+	var req api.BatchEnrichmentRequest
+	err := c.Bind(&req)
+	if err != nil {
+		slog.Warn("invalid batch enrichment request",
+			slog.String("request_id", requestid.Get(c)),
+			slog.String("error", err.Error()),
+		)
+		sendCompassError(c, http.StatusBadRequest, "Invalid format for batch enrichment")
+		return
+	}
+	if len(req.Policies) == 0 || len(req.Policies) > maxBatchSize {
+		sendCompassError(c, http.StatusBadRequest, fmt.Sprintf("Batch must contain between 1 and %d policies", maxBatchSize))
+		return
+	}
+
+	slog.Debug("batch enrich request received",
+		slog.String("request_id", requestid.Get(c)),
+		slog.Int("policies", len(req.Policies)),
+	)
+
+	s.mu.RLock()
+	set, scope, registry, etag := s.set, s.scope, s.exceptions, s.etag
+	s.mu.RUnlock()
+
+	now := time.Now()
+	response := api.BatchEnrichmentResponse{
+		Results: make([]api.EnrichmentResult, 0, len(req.Policies)),
+	}
+	for _, policy := range req.Policies {
+		compliance := mapPolicy(c, set, scope, policy)
+		registry.Apply(policy, &compliance, now)
+		response.Results = append(response.Results, api.EnrichmentResult{
+			Policy:     policy,
+			Compliance: compliance,
+		})
+	}
+
+	if etag != "" {
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", fmt.Sprintf("max-age=%d", int64(s.maxAge/time.Second)))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// mapPolicy maps policy with the mapper for its policy engine, falling
+// back to the basic mapper.
+func mapPolicy(c *gin.Context, set mapper.Set, scope mapper.Scope, policy api.Policy) api.Compliance {
+	mapperPlugin, ok := set[mapper.ID(policy.PolicyEngineName)]
 	if !ok {
 		// Use fallback
 		slog.Warn("Policy engine not found in mapper set, using basic mapper fallback",
 			slog.String("request_id", requestid.Get(c)),
-			slog.String("policy_engine_name", req.Policy.PolicyEngineName),
+			slog.String("policy_engine_name", policy.PolicyEngineName),
 		)
 		mapperPlugin = basic.NewBasicMapper()
 	}
@@ -62,19 +254,19 @@ func (s *Service) PostV1Enrich(c *gin.Context) {
 		slog.Bool("fallback_used", !ok),
 	)
 
-	compliance := mapperPlugin.Map(req.Policy, s.scope)
-	enrichedResponse := api.EnrichmentResponse{
-		Compliance: compliance,
-	}
-
-	slog.Debug("enrich result",
-		slog.String("request_id", requestid.Get(c)),
-		slog.String("mapping_status", string(enrichedResponse.Compliance.EnrichmentStatus)),
-		slog.String("compliance_catalog", enrichedResponse.Compliance.Control.CatalogId),
-		slog.String("compliance_control", enrichedResponse.Compliance.Control.Id),
-	)
+	return mapperPlugin.Map(policy, scope)
+}
 
-	c.JSON(http.StatusOK, enrichedResponse)
+// etagMatches reports whether an If-None-Match header value matches etag.
+// Weak comparison is used, as permitted for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
 }
 
 // sendCompassError wraps sending of an error in the Error format, and
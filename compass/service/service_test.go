@@ -1,17 +1,26 @@
 package service
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/complytime/complybeacon/compass/mapper/plugins/basic"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
 	"github.com/ossf/gemara/layer2"
 	"github.com/ossf/gemara/layer4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/complytime/complybeacon/compass/api"
+	"github.com/complytime/complybeacon/compass/internal/exceptions"
+	"github.com/complytime/complybeacon/compass/internal/snapshot"
 	"github.com/complytime/complybeacon/compass/mapper"
 )
 
@@ -24,6 +33,194 @@ func TestNewService(t *testing.T) {
 	assert.NotNil(t, service)
 	assert.Equal(t, mappers, service.set)
 	assert.Equal(t, scope, service.scope)
+	assert.Empty(t, service.etag)
+	assert.Equal(t, DefaultCacheMaxAge, service.maxAge)
+}
+
+func TestNewServiceWithOptions(t *testing.T) {
+	service := NewService(make(mapper.Set), make(mapper.Scope),
+		WithContentVersion("abc123"),
+		WithCacheMaxAge(time.Minute),
+	)
+
+	assert.Equal(t, `"abc123"`, service.etag)
+	assert.Equal(t, time.Minute, service.maxAge)
+
+	service = NewService(make(mapper.Set), make(mapper.Scope), WithContentVersion(""), WithCacheMaxAge(0))
+	assert.Empty(t, service.etag)
+	assert.Equal(t, DefaultCacheMaxAge, service.maxAge)
+}
+
+func TestPostV1EnrichConditional(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(opts ...Option) *gin.Engine {
+		r := gin.New()
+		api.RegisterHandlers(r, NewService(make(mapper.Set), make(mapper.Scope), opts...))
+		return r
+	}
+
+	enrich := func(r *gin.Engine, ifNoneMatch string) *httptest.ResponseRecorder {
+		body := `{"policy": {"policyEngineName": "test-policy-engine", "policyRuleId": "AC-1"}}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/enrich", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("no content version sends no caching headers", func(t *testing.T) {
+		w := enrich(newRouter(), `"abc123"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+		assert.Empty(t, w.Header().Get("Cache-Control"))
+	})
+
+	r := newRouter(WithContentVersion("abc123"), WithCacheMaxAge(90*time.Second))
+
+	tests := []struct {
+		name         string
+		ifNoneMatch  string
+		expectedCode int
+	}{
+		{name: "no If-None-Match", expectedCode: http.StatusOK},
+		{name: "matching ETag", ifNoneMatch: `"abc123"`, expectedCode: http.StatusNotModified},
+		{name: "weak matching ETag in list", ifNoneMatch: `"old", W/"abc123"`, expectedCode: http.StatusNotModified},
+		{name: "wildcard", ifNoneMatch: "*", expectedCode: http.StatusNotModified},
+		{name: "stale ETag", ifNoneMatch: `"old"`, expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := enrich(r, tt.ifNoneMatch)
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, `"abc123"`, w.Header().Get("ETag"))
+			assert.Equal(t, "max-age=90", w.Header().Get("Cache-Control"))
+
+			if tt.expectedCode == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+				return
+			}
+			var response api.EnrichmentResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, api.Unmapped, response.Compliance.EnrichmentStatus)
+		})
+	}
+}
+
+func TestPostV1EnrichBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	set := changesSet(map[string]string{"proc-1": "AC-1"})
+	r := gin.New()
+	api.RegisterHandlers(r, NewService(set, changesScope(), WithContentVersion("abc123")))
+
+	batch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/enrich/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := batch(`{"policies": [
+		{"policyEngineName": "test-policy-engine", "policyRuleId": "proc-1"},
+		{"policyEngineName": "test-policy-engine", "policyRuleId": "unknown"},
+		{"policyEngineName": "other-engine", "policyRuleId": "proc-1"}
+	]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"abc123"`, w.Header().Get("ETag"))
+	assert.NotEmpty(t, w.Header().Get("Cache-Control"))
+
+	var response api.BatchEnrichmentResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Results, 3)
+	assert.Equal(t, "proc-1", response.Results[0].Policy.PolicyRuleId)
+	assert.Equal(t, api.Success, response.Results[0].Compliance.EnrichmentStatus)
+	assert.Equal(t, "AC-1.1", response.Results[0].Compliance.Control.Id)
+	assert.Equal(t, api.Unmapped, response.Results[1].Compliance.EnrichmentStatus)
+	assert.Equal(t, "other-engine", response.Results[2].Policy.PolicyEngineName)
+	assert.Equal(t, api.Unmapped, response.Results[2].Compliance.EnrichmentStatus)
+
+	w = batch(`{"policies": []}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = batch(`{"policies": "not-a-list"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPostV1EnrichExceptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry, err := exceptions.New([]exceptions.Exception{
+		{Id: "EX-1", Control: "AC-1.1", Rule: "proc-1", Target: "cluster-a", Approver: "security-team", Reason: "Migration", Expires: "2999-01-01"},
+	})
+	require.NoError(t, err)
+
+	set := changesSet(map[string]string{"proc-1": "AC-1", "proc-2": "AC-1"})
+	r := gin.New()
+	api.RegisterHandlers(r, NewService(set, changesScope(), WithExceptions(registry)))
+
+	enrich := func(ruleID string) api.EnrichmentResponse {
+		body := `{"policy": {"policyEngineName": "test-policy-engine", "policyRuleId": "` + ruleID + `"}}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/enrich", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response api.EnrichmentResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := enrich("proc-1")
+	require.NotNil(t, response.Compliance.Exceptions)
+	require.Len(t, *response.Compliance.Exceptions, 1)
+	exception := (*response.Compliance.Exceptions)[0]
+	assert.Equal(t, "EX-1", exception.Id)
+	assert.Equal(t, "security-team", exception.Approver)
+	require.NotNil(t, exception.Target)
+	assert.Equal(t, "cluster-a", *exception.Target)
+
+	response = enrich("proc-2")
+	assert.Nil(t, response.Compliance.Exceptions)
+}
+
+func TestGetV1Snapshot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := snapshot.NewSigner(key)
+	require.NoError(t, err)
+
+	set := changesSet(map[string]string{"proc-1": "AC-1", "proc-2": "AC-2"})
+	service := NewService(set, changesScope(), WithContentVersion("abc123"), WithSnapshotSigner(signer))
+	r := gin.New()
+	api.RegisterHandlers(r, service)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/snapshot", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"abc123"`, w.Header().Get("ETag"))
+
+	var resolved api.Snapshot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resolved))
+	assert.Equal(t, "abc123", resolved.ContentVersion)
+	assert.True(t, resolved.Complete)
+	require.Len(t, resolved.Entries, 2)
+	assert.Equal(t, "proc-1", resolved.Entries[0].Policy.PolicyRuleId)
+	assert.Equal(t, "AC-1.1", resolved.Entries[0].Compliance.Control.Id)
+	assert.Equal(t, "proc-2", resolved.Entries[1].Policy.PolicyRuleId)
+	assert.Equal(t, "AC-2.1", resolved.Entries[1].Compliance.Control.Id)
+
+	// The signature must survive the JSON round trip.
+	require.NoError(t, snapshot.Verify(resolved, key.Public()))
 }
 
 func TestEnrich(t *testing.T) {
@@ -0,0 +1,96 @@
+package compliancerouting
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/collector/pipeline"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/applier"
+	"github.com/complytime/complybeacon/truthbeam/internal/client"
+)
+
+// Config defines configuration for the compliance routing connector.
+type Config struct {
+	Routes           []Route       `mapstructure:"routes"`            // Routes a record is sent by; a record is sent to every route it matches
+	DefaultPipelines []pipeline.ID `mapstructure:"default_pipelines"` // Pipelines receiving records that match no route
+}
+
+// Route sends the records matching all of its conditions to its pipelines.
+// A condition with no values matches any record, and a condition with
+// values matches a record whose attribute equals one of them.
+type Route struct {
+	Statuses           []string      `mapstructure:"statuses"`            // Values of compliance.status
+	RiskLevels         []string      `mapstructure:"risk_levels"`         // Values of compliance.risk.level
+	EnrichmentStatuses []string      `mapstructure:"enrichment_statuses"` // Values of compliance.enrichment.status
+	Pipelines          []pipeline.ID `mapstructure:"pipelines"`           // Logs pipelines receiving the matching records
+}
+
+var (
+	riskLevels = []client.ComplianceRiskLevel{
+		client.Critical, client.High, client.Medium, client.Low, client.Informational,
+	}
+	enrichmentStatuses = []client.ComplianceEnrichmentStatus{
+		client.Success, client.Partial, client.Unmapped, client.Unknown, client.Skipped,
+	}
+)
+
+// Validate checks that every route has logs pipelines and that its
+// conditions only use known values.
+func (cfg *Config) Validate() error {
+	if len(cfg.Routes) == 0 {
+		return errors.New("at least one route must be configured")
+	}
+	for i, route := range cfg.Routes {
+		if err := route.validate(); err != nil {
+			return fmt.Errorf("route %d: %w", i, err)
+		}
+	}
+	if err := validatePipelines(cfg.DefaultPipelines); err != nil {
+		return fmt.Errorf("default_pipelines: %w", err)
+	}
+	return nil
+}
+
+func (r Route) validate() error {
+	if len(r.Pipelines) == 0 {
+		return errors.New("pipelines must be specified")
+	}
+	if err := validatePipelines(r.Pipelines); err != nil {
+		return fmt.Errorf("pipelines: %w", err)
+	}
+	for _, status := range r.Statuses {
+		if _, err := applier.ParseStatus(status); err != nil {
+			return err
+		}
+	}
+	for _, level := range r.RiskLevels {
+		if !contains(riskLevels, client.ComplianceRiskLevel(level)) {
+			return fmt.Errorf("unknown risk level %q", level)
+		}
+	}
+	for _, status := range r.EnrichmentStatuses {
+		if !contains(enrichmentStatuses, client.ComplianceEnrichmentStatus(status)) {
+			return fmt.Errorf("unknown enrichment status %q", status)
+		}
+	}
+	return nil
+}
+
+func validatePipelines(ids []pipeline.ID) error {
+	for _, id := range ids {
+		if id.Signal() != pipeline.SignalLogs {
+			return fmt.Errorf("%s is not a logs pipeline", id)
+		}
+	}
+	return nil
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package compliancerouting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+func TestConfigValidate(t *testing.T) {
+	alerting := pipeline.NewIDWithName(pipeline.SignalLogs, "alerting")
+
+	tests := []struct {
+		name        string
+		config      Config
+		expectedErr string
+	}{
+		{
+			name: "Valid",
+			config: Config{
+				Routes: []Route{
+					{Statuses: []string{"Non-Compliant"}, RiskLevels: []string{"High", "Critical"}, Pipelines: []pipeline.ID{alerting}},
+					{EnrichmentStatuses: []string{"Unmapped"}, Pipelines: []pipeline.ID{pipeline.NewIDWithName(pipeline.SignalLogs, "triage")}},
+				},
+				DefaultPipelines: []pipeline.ID{pipeline.NewID(pipeline.SignalLogs)},
+			},
+		},
+		{
+			name:        "No routes",
+			config:      Config{},
+			expectedErr: "at least one route must be configured",
+		},
+		{
+			name:        "Route without pipelines",
+			config:      Config{Routes: []Route{{Statuses: []string{"Compliant"}}}},
+			expectedErr: "route 0: pipelines must be specified",
+		},
+		{
+			name:        "Unknown status",
+			config:      Config{Routes: []Route{{Statuses: []string{"Failed"}, Pipelines: []pipeline.ID{alerting}}}},
+			expectedErr: `route 0: unknown compliance status "Failed"`,
+		},
+		{
+			name:        "Unknown risk level",
+			config:      Config{Routes: []Route{{RiskLevels: []string{"Severe"}, Pipelines: []pipeline.ID{alerting}}}},
+			expectedErr: `route 0: unknown risk level "Severe"`,
+		},
+		{
+			name:        "Unknown enrichment status",
+			config:      Config{Routes: []Route{{EnrichmentStatuses: []string{"Missing"}, Pipelines: []pipeline.ID{alerting}}}},
+			expectedErr: `route 0: unknown enrichment status "Missing"`,
+		},
+		{
+			name:        "Traces pipeline",
+			config:      Config{Routes: []Route{{Pipelines: []pipeline.ID{pipeline.NewID(pipeline.SignalTraces)}}}},
+			expectedErr: "route 0: pipelines: traces is not a logs pipeline",
+		},
+		{
+			name: "Traces default pipeline",
+			config: Config{
+				Routes:           []Route{{Pipelines: []pipeline.ID{alerting}}},
+				DefaultPipelines: []pipeline.ID{pipeline.NewID(pipeline.SignalTraces)},
+			},
+			expectedErr: "default_pipelines: traces is not a logs pipeline",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
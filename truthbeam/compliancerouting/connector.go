@@ -0,0 +1,186 @@
+package compliancerouting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pipeline"
+	"go.uber.org/zap"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/applier"
+)
+
+type logsConnector struct {
+	component.StartFunc
+	component.ShutdownFunc
+
+	logger   *zap.Logger
+	routes   []Route
+	defaults []pipeline.ID
+
+	// pipelines lists every routed pipeline in configuration order, so
+	// batches are sent in a stable order.
+	pipelines []pipeline.ID
+	consumers map[pipeline.ID]consumer.Logs
+}
+
+func newLogsConnector(cfg *Config, router connector.LogsRouterAndConsumer, logger *zap.Logger) (*logsConnector, error) {
+	c := &logsConnector{
+		logger:    logger,
+		routes:    cfg.Routes,
+		defaults:  cfg.DefaultPipelines,
+		consumers: make(map[pipeline.ID]consumer.Logs),
+	}
+
+	ids := append([]pipeline.ID{}, cfg.DefaultPipelines...)
+	for _, route := range cfg.Routes {
+		ids = append(ids, route.Pipelines...)
+	}
+	for _, id := range ids {
+		if _, ok := c.consumers[id]; ok {
+			continue
+		}
+		next, err := router.Consumer(id)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline %s: %w", id, err)
+		}
+		c.consumers[id] = next
+		c.pipelines = append(c.pipelines, id)
+	}
+	return c, nil
+}
+
+func (c *logsConnector) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeLogs copies each record to the pipelines of the routes it matches,
+// or to the default pipelines, keeping its resource and scope. Records
+// matching no route are dropped when there are no default pipelines.
+func (c *logsConnector) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	batches := make(map[pipeline.ID]*logsBatch)
+	dropped := 0
+
+	allResourceLogs := ld.ResourceLogs()
+	for i := 0; i < allResourceLogs.Len(); i++ {
+		resourceLogs := allResourceLogs.At(i)
+		resourceScopeLogs := resourceLogs.ScopeLogs()
+		for j := 0; j < resourceScopeLogs.Len(); j++ {
+			scopeLogs := resourceScopeLogs.At(j)
+			logRecords := scopeLogs.LogRecords()
+			for k := 0; k < logRecords.Len(); k++ {
+				logRecord := logRecords.At(k)
+				ids := c.match(logRecord.Attributes())
+				if len(ids) == 0 {
+					dropped++
+				}
+				for _, id := range ids {
+					batch, ok := batches[id]
+					if !ok {
+						batch = newLogsBatch()
+						batches[id] = batch
+					}
+					logRecord.CopyTo(batch.scopeLogs(i, j, resourceLogs, scopeLogs).LogRecords().AppendEmpty())
+				}
+			}
+		}
+	}
+
+	if dropped > 0 {
+		c.logger.Debug("dropped compliance records matching no route", zap.Int("records", dropped))
+	}
+
+	var errs []error
+	for _, id := range c.pipelines {
+		batch, ok := batches[id]
+		if !ok {
+			continue
+		}
+		if err := c.consumers[id].ConsumeLogs(ctx, batch.logs); err != nil {
+			c.logger.Warn("failed to route compliance records",
+				zap.Stringer("pipeline", id),
+				zap.Int("records", batch.logs.LogRecordCount()),
+				zap.Error(err),
+			)
+			errs = append(errs, fmt.Errorf("pipeline %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// match returns the distinct pipelines a record with attrs is routed to.
+func (c *logsConnector) match(attrs pcommon.Map) []pipeline.ID {
+	status := attributeValue(attrs, applier.COMPLIANCE_STATUS)
+	riskLevel := attributeValue(attrs, applier.COMPLIANCE_RISK_LEVEL)
+	enrichmentStatus := attributeValue(attrs, applier.COMPLIANCE_ENRICHMENT_STATUS)
+
+	var ids []pipeline.ID
+	for _, route := range c.routes {
+		if !matches(route.Statuses, status) || !matches(route.RiskLevels, riskLevel) ||
+			!matches(route.EnrichmentStatuses, enrichmentStatus) {
+			continue
+		}
+		for _, id := range route.Pipelines {
+			if !contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return c.defaults
+	}
+	return ids
+}
+
+// matches reports whether value satisfies a condition. A condition without
+// values matches anything.
+func matches(values []string, value string) bool {
+	return len(values) == 0 || contains(values, value)
+}
+
+func attributeValue(attrs pcommon.Map, key string) string {
+	value, ok := attrs.Get(key)
+	if !ok {
+		return ""
+	}
+	return value.AsString()
+}
+
+// logsBatch collects the records routed to one pipeline. Records are added
+// in input order, so a new resource or scope is started whenever the input
+// resource or scope changes.
+type logsBatch struct {
+	logs     plog.Logs
+	resource int
+	scope    int
+	current  plog.ScopeLogs
+}
+
+func newLogsBatch() *logsBatch {
+	return &logsBatch{logs: plog.NewLogs(), resource: -1, scope: -1}
+}
+
+// scopeLogs returns the scope logs in the batch for the input scope j of
+// resource i.
+func (b *logsBatch) scopeLogs(i, j int, resourceLogs plog.ResourceLogs, scopeLogs plog.ScopeLogs) plog.ScopeLogs {
+	if b.resource != i {
+		out := b.logs.ResourceLogs().AppendEmpty()
+		resourceLogs.Resource().CopyTo(out.Resource())
+		out.SetSchemaUrl(resourceLogs.SchemaUrl())
+		b.resource, b.scope = i, -1
+	}
+	if b.scope != j {
+		resources := b.logs.ResourceLogs()
+		b.current = resources.At(resources.Len() - 1).ScopeLogs().AppendEmpty()
+		scopeLogs.Scope().CopyTo(b.current.Scope())
+		b.current.SetSchemaUrl(scopeLogs.SchemaUrl())
+		b.scope = j
+	}
+	return b.current
+}
@@ -0,0 +1,137 @@
+package compliancerouting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/connector/connectortest"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pipeline"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/applier"
+)
+
+func TestLogsConnector(t *testing.T) {
+	alerting := pipeline.NewIDWithName(pipeline.SignalLogs, "alerting")
+	triage := pipeline.NewIDWithName(pipeline.SignalLogs, "triage")
+	archive := pipeline.NewIDWithName(pipeline.SignalLogs, "archive")
+	other := pipeline.NewIDWithName(pipeline.SignalLogs, "other")
+
+	sinks := map[pipeline.ID]*consumertest.LogsSink{
+		alerting: new(consumertest.LogsSink),
+		triage:   new(consumertest.LogsSink),
+		archive:  new(consumertest.LogsSink),
+		other:    new(consumertest.LogsSink),
+	}
+	consumers := make(map[pipeline.ID]consumer.Logs, len(sinks))
+	for id, sink := range sinks {
+		consumers[id] = sink
+	}
+
+	cfg := &Config{
+		Routes: []Route{
+			{Statuses: []string{"Non-Compliant"}, RiskLevels: []string{"High", "Critical"}, Pipelines: []pipeline.ID{alerting, archive}},
+			{EnrichmentStatuses: []string{"Unmapped"}, Pipelines: []pipeline.ID{triage, archive}},
+		},
+		DefaultPipelines: []pipeline.ID{other},
+	}
+	require.NoError(t, cfg.Validate())
+
+	conn, err := NewFactory().CreateLogsToLogs(context.Background(), connectortest.NewNopSettings(Type), cfg, connector.NewLogsRouter(consumers))
+	require.NoError(t, err)
+
+	logs := plog.NewLogs()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	resourceLogs.Resource().Attributes().PutStr("service.name", "policy-engine")
+	scopeLogs := resourceLogs.ScopeLogs().AppendEmpty()
+	scopeLogs.Scope().SetName("proofwatch")
+	addRecord := func(id, status, riskLevel, enrichmentStatus string) {
+		record := scopeLogs.LogRecords().AppendEmpty()
+		record.Attributes().PutStr("id", id)
+		record.Attributes().PutStr(applier.COMPLIANCE_STATUS, status)
+		record.Attributes().PutStr(applier.COMPLIANCE_ENRICHMENT_STATUS, enrichmentStatus)
+		if riskLevel != "" {
+			record.Attributes().PutStr(applier.COMPLIANCE_RISK_LEVEL, riskLevel)
+		}
+	}
+	addRecord("critical", "Non-Compliant", "Critical", "Success")
+	addRecord("low", "Non-Compliant", "Low", "Success")
+	addRecord("unmapped", "Unknown", "", "Unmapped")
+	addRecord("compliant", "Compliant", "High", "Success")
+
+	require.NoError(t, conn.ConsumeLogs(context.Background(), logs))
+
+	routed := func(id pipeline.ID) []string {
+		var ids []string
+		for _, batch := range sinks[id].AllLogs() {
+			for i := 0; i < batch.ResourceLogs().Len(); i++ {
+				resourceLogs := batch.ResourceLogs().At(i)
+				assert.Equal(t, "policy-engine", resourceLogs.Resource().Attributes().AsRaw()["service.name"])
+				for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+					scopeLogs := resourceLogs.ScopeLogs().At(j)
+					assert.Equal(t, "proofwatch", scopeLogs.Scope().Name())
+					for k := 0; k < scopeLogs.LogRecords().Len(); k++ {
+						value, _ := scopeLogs.LogRecords().At(k).Attributes().Get("id")
+						ids = append(ids, value.Str())
+					}
+				}
+			}
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"critical"}, routed(alerting))
+	assert.Equal(t, []string{"unmapped"}, routed(triage))
+	assert.Equal(t, []string{"critical", "unmapped"}, routed(archive), "records matching several routes are sent once per pipeline")
+	assert.Equal(t, []string{"low", "compliant"}, routed(other))
+}
+
+func TestLogsConnectorUnknownPipeline(t *testing.T) {
+	cfg := &Config{
+		Routes: []Route{{Pipelines: []pipeline.ID{pipeline.NewIDWithName(pipeline.SignalLogs, "missing")}}},
+	}
+	router := connector.NewLogsRouter(map[pipeline.ID]consumer.Logs{
+		pipeline.NewID(pipeline.SignalLogs): new(consumertest.LogsSink),
+	})
+
+	_, err := NewFactory().CreateLogsToLogs(context.Background(), connectortest.NewNopSettings(Type), cfg, router)
+	assert.ErrorContains(t, err, "logs/missing")
+}
+
+func TestLogsConnectorLogging(t *testing.T) {
+	alerting := pipeline.NewIDWithName(pipeline.SignalLogs, "alerting")
+	cfg := &Config{
+		Routes: []Route{{Statuses: []string{"Non-Compliant"}, Pipelines: []pipeline.ID{alerting}}},
+	}
+	router := connector.NewLogsRouter(map[pipeline.ID]consumer.Logs{
+		alerting: consumertest.NewErr(errors.New("queue is full")),
+	})
+
+	core, observed := observer.New(zap.DebugLevel)
+	settings := connectortest.NewNopSettings(Type)
+	settings.Logger = zap.New(core)
+	conn, err := NewFactory().CreateLogsToLogs(context.Background(), settings, cfg, router)
+	require.NoError(t, err)
+
+	logs := plog.NewLogs()
+	logRecords := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	logRecords.AppendEmpty().Attributes().PutStr(applier.COMPLIANCE_STATUS, "Non-Compliant")
+	logRecords.AppendEmpty().Attributes().PutStr(applier.COMPLIANCE_STATUS, "Compliant")
+
+	assert.ErrorContains(t, conn.ConsumeLogs(context.Background(), logs), "logs/alerting")
+
+	dropped := observed.FilterMessage("dropped compliance records matching no route").All()
+	require.Len(t, dropped, 1)
+	assert.Equal(t, int64(1), dropped[0].ContextMap()["records"])
+	failed := observed.FilterMessage("failed to route compliance records").All()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "logs/alerting", failed[0].ContextMap()["pipeline"])
+}
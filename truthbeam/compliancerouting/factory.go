@@ -0,0 +1,50 @@
+// Package compliancerouting provides a connector that routes enriched
+// evidence to logs pipelines by its compliance outcome.
+package compliancerouting
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/consumer"
+)
+
+var (
+	// Type is the component type of the connector.
+	Type = component.MustNewType("compliancerouting")
+)
+
+const (
+	LogsToLogsStability = component.StabilityLevelAlpha
+)
+
+// NewFactory returns a new factory for the compliance routing connector.
+func NewFactory() connector.Factory {
+	return connector.NewFactory(
+		Type,
+		createDefaultConfig,
+		connector.WithLogsToLogs(createLogsToLogs, LogsToLogsStability))
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{}
+}
+
+func createLogsToLogs(
+	_ context.Context,
+	set connector.Settings,
+	cfg component.Config,
+	next consumer.Logs,
+) (connector.Logs, error) {
+	conf, ok := cfg.(*Config)
+	if !ok {
+		return nil, errors.New("invalid configuration provided")
+	}
+	router, ok := next.(connector.LogsRouterAndConsumer)
+	if !ok {
+		return nil, errors.New("consumer is not a logs router")
+	}
+	return newLogsConnector(conf, router, set.Logger)
+}
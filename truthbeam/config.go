@@ -2,32 +2,192 @@ package truthbeam
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/config/configtls"
 
+	"github.com/complytime/complybeacon/truthbeam/internal/applier"
 	"github.com/complytime/complybeacon/truthbeam/internal/client"
+	"github.com/complytime/complybeacon/truthbeam/internal/offline"
+	"github.com/complytime/complybeacon/truthbeam/internal/retryqueue"
 )
 
 // Config defines configuration for the truthbeam processor.
 type Config struct {
-	ClientConfig  confighttp.ClientConfig `mapstructure:",squash"`        // squash ensures fields are correctly decoded in embedded struct.
-	CacheTTL      time.Duration           `mapstructure:"cache_ttl"`      // Cache TTL for compliance metadata
-	CacheCapacity int                     `mapstructure:"cache_capacity"` // Cache capacity in number of entries (0 = use default from client.DefaultCacheCapacity)
+	ClientConfig         confighttp.ClientConfig    `mapstructure:",squash"`                // squash ensures fields are correctly decoded in embedded struct.
+	Backends             map[string]BackendConfig   `mapstructure:"backends"`               // Named Compass backends, in place of endpoint
+	BackendAttribute     string                     `mapstructure:"backend_attribute"`      // Resource attribute naming the backend of each record
+	DefaultBackend       string                     `mapstructure:"default_backend"`        // Backend of records without the backend attribute (empty = such records are not enriched)
+	CacheTTL             time.Duration              `mapstructure:"cache_ttl"`              // Cache TTL for Success compliance metadata
+	UnmappedCacheTTL     time.Duration              `mapstructure:"unmapped_cache_ttl"`     // Cache TTL for Unmapped and Partial results (0 = use default from client.DefaultUnmappedCacheTTL)
+	ErrorCacheTTL        time.Duration              `mapstructure:"error_cache_ttl"`        // Cache TTL for failed Compass calls (0 = use default from client.DefaultErrorCacheTTL)
+	CacheCapacity        int                        `mapstructure:"cache_capacity"`         // Cache capacity in number of entries (0 = use default from client.DefaultCacheCapacity)
+	ChangeFeed           ChangeFeedConfig           `mapstructure:"change_feed"`            // Subscription to the Compass mapping change feed
+	BatchSize            int                        `mapstructure:"batch_size"`             // Maximum policies per batch enrichment request (0 = use default from client.DefaultBatchSize)
+	MaxConcurrency       int                        `mapstructure:"max_concurrency"`        // Maximum concurrent Compass calls per logs batch (0 = use default from client.DefaultMaxConcurrency)
+	Retry                RetryConfig                `mapstructure:"retry"`                  // Retries of failed Compass calls
+	CircuitBreaker       CircuitBreakerConfig       `mapstructure:"circuit_breaker"`        // Circuit breaker around Compass calls
+	StaleWhileRevalidate StaleWhileRevalidateConfig `mapstructure:"stale_while_revalidate"` // Serving of expired entries while they are refreshed
+	Snapshot             SnapshotConfig             `mapstructure:"snapshot"`               // Offline enrichment from a local mapping snapshot
+	Warmup               WarmupConfig               `mapstructure:"warmup"`                 // Cache warmup at startup
+	Storage              *component.ID              `mapstructure:"storage"`                // Storage extension persisting the cache across restarts (nil = memory only)
+	Redis                RedisConfig                `mapstructure:"redis"`                  // Cache shared by a fleet of collectors
+	Extraction           applier.Extraction         `mapstructure:"extraction"`             // Where the lookup fields are read from
+	StatusMapping        applier.StatusMapping      `mapstructure:"status_mapping"`         // Per policy engine overrides of the evaluation result to compliance status mapping
+	OCSFBody             bool                       `mapstructure:"ocsf_body"`              // Also write the compliance context into OCSF event bodies
+	Severity             SeverityConfig             `mapstructure:"severity"`               // Log record severity derived from compliance status and risk level
+	FailClosed           FailClosedConfig           `mapstructure:"fail_closed"`            // Holding of log records that could not be enriched until they can be
+}
+
+// BackendConfig configures a named Compass backend. Requests fail over
+// across its endpoints, which must all serve the same catalogs.
+type BackendConfig struct {
+	ClientConfig confighttp.ClientConfig `mapstructure:",squash"`   // HTTP settings shared by the endpoints; endpoint must not be set
+	Endpoints    []string                `mapstructure:"endpoints"` // Endpoints in order of preference
+}
+
+// FailClosedConfig configures holding log records that could not be
+// enriched in a storage extension, instead of passing them through marked
+// as Unknown. Held records are retried with backoff and released once they
+// are enriched, or after MaxAge marked as Unknown.
+type FailClosedConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`          // Hold records that could not be enriched
+	Storage         *component.ID `mapstructure:"storage"`          // Storage extension holding the records; required when enabled
+	InitialInterval time.Duration `mapstructure:"initial_interval"` // Delay before the first retry (0 = use default from retryqueue.DefaultInitialInterval)
+	MaxInterval     time.Duration `mapstructure:"max_interval"`     // Upper bound of the backoff (0 = use default from retryqueue.DefaultMaxInterval)
+	MaxAge          time.Duration `mapstructure:"max_age"`          // How long a record is held before it is released as Unknown (0 = use default from retryqueue.DefaultMaxAge)
+	MaxItems        int           `mapstructure:"max_items"`        // Records held before the oldest are dropped (0 = use default from retryqueue.DefaultMaxItems)
+}
+
+// SeverityConfig sets the severity of log records from their compliance
+// status and risk level.
+type SeverityConfig struct {
+	Enabled           bool                   `mapstructure:"enabled"`            // Set SeverityNumber and SeverityText
+	Matrix            applier.SeverityMatrix `mapstructure:"matrix"`             // compliance.status -> compliance.risk.level or "default" -> severity name (empty = applier.DefaultSeverityMatrix)
+	OriginalAttribute string                 `mapstructure:"original_attribute"` // Attribute keeping the original severity (empty = not kept)
+}
+
+// RedisConfig configures a Redis-protocol cache shared by every replica,
+// layered under the local cache. While the server is unavailable, the
+// local cache is used on its own.
+type RedisConfig struct {
+	Endpoint      string                  `mapstructure:"endpoint"`       // Address of the server as host:port; enables the shared cache when set
+	Username      string                  `mapstructure:"username"`       // ACL username
+	Password      configopaque.String     `mapstructure:"password"`       // Password
+	DB            int                     `mapstructure:"db"`             // Database number
+	KeyPrefix     string                  `mapstructure:"key_prefix"`     // Prefix of every key (empty = use default from client.DefaultRedisKeyPrefix)
+	PoolSize      int                     `mapstructure:"pool_size"`      // Pooled connections (0 = use default from client.DefaultRedisPoolSize)
+	Timeout       time.Duration           `mapstructure:"timeout"`        // Timeout of every command (0 = use default from client.DefaultRedisTimeout)
+	RetryInterval time.Duration           `mapstructure:"retry_interval"` // How long a failed server is skipped (0 = use default from client.DefaultRedisRetryInterval)
+	TLS           *configtls.ClientConfig `mapstructure:"tls"`            // TLS settings (nil = plaintext)
+}
+
+// WarmupConfig configures filling the cache at startup, so a restarted
+// collector does not send its first records to Compass one by one.
+type WarmupConfig struct {
+	Enabled    bool           `mapstructure:"enabled"`    // Warm the cache at startup
+	Policies   []WarmupPolicy `mapstructure:"policies"`   // Policies to retrieve; the full Compass snapshot is fetched when empty
+	Timeout    time.Duration  `mapstructure:"timeout"`    // Upper bound on warmup (0 = use default from client.DefaultWarmupTimeout)
+	Background bool           `mapstructure:"background"` // Warm in the background instead of blocking startup
+}
+
+// WarmupPolicy is a policy rule retrieved during warmup.
+type WarmupPolicy struct {
+	PolicyEngineName string `mapstructure:"policy_engine_name"` // Name of the policy engine
+	PolicyRuleID     string `mapstructure:"policy_rule_id"`     // Policy rule identifier
+}
+
+// SnapshotConfig configures offline enrichment from a local mapping
+// snapshot written by `compass export` instead of a Compass service.
+type SnapshotConfig struct {
+	Path           string        `mapstructure:"path"`            // Snapshot file; enables offline mode when set
+	PublicKey      string        `mapstructure:"public_key"`      // PEM public key the snapshot must be signed with; signatures are not checked when empty
+	ReloadInterval time.Duration `mapstructure:"reload_interval"` // How often the snapshot file is checked for changes (0 = use default from offline.DefaultReloadInterval)
+}
+
+// StaleWhileRevalidateConfig configures serving expired cache entries
+// while a background call refreshes them, so compliance attributes stay
+// continuous through Compass outages and deploys.
+type StaleWhileRevalidateConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`       // Serve expired entries while they are refreshed
+	MaxStaleness time.Duration `mapstructure:"max_staleness"` // How long past expiry an entry may be served (0 = use default from client.DefaultMaxStaleness)
+}
+
+// RetryConfig configures retries of failed Compass calls with jittered
+// exponential backoff. Only transport errors, throttling and server errors
+// are retried.
+type RetryConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`          // Retry failed calls
+	MaxRetries      int           `mapstructure:"max_retries"`      // Retries after the first attempt (0 = use default from client.DefaultMaxRetries)
+	InitialInterval time.Duration `mapstructure:"initial_interval"` // Backoff before the first retry (0 = use default from client.DefaultRetryInitialInterval)
+	MaxInterval     time.Duration `mapstructure:"max_interval"`     // Upper bound of the backoff (0 = use default from client.DefaultRetryMaxInterval)
+}
+
+// CircuitBreakerConfig configures the circuit breaker that stops calling
+// Compass after repeated failures. While it is open, records pass through
+// with the Unknown enrichment status.
+type CircuitBreakerConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`           // Enable the circuit breaker
+	FailureThreshold int           `mapstructure:"failure_threshold"` // Consecutive failures that open the breaker (0 = use default from client.DefaultBreakerFailureThreshold)
+	OpenDuration     time.Duration `mapstructure:"open_duration"`     // Time before a trial call is let through (0 = use default from client.DefaultBreakerOpenDuration)
+}
+
+// ChangeFeedConfig configures the subscription to the Compass change feed,
+// which invalidates cached enrichments as soon as their mappings change.
+type ChangeFeedConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`        // Subscribe to the change feed
+	Wait          time.Duration `mapstructure:"wait"`           // Long poll duration per request (0 = use default from client.DefaultChangeFeedWait)
+	RetryInterval time.Duration `mapstructure:"retry_interval"` // Delay before retrying a failed request (0 = use default from client.DefaultChangeFeedRetryInterval)
 }
 
 var _ component.Config = (*Config)(nil)
 
 // Validate checks if the exporter configuration is valid
 func (cfg *Config) Validate() error {
-	if cfg.ClientConfig.Endpoint == "" {
-		return errors.New("endpoint must be specified")
+	if cfg.Snapshot.PublicKey != "" && cfg.Snapshot.Path == "" {
+		return errors.New("snapshot public_key requires a snapshot path")
+	}
+	if cfg.Snapshot.Path != "" {
+		if cfg.ClientConfig.Endpoint != "" || len(cfg.Backends) > 0 {
+			return errors.New("endpoint, backends and snapshot path are mutually exclusive")
+		}
+		if cfg.Snapshot.ReloadInterval == 0 {
+			cfg.Snapshot.ReloadInterval = offline.DefaultReloadInterval
+		}
+		if cfg.Storage != nil || cfg.Redis.Endpoint != "" {
+			return errors.New("storage and redis cannot be used with a snapshot path")
+		}
+		if cfg.Warmup.Enabled || cfg.ChangeFeed.Enabled {
+			return errors.New("warmup and change_feed need a Compass service and cannot be used with a snapshot path; the snapshot is loaded in full and reloaded when it changes")
+		}
+		if cfg.Snapshot.ReloadInterval < 0 {
+			return errors.New("snapshot reload_interval must be non-negative")
+		}
+	} else if len(cfg.Backends) > 0 {
+		if cfg.ClientConfig.Endpoint != "" {
+			return errors.New("endpoint and backends are mutually exclusive")
+		}
+	} else if cfg.ClientConfig.Endpoint == "" {
+		return errors.New("endpoint must be specified when no snapshot path is set")
 	}
 	// Normalize cache TTL: 0 means use default (24 hours for compliance metadata)
 	if cfg.CacheTTL == 0 {
 		cfg.CacheTTL = client.DefaultCacheTTL
 	}
+	if cfg.UnmappedCacheTTL == 0 {
+		cfg.UnmappedCacheTTL = client.DefaultUnmappedCacheTTL
+	}
+	if cfg.ErrorCacheTTL == 0 {
+		cfg.ErrorCacheTTL = client.DefaultErrorCacheTTL
+	}
+	if cfg.CacheTTL < 0 || cfg.UnmappedCacheTTL < 0 || cfg.ErrorCacheTTL < 0 {
+		return errors.New("cache_ttl, unmapped_cache_ttl and error_cache_ttl must be non-negative")
+	}
 	// Set default cache capacity if not specified
 	if cfg.CacheCapacity == 0 {
 		cfg.CacheCapacity = client.DefaultCacheCapacity
@@ -36,5 +196,161 @@ func (cfg *Config) Validate() error {
 		return errors.New("cache_capacity must be non-negative")
 	}
 
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = client.DefaultBatchSize
+	}
+	if cfg.BatchSize < 0 || cfg.BatchSize > client.MaxBatchSize {
+		return fmt.Errorf("batch_size must be between 1 and %d", client.MaxBatchSize)
+	}
+	if cfg.MaxConcurrency == 0 {
+		cfg.MaxConcurrency = client.DefaultMaxConcurrency
+	}
+	if cfg.MaxConcurrency < 0 {
+		return errors.New("max_concurrency must be non-negative")
+	}
+
+	if cfg.Retry.MaxRetries == 0 {
+		cfg.Retry.MaxRetries = client.DefaultMaxRetries
+	}
+	if cfg.Retry.InitialInterval == 0 {
+		cfg.Retry.InitialInterval = client.DefaultRetryInitialInterval
+	}
+	if cfg.Retry.MaxInterval == 0 {
+		cfg.Retry.MaxInterval = client.DefaultRetryMaxInterval
+	}
+	if cfg.Retry.MaxRetries < 0 || cfg.Retry.InitialInterval < 0 || cfg.Retry.MaxInterval < cfg.Retry.InitialInterval {
+		return errors.New("retry max_retries and initial_interval must be non-negative and max_interval must not be less than initial_interval")
+	}
+	if cfg.CircuitBreaker.FailureThreshold == 0 {
+		cfg.CircuitBreaker.FailureThreshold = client.DefaultBreakerFailureThreshold
+	}
+	if cfg.CircuitBreaker.OpenDuration == 0 {
+		cfg.CircuitBreaker.OpenDuration = client.DefaultBreakerOpenDuration
+	}
+	if cfg.CircuitBreaker.FailureThreshold < 0 || cfg.CircuitBreaker.OpenDuration < 0 {
+		return errors.New("circuit_breaker failure_threshold and open_duration must be non-negative")
+	}
+
+	if cfg.StaleWhileRevalidate.MaxStaleness == 0 {
+		cfg.StaleWhileRevalidate.MaxStaleness = client.DefaultMaxStaleness
+	}
+	if cfg.StaleWhileRevalidate.MaxStaleness < 0 {
+		return errors.New("stale_while_revalidate max_staleness must be non-negative")
+	}
+
+	if cfg.Warmup.Timeout == 0 {
+		cfg.Warmup.Timeout = client.DefaultWarmupTimeout
+	}
+	if cfg.Warmup.Timeout < 0 {
+		return errors.New("warmup timeout must be non-negative")
+	}
+	for _, policy := range cfg.Warmup.Policies {
+		if policy.PolicyEngineName == "" || policy.PolicyRuleID == "" {
+			return errors.New("warmup policies need a policy_engine_name and a policy_rule_id")
+		}
+	}
+
+	if cfg.Redis.Endpoint != "" {
+		if cfg.Redis.KeyPrefix == "" {
+			cfg.Redis.KeyPrefix = client.DefaultRedisKeyPrefix
+		}
+		if cfg.Redis.PoolSize == 0 {
+			cfg.Redis.PoolSize = client.DefaultRedisPoolSize
+		}
+		if cfg.Redis.Timeout == 0 {
+			cfg.Redis.Timeout = client.DefaultRedisTimeout
+		}
+		if cfg.Redis.RetryInterval == 0 {
+			cfg.Redis.RetryInterval = client.DefaultRedisRetryInterval
+		}
+		if cfg.Redis.DB < 0 || cfg.Redis.PoolSize < 0 || cfg.Redis.Timeout < 0 || cfg.Redis.RetryInterval < 0 {
+			return errors.New("redis db, pool_size, timeout and retry_interval must be non-negative")
+		}
+	}
+
+	if err := cfg.Extraction.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.StatusMapping.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Severity.Matrix.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.FailClosed.InitialInterval == 0 {
+		cfg.FailClosed.InitialInterval = retryqueue.DefaultInitialInterval
+	}
+	if cfg.FailClosed.MaxInterval == 0 {
+		cfg.FailClosed.MaxInterval = retryqueue.DefaultMaxInterval
+	}
+	if cfg.FailClosed.MaxAge == 0 {
+		cfg.FailClosed.MaxAge = retryqueue.DefaultMaxAge
+	}
+	if cfg.FailClosed.MaxItems == 0 {
+		cfg.FailClosed.MaxItems = retryqueue.DefaultMaxItems
+	}
+	if cfg.FailClosed.InitialInterval < 0 || cfg.FailClosed.MaxInterval < cfg.FailClosed.InitialInterval || cfg.FailClosed.MaxAge < 0 {
+		return errors.New("fail_closed initial_interval and max_age must be non-negative and max_interval must not be less than initial_interval")
+	}
+	if cfg.FailClosed.MaxItems < 0 {
+		return errors.New("fail_closed max_items must be non-negative")
+	}
+	if cfg.FailClosed.Enabled && cfg.FailClosed.Storage == nil {
+		return errors.New("fail_closed needs a storage extension")
+	}
+
+	if cfg.ChangeFeed.Wait == 0 {
+		cfg.ChangeFeed.Wait = client.DefaultChangeFeedWait
+	}
+	if cfg.ChangeFeed.RetryInterval == 0 {
+		cfg.ChangeFeed.RetryInterval = client.DefaultChangeFeedRetryInterval
+	}
+	if cfg.ChangeFeed.Wait < time.Second || cfg.ChangeFeed.RetryInterval < 0 {
+		return errors.New("change_feed wait must be at least 1s and retry_interval must be non-negative")
+	}
+	// The long poll must complete before the HTTP client gives up on it.
+	if cfg.ChangeFeed.Enabled && cfg.ClientConfig.Timeout > 0 && cfg.ChangeFeed.Wait >= cfg.ClientConfig.Timeout {
+		return fmt.Errorf("change_feed wait (%s) must be less than timeout (%s)", cfg.ChangeFeed.Wait, cfg.ClientConfig.Timeout)
+	}
+
+	return cfg.validateBackends()
+}
+
+// validateBackends checks the named Compass backends, if any.
+func (cfg *Config) validateBackends() error {
+	if len(cfg.Backends) == 0 {
+		if cfg.BackendAttribute != "" || cfg.DefaultBackend != "" {
+			return errors.New("backend_attribute and default_backend need backends")
+		}
+		return nil
+	}
+
+	for name, backend := range cfg.Backends {
+		if name == "" || strings.Contains(name, client.CacheKeySeparator) {
+			return fmt.Errorf("backend name %q must be non-empty and must not contain %q", name, client.CacheKeySeparator)
+		}
+		if backend.ClientConfig.Endpoint != "" {
+			return fmt.Errorf("backend %q: set endpoints instead of endpoint", name)
+		}
+		if len(backend.Endpoints) == 0 {
+			return fmt.Errorf("backend %q: endpoints must be specified", name)
+		}
+		for _, endpoint := range backend.Endpoints {
+			if endpoint == "" {
+				return fmt.Errorf("backend %q: endpoints must not be empty", name)
+			}
+		}
+		if cfg.ChangeFeed.Enabled && backend.ClientConfig.Timeout > 0 && cfg.ChangeFeed.Wait >= backend.ClientConfig.Timeout {
+			return fmt.Errorf("backend %q: change_feed wait (%s) must be less than timeout (%s)", name, cfg.ChangeFeed.Wait, backend.ClientConfig.Timeout)
+		}
+	}
+
+	if cfg.BackendAttribute == "" && cfg.DefaultBackend == "" {
+		return errors.New("backends need a backend_attribute or a default_backend")
+	}
+	if _, ok := cfg.Backends[cfg.DefaultBackend]; cfg.DefaultBackend != "" && !ok {
+		return fmt.Errorf("default_backend %q is not a configured backend", cfg.DefaultBackend)
+	}
 	return nil
 }
@@ -5,9 +5,13 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/confighttp"
 
+	"github.com/complytime/complybeacon/truthbeam/internal/applier"
 	"github.com/complytime/complybeacon/truthbeam/internal/client"
+	"github.com/complytime/complybeacon/truthbeam/internal/offline"
+	"github.com/complytime/complybeacon/truthbeam/internal/retryqueue"
 )
 
 // The config tests are table-driven tests to validate configuration validation
@@ -260,3 +264,374 @@ func TestCacheCapacityValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestChangeFeedValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		changeFeed  ChangeFeedConfig
+		timeout     time.Duration
+		expected    ChangeFeedConfig
+		expectError bool
+	}{
+		{
+			name:     "zero values normalize to defaults",
+			expected: ChangeFeedConfig{Wait: client.DefaultChangeFeedWait, RetryInterval: client.DefaultChangeFeedRetryInterval},
+		},
+		{
+			name:       "wait shorter than timeout",
+			changeFeed: ChangeFeedConfig{Enabled: true, Wait: 10 * time.Second, RetryInterval: time.Second},
+			timeout:    30 * time.Second,
+			expected:   ChangeFeedConfig{Enabled: true, Wait: 10 * time.Second, RetryInterval: time.Second},
+		},
+		{
+			name:        "wait not shorter than timeout should fail",
+			changeFeed:  ChangeFeedConfig{Enabled: true, Wait: 30 * time.Second},
+			timeout:     30 * time.Second,
+			expectError: true,
+		},
+		{
+			name:       "timeout ignored when disabled",
+			changeFeed: ChangeFeedConfig{Wait: 30 * time.Second},
+			timeout:    30 * time.Second,
+			expected:   ChangeFeedConfig{Wait: 30 * time.Second, RetryInterval: client.DefaultChangeFeedRetryInterval},
+		},
+		{
+			name:        "sub-second wait should fail",
+			changeFeed:  ChangeFeedConfig{Wait: 500 * time.Millisecond},
+			expectError: true,
+		},
+		{
+			name:        "negative retry interval should fail",
+			changeFeed:  ChangeFeedConfig{RetryInterval: -time.Second},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ClientConfig: confighttp.ClientConfig{
+					Endpoint: "http://localhost:8081",
+					Timeout:  tt.timeout,
+				},
+				ChangeFeed: tt.changeFeed,
+			}
+
+			err := cfg.Validate()
+			if tt.expectError {
+				assert.Error(t, err, "Expected validation error")
+			} else {
+				assert.NoError(t, err, "Expected no validation error")
+				assert.Equal(t, tt.expected, cfg.ChangeFeed)
+			}
+		})
+	}
+}
+
+func TestBatchValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		batchSize      int
+		maxConcurrency int
+		expectError    bool
+	}{
+		{name: "zero values normalize to defaults"},
+		{name: "custom values preserved", batchSize: 500, maxConcurrency: 4},
+		{name: "batch size above compass limit should fail", batchSize: client.MaxBatchSize + 1, expectError: true},
+		{name: "negative batch size should fail", batchSize: -1, expectError: true},
+		{name: "negative max concurrency should fail", maxConcurrency: -1, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ClientConfig: confighttp.ClientConfig{
+					Endpoint: "http://localhost:8081",
+				},
+				BatchSize:      tt.batchSize,
+				MaxConcurrency: tt.maxConcurrency,
+			}
+
+			err := cfg.Validate()
+			if tt.expectError {
+				assert.Error(t, err, "Expected validation error")
+				return
+			}
+			assert.NoError(t, err, "Expected no validation error")
+			if tt.batchSize == 0 {
+				assert.Equal(t, client.DefaultBatchSize, cfg.BatchSize)
+				assert.Equal(t, client.DefaultMaxConcurrency, cfg.MaxConcurrency)
+			} else {
+				assert.Equal(t, tt.batchSize, cfg.BatchSize)
+				assert.Equal(t, tt.maxConcurrency, cfg.MaxConcurrency)
+			}
+		})
+	}
+}
+
+func TestOutcomeCacheTTLValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, client.DefaultUnmappedCacheTTL, cfg.UnmappedCacheTTL)
+	assert.Equal(t, client.DefaultErrorCacheTTL, cfg.ErrorCacheTTL)
+
+	cfg = &Config{
+		ClientConfig:     confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		UnmappedCacheTTL: time.Minute,
+		ErrorCacheTTL:    time.Second,
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, time.Minute, cfg.UnmappedCacheTTL)
+	assert.Equal(t, time.Second, cfg.ErrorCacheTTL)
+
+	cfg = &Config{
+		ClientConfig:  confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		ErrorCacheTTL: -time.Second,
+	}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestResilienceValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, client.DefaultMaxRetries, cfg.Retry.MaxRetries)
+	assert.Equal(t, client.DefaultRetryInitialInterval, cfg.Retry.InitialInterval)
+	assert.Equal(t, client.DefaultRetryMaxInterval, cfg.Retry.MaxInterval)
+	assert.Equal(t, client.DefaultBreakerFailureThreshold, cfg.CircuitBreaker.FailureThreshold)
+	assert.Equal(t, client.DefaultBreakerOpenDuration, cfg.CircuitBreaker.OpenDuration)
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{name: "negative max_retries", modify: func(cfg *Config) { cfg.Retry.MaxRetries = -1 }},
+		{name: "max_interval below initial_interval", modify: func(cfg *Config) {
+			cfg.Retry.InitialInterval = time.Second
+			cfg.Retry.MaxInterval = time.Millisecond
+		}},
+		{name: "negative failure_threshold", modify: func(cfg *Config) { cfg.CircuitBreaker.FailureThreshold = -1 }},
+		{name: "negative open_duration", modify: func(cfg *Config) { cfg.CircuitBreaker.OpenDuration = -time.Second }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+			}
+			tt.modify(cfg)
+			assert.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestStaleWhileRevalidateValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.False(t, cfg.StaleWhileRevalidate.Enabled)
+	assert.Equal(t, client.DefaultMaxStaleness, cfg.StaleWhileRevalidate.MaxStaleness)
+
+	cfg = &Config{
+		ClientConfig:         confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		StaleWhileRevalidate: StaleWhileRevalidateConfig{Enabled: true, MaxStaleness: -time.Minute},
+	}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestSnapshotValidation(t *testing.T) {
+	cfg := &Config{Snapshot: SnapshotConfig{Path: "snapshot.json"}}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, offline.DefaultReloadInterval, cfg.Snapshot.ReloadInterval)
+
+	cfg = &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		Snapshot:     SnapshotConfig{Path: "snapshot.json"},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "mutually exclusive")
+
+	cfg = &Config{Snapshot: SnapshotConfig{Path: "snapshot.json", ReloadInterval: -time.Second}}
+	assert.Error(t, cfg.Validate())
+
+	storageID := component.MustNewID("file_storage")
+	cfg = &Config{Snapshot: SnapshotConfig{Path: "snapshot.json"}, Storage: &storageID}
+	assert.ErrorContains(t, cfg.Validate(), "storage")
+
+	cfg = &Config{Snapshot: SnapshotConfig{Path: "snapshot.json"}, Warmup: WarmupConfig{Enabled: true}}
+	assert.ErrorContains(t, cfg.Validate(), "warmup and change_feed")
+
+	cfg = &Config{Snapshot: SnapshotConfig{Path: "snapshot.json"}, ChangeFeed: ChangeFeedConfig{Enabled: true}}
+	assert.ErrorContains(t, cfg.Validate(), "warmup and change_feed")
+
+	cfg = &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		Snapshot:     SnapshotConfig{PublicKey: "public.pem"},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "public_key")
+}
+
+func TestWarmupValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, client.DefaultWarmupTimeout, cfg.Warmup.Timeout)
+
+	cfg = &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		Warmup:       WarmupConfig{Enabled: true, Timeout: -time.Second},
+	}
+	assert.Error(t, cfg.Validate())
+
+	cfg = &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		Warmup: WarmupConfig{
+			Enabled:  true,
+			Policies: []WarmupPolicy{{PolicyEngineName: "test-engine"}},
+		},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "policy_rule_id")
+}
+
+func TestRedisValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		Redis:        RedisConfig{Endpoint: "localhost:6379"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, client.DefaultRedisKeyPrefix, cfg.Redis.KeyPrefix)
+	assert.Equal(t, client.DefaultRedisPoolSize, cfg.Redis.PoolSize)
+	assert.Equal(t, client.DefaultRedisTimeout, cfg.Redis.Timeout)
+	assert.Equal(t, client.DefaultRedisRetryInterval, cfg.Redis.RetryInterval)
+
+	cfg = &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		Redis:        RedisConfig{Endpoint: "localhost:6379", PoolSize: -1},
+	}
+	assert.Error(t, cfg.Validate())
+
+	cfg = &Config{
+		Snapshot: SnapshotConfig{Path: "snapshot.json"},
+		Redis:    RedisConfig{Endpoint: "localhost:6379"},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "redis")
+}
+
+func TestExtractionValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, applier.DefaultExtraction(), cfg.Extraction)
+
+	cfg = &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		Extraction: applier.Extraction{
+			PolicyRuleID: applier.Field{Sources: []applier.Source{{ResourceAttribute: "rule", ScopeAttribute: "rule"}}},
+		},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "extraction policy_rule_id")
+}
+
+func TestStatusMappingValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig:  confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		StatusMapping: applier.StatusMapping{"opa": {"Needs Review": "Unknown"}},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.StatusMapping = applier.StatusMapping{"opa": {"Needs Review": "Pending"}}
+	assert.ErrorContains(t, cfg.Validate(), "status_mapping opa")
+}
+
+func TestSeverityValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		Severity: SeverityConfig{
+			Enabled: true,
+			Matrix:  applier.SeverityMatrix{"Non-Compliant": {"Critical": "FATAL", "default": "WARN"}},
+		},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Severity.Matrix = applier.SeverityMatrix{"Non-Compliant": {"Critical": "PANIC"}}
+	assert.ErrorContains(t, cfg.Validate(), "severity matrix Non-Compliant Critical")
+}
+
+func TestFailClosedValidation(t *testing.T) {
+	cfg := &Config{
+		ClientConfig: confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, retryqueue.DefaultInitialInterval, cfg.FailClosed.InitialInterval)
+	assert.Equal(t, retryqueue.DefaultMaxInterval, cfg.FailClosed.MaxInterval)
+	assert.Equal(t, retryqueue.DefaultMaxAge, cfg.FailClosed.MaxAge)
+	assert.Equal(t, retryqueue.DefaultMaxItems, cfg.FailClosed.MaxItems)
+
+	cfg.FailClosed.Enabled = true
+	assert.ErrorContains(t, cfg.Validate(), "storage extension")
+
+	storageID := component.MustNewID("file_storage")
+	cfg.FailClosed.Storage = &storageID
+	assert.NoError(t, cfg.Validate())
+
+	cfg.FailClosed.MaxItems = -1
+	assert.ErrorContains(t, cfg.Validate(), "max_items")
+
+	cfg.FailClosed.MaxItems = 10
+	cfg.FailClosed.MaxInterval = time.Second
+	assert.Error(t, cfg.Validate())
+}
+
+func TestBackendsValidation(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			Backends: map[string]BackendConfig{
+				"finance": {Endpoints: []string{"https://compass-finance-a:8081", "https://compass-finance-b:8081"}},
+				"retail":  {Endpoints: []string{"https://compass-retail:8081"}},
+			},
+			BackendAttribute: "service.namespace",
+			DefaultBackend:   "retail",
+		}
+	}
+	assert.NoError(t, newConfig().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		errMsg string
+	}{
+		{name: "endpoint with backends", modify: func(cfg *Config) { cfg.ClientConfig.Endpoint = "http://localhost:8081" }, errMsg: "mutually exclusive"},
+		{name: "snapshot with backends", modify: func(cfg *Config) { cfg.Snapshot.Path = "snapshot.json" }, errMsg: "mutually exclusive"},
+		{name: "backend without endpoints", modify: func(cfg *Config) { cfg.Backends["retail"] = BackendConfig{} }, errMsg: "endpoints must be specified"},
+		{name: "backend with endpoint", modify: func(cfg *Config) {
+			cfg.Backends["retail"] = BackendConfig{ClientConfig: confighttp.ClientConfig{Endpoint: "https://compass-retail:8081"}}
+		}, errMsg: "set endpoints instead of endpoint"},
+		{name: "backend name with separator", modify: func(cfg *Config) {
+			cfg.Backends["a:b"] = BackendConfig{Endpoints: []string{"https://compass:8081"}}
+		}, errMsg: "must not contain"},
+		{name: "unknown default backend", modify: func(cfg *Config) { cfg.DefaultBackend = "marketing" }, errMsg: "not a configured backend"},
+		{name: "no attribute or default", modify: func(cfg *Config) {
+			cfg.BackendAttribute = ""
+			cfg.DefaultBackend = ""
+		}, errMsg: "backend_attribute or a default_backend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newConfig()
+			tt.modify(cfg)
+			assert.ErrorContains(t, cfg.Validate(), tt.errMsg)
+		})
+	}
+
+	cfg := &Config{
+		ClientConfig:     confighttp.ClientConfig{Endpoint: "http://localhost:8081"},
+		BackendAttribute: "service.namespace",
+	}
+	assert.ErrorContains(t, cfg.Validate(), "need backends")
+}
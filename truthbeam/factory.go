@@ -10,22 +10,35 @@ import (
 	"go.opentelemetry.io/collector/processor"
 	"go.opentelemetry.io/collector/processor/processorhelper"
 
+	"github.com/complytime/complybeacon/truthbeam/internal/applier"
 	"github.com/complytime/complybeacon/truthbeam/internal/client"
 	"github.com/complytime/complybeacon/truthbeam/internal/metadata"
+	"github.com/complytime/complybeacon/truthbeam/internal/sharedcomponent"
 )
 
 var processorCapabilities = consumer.Capabilities{MutatesData: true}
 
+// processors holds the processor shared by the pipelines of each
+// component, keyed by its configuration, which the collector creates once
+// per component ID. All its pipelines then share one cache, circuit
+// breaker, change feed subscription, set of metrics and storage clients.
+// Each pipeline keeps its own next consumer, and the processor is shut
+// down with the last pipeline.
+var processors = sharedcomponent.NewMap[component.Config, *truthBeamProcessor]()
+
 // NewFactory returns a new factory for the Attributes processor.
 func NewFactory() processor.Factory {
 	return processor.NewFactory(
 		metadata.Type,
 		createDefaultConfig,
-		processor.WithLogs(createLogsProcessor, metadata.LogsStability))
+		processor.WithLogs(createLogsProcessor, metadata.LogsStability),
+		processor.WithTraces(createTracesProcessor, metadata.TracesStability))
 }
 
 func createDefaultConfig() component.Config {
 	clientConfig := confighttp.NewDefaultClientConfig()
+	// Timed out calls are not retried, so a cache miss waits at most this
+	// long for an unresponsive Compass. It must exceed the change feed wait.
 	clientConfig.Timeout = 30 * time.Second
 	// Compression disabled by default - enrichment requests are small (~200 bytes)
 	// Compression overhead is unnecessary for such small payloads
@@ -34,9 +47,32 @@ func createDefaultConfig() component.Config {
 	clientConfig.WriteBufferSize = 512 * 1024
 
 	return &Config{
-		ClientConfig:  clientConfig,
-		CacheTTL:      client.DefaultCacheTTL,
-		CacheCapacity: client.DefaultCacheCapacity,
+		ClientConfig:     clientConfig,
+		CacheTTL:         client.DefaultCacheTTL,
+		UnmappedCacheTTL: client.DefaultUnmappedCacheTTL,
+		ErrorCacheTTL:    client.DefaultErrorCacheTTL,
+		CacheCapacity:    client.DefaultCacheCapacity,
+		BatchSize:        client.DefaultBatchSize,
+		MaxConcurrency:   client.DefaultMaxConcurrency,
+		Retry: RetryConfig{
+			Enabled:         true,
+			MaxRetries:      client.DefaultMaxRetries,
+			InitialInterval: client.DefaultRetryInitialInterval,
+			MaxInterval:     client.DefaultRetryMaxInterval,
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Enabled:          true,
+			FailureThreshold: client.DefaultBreakerFailureThreshold,
+			OpenDuration:     client.DefaultBreakerOpenDuration,
+		},
+		StaleWhileRevalidate: StaleWhileRevalidateConfig{
+			MaxStaleness: client.DefaultMaxStaleness,
+		},
+		ChangeFeed: ChangeFeedConfig{
+			Wait:          client.DefaultChangeFeedWait,
+			RetryInterval: client.DefaultChangeFeedRetryInterval,
+		},
+		Extraction: applier.DefaultExtraction(),
 	}
 }
 
@@ -46,10 +82,14 @@ func createLogsProcessor(
 	cfg component.Config,
 	next consumer.Logs,
 ) (processor.Logs, error) {
-	beamProcessor, err := newTruthBeamProcessor(cfg, set)
+	shared, err := processors.LoadOrStore(cfg, func() (*truthBeamProcessor, error) {
+		return newTruthBeamProcessor(cfg, set)
+	})
 	if err != nil {
 		return nil, err
 	}
+	beamProcessor := shared.Unwrap()
+	beamProcessor.addLogsPipeline(next)
 	return processorhelper.NewLogs(
 		ctx,
 		set,
@@ -57,6 +97,31 @@ func createLogsProcessor(
 		next,
 		beamProcessor.processLogs,
 		processorhelper.WithCapabilities(processorCapabilities),
-		processorhelper.WithStart(beamProcessor.start),
+		processorhelper.WithStart(shared.Start),
+		processorhelper.WithShutdown(shared.Shutdown),
+	)
+}
+
+func createTracesProcessor(
+	ctx context.Context,
+	set processor.Settings,
+	cfg component.Config,
+	next consumer.Traces,
+) (processor.Traces, error) {
+	shared, err := processors.LoadOrStore(cfg, func() (*truthBeamProcessor, error) {
+		return newTruthBeamProcessor(cfg, set)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return processorhelper.NewTraces(
+		ctx,
+		set,
+		cfg,
+		next,
+		shared.Unwrap().processTraces,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(shared.Start),
+		processorhelper.WithShutdown(shared.Shutdown),
 	)
 }
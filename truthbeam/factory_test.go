@@ -1,14 +1,20 @@
 package truthbeam
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/processor/processortest"
 
 	"github.com/complytime/complybeacon/truthbeam/internal/client"
+	"github.com/complytime/complybeacon/truthbeam/internal/metadata"
 )
 
 // The factory tests validate processor factory lifecycle including creation,
@@ -31,6 +37,8 @@ func TestCreateDefaultConfig(t *testing.T) {
 	assert.Equal(t, 512*1024, cfg.ClientConfig.WriteBufferSize, "Expected write buffer size 512KB")
 	assert.Equal(t, client.DefaultCacheTTL, cfg.CacheTTL, "Expected default cache TTL to be 24 hours")
 	assert.Equal(t, client.DefaultCacheCapacity, cfg.CacheCapacity, "Expected cache capacity to be default")
+	assert.True(t, cfg.Retry.Enabled, "Expected retries to be enabled by default")
+	assert.True(t, cfg.CircuitBreaker.Enabled, "Expected circuit breaker to be enabled by default")
 }
 
 func TestCreateLogsProcessor(t *testing.T) {
@@ -49,6 +57,106 @@ func TestCreateLogsProcessor(t *testing.T) {
 	assert.Contains(t, err.Error(), "endpoint must be specified")
 }
 
+func TestCreateTracesProcessor(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = "http://localhost:8081"
+
+	assert.Equal(t, metadata.TracesStability, factory.TracesStability())
+
+	tracesProcessor, err := factory.CreateTraces(context.Background(), processortest.NewNopSettings(metadata.Type), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	assert.True(t, tracesProcessor.Capabilities().MutatesData)
+}
+
+func TestCreateProcessorsShared(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = "http://localhost:8081"
+	settings := processortest.NewNopSettings(metadata.Type)
+
+	logsProcessor, err := factory.CreateLogs(context.Background(), settings, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	tracesProcessor, err := factory.CreateTraces(context.Background(), settings, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+
+	// Both pipelines use the processor created for the logs pipeline.
+	shared, ok := processors.Load(cfg)
+	require.True(t, ok)
+	beamProcessor := shared.Unwrap()
+	assert.Len(t, beamProcessor.logsPipelines, 1, "the logs pipeline registers its next consumer")
+
+	other := factory.CreateDefaultConfig().(*Config)
+	other.ClientConfig.Endpoint = "http://localhost:8082"
+	otherProcessor, err := factory.CreateTraces(context.Background(), settings, other, consumertest.NewNop())
+	require.NoError(t, err)
+	otherShared, ok := processors.Load(other)
+	require.True(t, ok)
+	assert.NotSame(t, beamProcessor, otherShared.Unwrap())
+	require.NoError(t, otherProcessor.Shutdown(context.Background()))
+
+	// The shared processor is started once and shut down with the last
+	// pipeline.
+	require.NoError(t, logsProcessor.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, tracesProcessor.Start(context.Background(), componenttest.NewNopHost()))
+	require.NotNil(t, beamProcessor.client)
+	require.NotNil(t, beamProcessor.telemetryRegistration)
+	require.NoError(t, tracesProcessor.Shutdown(context.Background()))
+	assert.NotNil(t, beamProcessor.telemetryRegistration, "the logs pipeline still uses the processor")
+	require.NoError(t, logsProcessor.Shutdown(context.Background()))
+	assert.Nil(t, beamProcessor.telemetryRegistration)
+
+	_, ok = processors.Load(cfg)
+	assert.False(t, ok, "a shut down processor is not reused")
+}
+
+func TestCreateProcessorsSharedLogs(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = "http://localhost:8081"
+	settings := processortest.NewNopSettings(metadata.Type)
+
+	first, second := new(consumertest.LogsSink), new(consumertest.LogsSink)
+	firstProcessor, err := factory.CreateLogs(context.Background(), settings, cfg, first)
+	require.NoError(t, err)
+	secondProcessor, err := factory.CreateLogs(context.Background(), settings, cfg, second)
+	require.NoError(t, err)
+
+	shared, ok := processors.Load(cfg)
+	require.True(t, ok)
+	beamProcessor := shared.Unwrap()
+	require.Len(t, beamProcessor.logsPipelines, 2)
+
+	// Each pipeline passes records to its own next consumer.
+	require.NoError(t, firstProcessor.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, secondProcessor.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, firstProcessor.ConsumeLogs(context.Background(), plog.NewLogs()))
+	assert.Len(t, first.AllLogs(), 1)
+	assert.Empty(t, second.AllLogs())
+	require.NoError(t, secondProcessor.ConsumeLogs(context.Background(), plog.NewLogs()))
+	assert.Len(t, second.AllLogs(), 1)
+
+	// Shutting down one pipeline leaves the processor running for the
+	// other.
+	require.NoError(t, firstProcessor.Shutdown(context.Background()))
+	assert.NotNil(t, beamProcessor.telemetryRegistration)
+	require.NoError(t, secondProcessor.ConsumeLogs(context.Background(), plog.NewLogs()))
+	assert.Len(t, second.AllLogs(), 2)
+	require.NoError(t, secondProcessor.Shutdown(context.Background()))
+	assert.Nil(t, beamProcessor.telemetryRegistration)
+
+	// Held records cannot be released to the pipeline they came from.
+	cfg.FailClosed.Enabled = true
+	firstProcessor, err = factory.CreateLogs(context.Background(), settings, cfg, first)
+	require.NoError(t, err)
+	secondProcessor, err = factory.CreateLogs(context.Background(), settings, cfg, second)
+	require.NoError(t, err)
+	err = firstProcessor.Start(context.Background(), componenttest.NewNopHost())
+	assert.ErrorContains(t, err, "fail_closed needs a separate component per logs pipeline")
+	require.NoError(t, firstProcessor.Shutdown(context.Background()))
+	require.NoError(t, secondProcessor.Shutdown(context.Background()))
+}
+
 func TestConfigValidation(t *testing.T) {
 	validConfig := getValidConfig()
 	err := validConfig.Validate()
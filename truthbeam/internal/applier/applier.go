@@ -3,36 +3,136 @@ package applier
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.uber.org/zap"
 
 	"github.com/complytime/complybeacon/truthbeam/internal/client"
 )
 
+// ExtractionError is returned when the lookup attributes of a record are
+// missing or empty.
+type ExtractionError struct {
+	// Attributes are the lookup attributes that could not be read.
+	Attributes []string
+	// Empty is set when the attribute is present but has no value.
+	Empty bool
+}
+
+func (e *ExtractionError) Error() string {
+	if e.Empty {
+		return fmt.Sprintf("required attribute %s is empty", strings.Join(e.Attributes, ", "))
+	}
+	return fmt.Sprintf("missing required attributes: %s", strings.Join(e.Attributes, ", "))
+}
+
 // Applier handles the application of enrichment data to log records
 type Applier struct {
-	logger *zap.Logger
+	logger        *zap.Logger
+	extraction    Extraction
+	statusMapping map[string]map[string]Status
+	ocsfBody      bool
+	// severity is nil unless log record severities are set.
+	severity                  map[string]map[string]plog.SeverityNumber
+	originalSeverityAttribute string
+	// now returns the time exceptions are checked against.
+	now func() time.Time
+}
+
+// Option configures an Applier.
+type Option func(*Applier)
+
+// WithExtraction sets where the lookup fields are read from. Fields without
+// sources keep their default attribute.
+func WithExtraction(extraction Extraction) Option {
+	return func(a *Applier) {
+		extraction.setDefaults()
+		a.extraction = extraction
+	}
+}
+
+// WithStatusMapping overrides the compliance status derived from evaluation
+// results for the given policy engines. A mapping with an invalid status is
+// ignored; use StatusMapping.Validate to report it.
+func WithStatusMapping(mapping StatusMapping) Option {
+	return func(a *Applier) {
+		if parsed, err := mapping.parse(); err == nil {
+			a.statusMapping = parsed
+		}
+	}
+}
+
+// WithOCSFBody also writes the compliance context into log record bodies
+// holding an OCSF event, so body and attributes stay consistent.
+func WithOCSFBody(enabled bool) Option {
+	return func(a *Applier) {
+		a.ocsfBody = enabled
+	}
+}
+
+// WithSeverity sets the severity of log records from their compliance status and risk
+// level following matrix, or DefaultSeverityMatrix when it is empty. When originalAttribute
+// is set, the original severity is kept in that attribute. An invalid matrix is ignored;
+// use SeverityMatrix.Validate to report it.
+func WithSeverity(matrix SeverityMatrix, originalAttribute string) Option {
+	return func(a *Applier) {
+		if len(matrix) == 0 {
+			matrix = DefaultSeverityMatrix()
+		}
+		if parsed, err := matrix.parse(); err == nil {
+			a.severity = parsed
+			a.originalSeverityAttribute = originalAttribute
+		}
+	}
 }
 
 // NewApplier creates a new Applier struct.
-func NewApplier(logger *zap.Logger) *Applier {
-	return &Applier{
-		logger: logger,
+func NewApplier(logger *zap.Logger, opts ...Option) *Applier {
+	a := &Applier{
+		logger:     logger,
+		extraction: DefaultExtraction(),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(a)
 	}
+	return a
 }
 
 // Apply applies the given compliance data to a log record following beacon semantic conventions.
-// The compliance status is dynamically calculated from the result.
-func (a *Applier) Apply(logRecord plog.LogRecord, compliance client.Compliance, result string) error {
+// The compliance status is dynamically calculated from the result, using the status mapping
+// of the policy engine when one is configured. A record covered by an unexpired exception
+// returned by Compass is marked Exempt. With WithOCSFBody, an OCSF event body is updated too,
+// and with WithSeverity, the record severity.
+func (a *Applier) Apply(logRecord plog.LogRecord, policy client.Policy, compliance client.Compliance, result string) error {
 	attrs := logRecord.Attributes()
+	if err := a.ApplyAttributes(attrs, policy, compliance, result); err != nil {
+		return err
+	}
+	a.applySeverity(logRecord)
+	if a.ocsfBody && enriched(compliance) {
+		status, _ := attributeValue(attrs, COMPLIANCE_STATUS)
+		applyOCSFBody(logRecord.Body(), status, compliance)
+	}
+	return nil
+}
+
+// ApplyAttributes applies the given compliance data to the attributes of a log record,
+// span or span event, as Apply does.
+func (a *Applier) ApplyAttributes(attrs pcommon.Map, policy client.Policy, compliance client.Compliance, result string) error {
+	if exception, ok := a.activeException(attrs, compliance); ok {
+		attrs.PutStr(COMPLIANCE_REMEDIATION_EXCEPTION_ID, exception.Id)
+		attrs.PutBool(COMPLIANCE_REMEDIATION_EXCEPTION_ACTIVE, true)
+	}
 
 	// Map the evaluation result to a compliance status
-	status := mapResult(result)
+	status := a.status(attrs, policy.PolicyEngineName, result)
 	attrs.PutStr(COMPLIANCE_STATUS, status.String())
 
 	attrs.PutStr(COMPLIANCE_ENRICHMENT_STATUS, string(compliance.EnrichmentStatus))
-	if compliance.EnrichmentStatus == client.Unmapped {
+	if !enriched(compliance) {
 		return nil
 	}
 
@@ -64,41 +164,127 @@ func (a *Applier) Apply(logRecord plog.LogRecord, compliance client.Compliance,
 	return nil
 }
 
+// enriched reports whether Compass returned compliance context for the policy.
+func enriched(compliance client.Compliance) bool {
+	switch compliance.EnrichmentStatus {
+	case client.Unmapped, client.Unknown, client.Skipped:
+		return false
+	}
+	return true
+}
+
+// activeException returns the first exception in compliance that has not expired and
+// applies to the policy target of the record. Expiry is checked here because enrichment
+// results are cached.
+func (a *Applier) activeException(attrs pcommon.Map, compliance client.Compliance) (client.ComplianceException, bool) {
+	if compliance.Exceptions == nil {
+		return client.ComplianceException{}, false
+	}
+	now := a.now()
+	target, _ := attributeValue(attrs, POLICY_TARGET_ID)
+	for _, exception := range *compliance.Exceptions {
+		if !now.Before(exception.Expires) {
+			continue
+		}
+		if exception.Target != nil && *exception.Target != target {
+			continue
+		}
+		return exception, true
+	}
+	return client.ComplianceException{}, false
+}
+
+// ApplyFailure marks a log record that could not be enriched, so it can be told apart
+// from one truthbeam never saw. The enrichment status is Skipped when the lookup
+// attributes could not be extracted and Unknown when Compass could not be reached.
+// With WithSeverity, the record severity is set from its compliance status.
+func (a *Applier) ApplyFailure(logRecord plog.LogRecord, policy client.Policy, enrichmentStatus client.ComplianceEnrichmentStatus, result string, reason error) {
+	a.ApplyFailureAttributes(logRecord.Attributes(), policy, enrichmentStatus, result, reason)
+	a.applySeverity(logRecord)
+}
+
+// ApplyFailureAttributes marks the attributes of a log record, span or span event that
+// could not be enriched, as ApplyFailure does.
+func (a *Applier) ApplyFailureAttributes(attrs pcommon.Map, policy client.Policy, enrichmentStatus client.ComplianceEnrichmentStatus, result string, reason error) {
+	attrs.PutStr(COMPLIANCE_STATUS, a.status(attrs, policy.PolicyEngineName, result).String())
+	attrs.PutStr(COMPLIANCE_ENRICHMENT_STATUS, string(enrichmentStatus))
+	if reason != nil {
+		attrs.PutStr(COMPLIANCE_ENRICHMENT_REASON, reason.Error())
+	}
+}
+
 // Extract extracts policy data from a log record for requests for compliance context.
 func (a *Applier) Extract(logRecord plog.LogRecord) (client.Policy, string, error) {
-	attrs := logRecord.Attributes()
+	return a.ExtractFrom(pcommon.NewResource(), pcommon.NewInstrumentationScope(), logRecord)
+}
+
+// ExtractFrom extracts policy data from a log record and the resource and scope it
+// was emitted by, following the configured extraction sources.
+func (a *Applier) ExtractFrom(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) (client.Policy, string, error) {
+	return a.extract(&record{resource: resource, scope: scope, attributes: logRecord.Attributes(), body: logRecord.Body()})
+}
+
+// ExtractAttributes extracts policy data from the attributes of a span or span event and
+// the resource and scope it was emitted by. Body path sources never match.
+func (a *Applier) ExtractAttributes(resource pcommon.Resource, scope pcommon.InstrumentationScope, attrs pcommon.Map) (client.Policy, string, error) {
+	return a.extract(&record{resource: resource, scope: scope, attributes: attrs, body: pcommon.NewValueEmpty()})
+}
 
+// IsEvidence reports whether the attributes of a span or span event carry
+// evidence: whether they hold an attribute source of the policy rule ID.
+// Defaults and resource or scope sources apply to every span, so they do
+// not count. When the rule ID has no attribute sources, an attribute source
+// of any lookup field counts, and without any every span is evidence.
+func (a *Applier) IsEvidence(attrs pcommon.Map) bool {
+	fields := []Field{a.extraction.PolicyRuleID}
+	if !fields[0].hasAttributeSource() {
+		fields = []Field{a.extraction.PolicyRuleID, a.extraction.PolicyEngineName, a.extraction.PolicyEvaluationResult}
+	}
+
+	found := false
+	for _, field := range fields {
+		for _, source := range field.Sources {
+			if source.Attribute == "" {
+				continue
+			}
+			found = true
+			if _, ok := attrs.Get(source.Attribute); ok {
+				return true
+			}
+		}
+	}
+	return !found
+}
+
+func (a *Applier) extract(r *record) (client.Policy, string, error) {
 	// Retrieve lookup attributes
 	var missingAttrs []string
 
-	policyRuleIDVal, ok := attrs.Get(POLICY_RULE_ID)
+	policyRuleId, ok := a.extraction.PolicyRuleID.extract(r)
 	if !ok {
 		missingAttrs = append(missingAttrs, POLICY_RULE_ID)
 	}
 
-	policySourceVal, ok := attrs.Get(POLICY_ENGINE_NAME)
+	policyEngineName, ok := a.extraction.PolicyEngineName.extract(r)
 	if !ok {
 		missingAttrs = append(missingAttrs, POLICY_ENGINE_NAME)
 	}
 
-	policyEvalStatusVal, ok := attrs.Get(POLICY_EVALUATION_RESULT)
+	result, ok := a.extraction.PolicyEvaluationResult.extract(r)
 	if !ok {
 		missingAttrs = append(missingAttrs, POLICY_EVALUATION_RESULT)
 	}
 
 	if len(missingAttrs) > 0 {
-		return client.Policy{}, "", fmt.Errorf("missing required attributes: %s", strings.Join(missingAttrs, ", "))
+		return client.Policy{}, "", &ExtractionError{Attributes: missingAttrs}
 	}
 
-	policyEngineName := strings.TrimSpace(policySourceVal.Str())
-	policyRuleId := strings.TrimSpace(policyRuleIDVal.Str())
-
 	// Validate that values are not empty
 	if policyEngineName == "" {
-		return client.Policy{}, "", fmt.Errorf("required attribute %s is empty", POLICY_ENGINE_NAME)
+		return client.Policy{}, "", &ExtractionError{Attributes: []string{POLICY_ENGINE_NAME}, Empty: true}
 	}
 	if policyRuleId == "" {
-		return client.Policy{}, "", fmt.Errorf("required attribute %s is empty", POLICY_RULE_ID)
+		return client.Policy{}, "", &ExtractionError{Attributes: []string{POLICY_RULE_ID}, Empty: true}
 	}
 
 	policy := client.Policy{
@@ -106,5 +292,5 @@ func (a *Applier) Extract(logRecord plog.LogRecord) (client.Policy, string, erro
 		PolicyRuleId:     policyRuleId,
 	}
 
-	return policy, policyEvalStatusVal.AsString(), nil
+	return policy, result, nil
 }
@@ -1,6 +1,8 @@
 package applier
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -389,7 +391,7 @@ func TestApplier_Apply(t *testing.T) {
 			attrs := logRecord.Attributes()
 
 			// Apply enrichment
-			err := applier.Apply(logRecord, tt.compliance, tt.status)
+			err := applier.Apply(logRecord, client.Policy{}, tt.compliance, tt.status)
 
 			if tt.expectedError {
 				require.Error(t, err)
@@ -456,6 +458,11 @@ func TestApplier_StatusMapping(t *testing.T) {
 			inputStatus:    "Not Run",
 			expectedStatus: "Not Applicable",
 		},
+		{
+			name:           "Needs Review maps to NON_COMPLIANT",
+			inputStatus:    "Needs Review",
+			expectedStatus: "Non-Compliant",
+		},
 		{
 			name:           "Unknown maps to UNKNOWN",
 			inputStatus:    "Unknown",
@@ -496,7 +503,7 @@ func TestApplier_StatusMapping(t *testing.T) {
 			}
 
 			// Apply enrichment
-			err := applier.Apply(logRecord, compliance, tt.inputStatus)
+			err := applier.Apply(logRecord, client.Policy{}, compliance, tt.inputStatus)
 			require.NoError(t, err)
 
 			// Verify the status mapping
@@ -507,6 +514,308 @@ func TestApplier_StatusMapping(t *testing.T) {
 	}
 }
 
+func TestApplier_StatusOverrides(t *testing.T) {
+	mapping := StatusMapping{
+		"manual-review": {"Needs Review": "Unknown", "warn": "Non-Compliant"},
+	}
+	require.NoError(t, mapping.Validate())
+	applier := NewApplier(zap.NewNop(), WithStatusMapping(mapping))
+
+	tests := []struct {
+		name            string
+		engine          string
+		result          string
+		exceptionActive any
+		expectedStatus  string
+	}{
+		{name: "Engine mapping", engine: "manual-review", result: "Needs Review", expectedStatus: "Unknown"},
+		{name: "Engine specific result", engine: "manual-review", result: "warn", expectedStatus: "Non-Compliant"},
+		{name: "Unmapped result uses default", engine: "manual-review", result: "Passed", expectedStatus: "Compliant"},
+		{name: "Other engine uses default", engine: "opa", result: "Needs Review", expectedStatus: "Non-Compliant"},
+		{name: "Active exception", engine: "opa", result: "Failed", exceptionActive: true, expectedStatus: "Exempt"},
+		{name: "Active exception as string", engine: "opa", result: "Failed", exceptionActive: "true", expectedStatus: "Exempt"},
+		{name: "Inactive exception", engine: "opa", result: "Failed", exceptionActive: false, expectedStatus: "Non-Compliant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logRecord := plog.NewLogRecord()
+			switch active := tt.exceptionActive.(type) {
+			case bool:
+				logRecord.Attributes().PutBool(COMPLIANCE_REMEDIATION_EXCEPTION_ACTIVE, active)
+			case string:
+				logRecord.Attributes().PutStr(COMPLIANCE_REMEDIATION_EXCEPTION_ACTIVE, active)
+			}
+
+			policy := client.Policy{PolicyEngineName: tt.engine, PolicyRuleId: "rule-1"}
+			require.NoError(t, applier.Apply(logRecord, policy, client.Compliance{EnrichmentStatus: client.Unmapped}, tt.result))
+			assert.Equal(t, tt.expectedStatus, logRecord.Attributes().AsRaw()[COMPLIANCE_STATUS])
+		})
+	}
+
+	assert.Error(t, StatusMapping{"opa": {"Passed": "Fine"}}.Validate())
+}
+
+func TestApplier_ApplyExceptions(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	applier := NewApplier(zap.NewNop())
+	applier.now = func() time.Time { return now }
+
+	clusterA := "cluster-a"
+	compliance := client.Compliance{
+		EnrichmentStatus: client.Success,
+		Control:          client.ComplianceControl{Id: "AC-1", CatalogId: "NIST-800-53", Category: "Access Control"},
+		Exceptions: &[]client.ComplianceException{
+			{Id: "EX-expired", Approver: "a", Reason: "r", Expires: now.Add(-time.Hour)},
+			{Id: "EX-cluster-a", Target: &clusterA, Approver: "a", Reason: "r", Expires: now.Add(time.Hour)},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		target            string
+		expectedStatus    string
+		expectedException string
+	}{
+		{
+			name:              "Matching target is exempt",
+			target:            "cluster-a",
+			expectedStatus:    "Exempt",
+			expectedException: "EX-cluster-a",
+		},
+		{
+			name:           "Other target keeps its status",
+			target:         "cluster-b",
+			expectedStatus: "Non-Compliant",
+		},
+		{
+			name:           "Record without target keeps its status",
+			expectedStatus: "Non-Compliant",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logRecord := plog.NewLogRecord()
+			if tt.target != "" {
+				logRecord.Attributes().PutStr(POLICY_TARGET_ID, tt.target)
+			}
+
+			err := applier.Apply(logRecord, client.Policy{PolicyEngineName: "engine", PolicyRuleId: "rule"}, compliance, "Failed")
+			require.NoError(t, err)
+
+			attrs := logRecord.Attributes().AsRaw()
+			assert.Equal(t, tt.expectedStatus, attrs[COMPLIANCE_STATUS])
+			if tt.expectedException == "" {
+				assert.NotContains(t, attrs, COMPLIANCE_REMEDIATION_EXCEPTION_ID)
+				assert.NotContains(t, attrs, COMPLIANCE_REMEDIATION_EXCEPTION_ACTIVE)
+				return
+			}
+			assert.Equal(t, tt.expectedException, attrs[COMPLIANCE_REMEDIATION_EXCEPTION_ID])
+			assert.Equal(t, true, attrs[COMPLIANCE_REMEDIATION_EXCEPTION_ACTIVE])
+		})
+	}
+}
+
+func TestApplier_ApplyOCSFBody(t *testing.T) {
+	ocsfBody := `{"policy":{"uid":"github_branch_protection"},"status":"failure","metadata":{"product":{"name":"conforma"}},` +
+		`"compliance":{"checks":[{"name":"branch-protection"}]},"category_uid":6,"class_uid":6007,"time":1757087791576}`
+	compliance := client.Compliance{
+		EnrichmentStatus: client.Success,
+		Control:          client.ComplianceControl{Id: "AC-1", CatalogId: "NIST-800-53", Category: "Access Control"},
+		Frameworks: client.ComplianceFrameworks{
+			Requirements: []string{"AC-1.1"},
+			Frameworks:   []string{"NIST-800-53"},
+		},
+		Risk: &client.ComplianceRisk{Level: client.High},
+	}
+	policy := client.Policy{PolicyEngineName: "conforma", PolicyRuleId: "github_branch_protection"}
+
+	t.Run("String body", func(t *testing.T) {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr(ocsfBody)
+
+		require.NoError(t, NewApplier(zap.NewNop(), WithOCSFBody(true)).Apply(logRecord, policy, compliance, "Failed"))
+
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(logRecord.Body().Str()), &event))
+		assert.Equal(t, map[string]any{
+			"control":      "AC-1",
+			"category":     "Access Control",
+			"requirements": []any{"AC-1.1"},
+			"standards":    []any{"NIST-800-53"},
+			"status":       "Fail",
+			"status_id":    float64(3),
+			"checks":       []any{map[string]any{"name": "branch-protection"}},
+		}, event["compliance"])
+		assert.Equal(t, float64(4), event["severity_id"])
+		assert.Equal(t, "High", event["severity"])
+		assert.Contains(t, logRecord.Body().Str(), `"time":1757087791576`)
+	})
+
+	t.Run("Map body", func(t *testing.T) {
+		logRecord := plog.NewLogRecord()
+		require.NoError(t, logRecord.Body().SetEmptyMap().FromRaw(map[string]any{
+			"class_uid": 6007,
+			"metadata":  map[string]any{"version": "1.5.0"},
+		}))
+
+		require.NoError(t, NewApplier(zap.NewNop(), WithOCSFBody(true)).Apply(logRecord, policy, compliance, "Passed"))
+
+		body := logRecord.Body().Map().AsRaw()
+		assert.Equal(t, "Pass", body["compliance"].(map[string]any)["status"])
+		assert.Equal(t, int64(4), body["severity_id"])
+	})
+
+	t.Run("Non-OCSF body is untouched", func(t *testing.T) {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr(`{"rule":"deny-root"}`)
+
+		require.NoError(t, NewApplier(zap.NewNop(), WithOCSFBody(true)).Apply(logRecord, policy, compliance, "Failed"))
+		assert.Equal(t, `{"rule":"deny-root"}`, logRecord.Body().Str())
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr(ocsfBody)
+
+		require.NoError(t, NewApplier(zap.NewNop()).Apply(logRecord, policy, compliance, "Failed"))
+		assert.Equal(t, ocsfBody, logRecord.Body().Str())
+	})
+
+	t.Run("Unmapped leaves the body untouched", func(t *testing.T) {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr(ocsfBody)
+
+		unmapped := client.Compliance{EnrichmentStatus: client.Unmapped}
+		require.NoError(t, NewApplier(zap.NewNop(), WithOCSFBody(true)).Apply(logRecord, policy, unmapped, "Failed"))
+		assert.Equal(t, ocsfBody, logRecord.Body().Str())
+	})
+}
+
+func TestApplier_ApplySeverity(t *testing.T) {
+	policy := client.Policy{PolicyEngineName: "engine", PolicyRuleId: "rule"}
+	withRisk := func(level client.ComplianceRiskLevel) client.Compliance {
+		return client.Compliance{
+			EnrichmentStatus: client.Success,
+			Control:          client.ComplianceControl{Id: "AC-1"},
+			Risk:             &client.ComplianceRisk{Level: level},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		matrix           SeverityMatrix
+		compliance       client.Compliance
+		result           string
+		expectedNumber   plog.SeverityNumber
+		expectedText     string
+		expectedOriginal string
+	}{
+		{
+			name:             "Critical Non-Compliant is fatal",
+			compliance:       withRisk(client.Critical),
+			result:           "Failed",
+			expectedNumber:   plog.SeverityNumberFatal,
+			expectedText:     "FATAL",
+			expectedOriginal: "INFO",
+		},
+		{
+			name:             "Non-Compliant without risk uses the default",
+			compliance:       client.Compliance{EnrichmentStatus: client.Success},
+			result:           "Failed",
+			expectedNumber:   plog.SeverityNumberWarn,
+			expectedText:     "WARN",
+			expectedOriginal: "INFO",
+		},
+		{
+			name:             "Compliant is info",
+			compliance:       withRisk(client.Critical),
+			result:           "Passed",
+			expectedNumber:   plog.SeverityNumberInfo,
+			expectedText:     "INFO",
+			expectedOriginal: "INFO",
+		},
+		{
+			name:             "Configured matrix",
+			matrix:           SeverityMatrix{"Non-Compliant": {"High": "error3"}},
+			compliance:       withRisk(client.High),
+			result:           "Failed",
+			expectedNumber:   plog.SeverityNumberError3,
+			expectedText:     "ERROR3",
+			expectedOriginal: "INFO",
+		},
+		{
+			name:           "Status not in the matrix keeps its severity",
+			matrix:         SeverityMatrix{"Non-Compliant": {"High": "ERROR"}},
+			compliance:     withRisk(client.High),
+			result:         "Passed",
+			expectedNumber: plog.SeverityNumberInfo,
+			expectedText:   "INFO",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applier := NewApplier(zap.NewNop(), WithSeverity(tt.matrix, "log.original.severity"))
+
+			logRecord := plog.NewLogRecord()
+			logRecord.SetSeverityNumber(plog.SeverityNumberInfo)
+			logRecord.SetSeverityText("INFO")
+			require.NoError(t, applier.Apply(logRecord, policy, tt.compliance, tt.result))
+
+			assert.Equal(t, tt.expectedNumber, logRecord.SeverityNumber())
+			assert.Equal(t, tt.expectedText, logRecord.SeverityText())
+			original, ok := logRecord.Attributes().Get("log.original.severity")
+			if tt.expectedOriginal == "" {
+				assert.False(t, ok)
+				return
+			}
+			assert.Equal(t, tt.expectedOriginal, original.Str())
+		})
+	}
+
+	t.Run("Failures use their status", func(t *testing.T) {
+		applier := NewApplier(zap.NewNop(), WithSeverity(nil, ""))
+		logRecord := plog.NewLogRecord()
+		applier.ApplyFailure(logRecord, policy, client.Unknown, "", errors.New("compass unavailable"))
+		assert.Equal(t, plog.SeverityNumberWarn, logRecord.SeverityNumber())
+		assert.Equal(t, "WARN", logRecord.SeverityText())
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		logRecord := plog.NewLogRecord()
+		require.NoError(t, NewApplier(zap.NewNop()).Apply(logRecord, policy, withRisk(client.Critical), "Failed"))
+		assert.Equal(t, plog.SeverityNumberUnspecified, logRecord.SeverityNumber())
+	})
+}
+
+func TestSeverityMatrix_Validate(t *testing.T) {
+	assert.NoError(t, DefaultSeverityMatrix().Validate())
+	assert.ErrorContains(t, SeverityMatrix{"Failing": {"High": "ERROR"}}.Validate(), `unknown compliance status "Failing"`)
+	assert.ErrorContains(t, SeverityMatrix{"Non-Compliant": {"Severe": "ERROR"}}.Validate(), `unknown risk level "Severe"`)
+	assert.ErrorContains(t, SeverityMatrix{"Non-Compliant": {"High": "LOUD"}}.Validate(), `unknown severity "LOUD"`)
+}
+
+func TestApplier_ApplyFailure(t *testing.T) {
+	applier := NewApplier(zap.NewNop())
+
+	logRecord := plog.NewLogRecord()
+	applier.ApplyFailure(logRecord, client.Policy{}, client.Skipped, "", errors.New("missing required attributes: policy.rule.id"))
+	attrs := logRecord.Attributes().AsRaw()
+	assert.Equal(t, "Unknown", attrs[COMPLIANCE_STATUS])
+	assert.Equal(t, "Skipped", attrs[COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Equal(t, "missing required attributes: policy.rule.id", attrs[COMPLIANCE_ENRICHMENT_REASON])
+
+	logRecord = plog.NewLogRecord()
+	applier.ApplyFailure(logRecord, client.Policy{}, client.Unknown, "Failed", errors.New("compass circuit breaker is open"))
+	attrs = logRecord.Attributes().AsRaw()
+	assert.Equal(t, "Non-Compliant", attrs[COMPLIANCE_STATUS])
+	assert.Equal(t, "Unknown", attrs[COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Equal(t, "compass circuit breaker is open", attrs[COMPLIANCE_ENRICHMENT_REASON])
+	assert.NotContains(t, attrs, COMPLIANCE_CONTROL_ID)
+}
+
 func TestApplier_NewApplier(t *testing.T) {
 	logger := zap.NewNop()
 	applier := NewApplier(logger)
@@ -534,7 +843,7 @@ func TestApplier_ApplyWithEmptyArrays(t *testing.T) {
 	logRecord := plog.NewLogRecord()
 	attrs := logRecord.Attributes()
 
-	err := applier.Apply(logRecord, compliance, "Passed")
+	err := applier.Apply(logRecord, client.Policy{}, compliance, "Passed")
 	require.NoError(t, err)
 
 	// Verify that empty arrays are handled correctly
@@ -553,3 +862,206 @@ func TestApplier_ApplyWithEmptyArrays(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestApplier_ExtractFrom(t *testing.T) {
+	tests := []struct {
+		name           string
+		extraction     Extraction
+		setup          func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord)
+		expectedPolicy client.Policy
+		expectedResult string
+		errorContains  string
+	}{
+		{
+			name: "Resource and scope attributes",
+			extraction: Extraction{
+				PolicyRuleID:           Field{Sources: []Source{{ResourceAttribute: "rule"}}},
+				PolicyEngineName:       Field{Sources: []Source{{ScopeAttribute: "engine"}}},
+				PolicyEvaluationResult: Field{Sources: []Source{{Attribute: "outcome"}}},
+			},
+			setup: func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
+				resource.Attributes().PutStr("rule", "rule-1")
+				scope.Attributes().PutStr("engine", "engine-1")
+				logRecord.Attributes().PutStr("outcome", "Passed")
+			},
+			expectedPolicy: client.Policy{PolicyRuleId: "rule-1", PolicyEngineName: "engine-1"},
+			expectedResult: "Passed",
+		},
+		{
+			name: "First non-empty source wins",
+			extraction: Extraction{
+				PolicyRuleID: Field{Sources: []Source{{Attribute: "missing"}, {Attribute: "blank"}, {ResourceAttribute: "rule"}}, Trim: true},
+			},
+			setup: func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
+				resource.Attributes().PutStr("rule", "rule-1")
+				logRecord.Attributes().PutStr("blank", "  ")
+				logRecord.Attributes().PutStr(POLICY_ENGINE_NAME, "engine-1")
+				logRecord.Attributes().PutStr(POLICY_EVALUATION_RESULT, "Passed")
+			},
+			expectedPolicy: client.Policy{PolicyRuleId: "rule-1", PolicyEngineName: "engine-1"},
+			expectedResult: "Passed",
+		},
+		{
+			name: "Map body path",
+			extraction: Extraction{
+				PolicyRuleID:           Field{Sources: []Source{{BodyPath: "$.finding.rule"}}},
+				PolicyEngineName:       Field{Sources: []Source{{BodyPath: "metadata.product.name"}}},
+				PolicyEvaluationResult: Field{Sources: []Source{{BodyPath: "results[0].status"}}},
+			},
+			setup: func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
+				body := logRecord.Body().SetEmptyMap()
+				body.PutEmptyMap("finding").PutStr("rule", "rule-1")
+				body.PutEmptyMap("metadata").PutEmptyMap("product").PutStr("name", "engine-1")
+				body.PutEmptySlice("results").AppendEmpty().SetEmptyMap().PutStr("status", "Failed")
+			},
+			expectedPolicy: client.Policy{PolicyRuleId: "rule-1", PolicyEngineName: "engine-1"},
+			expectedResult: "Failed",
+		},
+		{
+			name: "JSON string body path",
+			extraction: Extraction{
+				PolicyRuleID:           Field{Sources: []Source{{BodyPath: "$.rule.id"}}},
+				PolicyEngineName:       Field{Sources: []Source{{BodyPath: "$.engine"}}},
+				PolicyEvaluationResult: Field{Sources: []Source{{BodyPath: "$.code"}}},
+			},
+			setup: func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
+				logRecord.Body().SetStr(`{"rule": {"id": "rule-1"}, "engine": "engine-1", "code": 3}`)
+			},
+			expectedPolicy: client.Policy{PolicyRuleId: "rule-1", PolicyEngineName: "engine-1"},
+			expectedResult: "3",
+		},
+		{
+			name: "Numeric body path",
+			extraction: Extraction{
+				PolicyRuleID:           Field{Sources: []Source{{BodyPath: "$.rule"}}},
+				PolicyEngineName:       Field{Sources: []Source{{BodyPath: "$.engine"}}},
+				PolicyEvaluationResult: Field{Sources: []Source{{BodyPath: "$.score"}}},
+			},
+			setup: func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
+				logRecord.Body().SetStr(`{"rule": 1234567, "engine": "engine-1", "score": 12345678.5}`)
+			},
+			expectedPolicy: client.Policy{PolicyRuleId: "1234567", PolicyEngineName: "engine-1"},
+			expectedResult: "12345678.5",
+		},
+		{
+			name: "Numeric map body path",
+			extraction: Extraction{
+				PolicyRuleID:           Field{Sources: []Source{{BodyPath: "$.rule"}}},
+				PolicyEngineName:       Field{Sources: []Source{{BodyPath: "$.engine"}}},
+				PolicyEvaluationResult: Field{Sources: []Source{{BodyPath: "$.score"}}},
+			},
+			setup: func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
+				body := logRecord.Body().SetEmptyMap()
+				body.PutInt("rule", 1234567)
+				body.PutStr("engine", "engine-1")
+				body.PutDouble("score", 1234567)
+			},
+			expectedPolicy: client.Policy{PolicyRuleId: "1234567", PolicyEngineName: "engine-1"},
+			expectedResult: "1234567",
+		},
+		{
+			name: "Defaults and normalization",
+			extraction: Extraction{
+				PolicyEngineName:       Field{Sources: []Source{{Attribute: "engine"}}, Default: "Default-Engine", Trim: true, Lowercase: true},
+				PolicyEvaluationResult: Field{Sources: []Source{{Attribute: "outcome"}}, Default: "Not Run"},
+			},
+			setup: func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
+				logRecord.Attributes().PutStr(POLICY_RULE_ID, " rule-1 ")
+			},
+			expectedPolicy: client.Policy{PolicyRuleId: "rule-1", PolicyEngineName: "default-engine"},
+			expectedResult: "Not Run",
+		},
+		{
+			name: "Missing body path",
+			extraction: Extraction{
+				PolicyRuleID: Field{Sources: []Source{{BodyPath: "$.rule.id"}}},
+			},
+			setup: func(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
+				logRecord.Body().SetStr("not json")
+				logRecord.Attributes().PutStr(POLICY_ENGINE_NAME, "engine-1")
+				logRecord.Attributes().PutStr(POLICY_EVALUATION_RESULT, "Passed")
+			},
+			errorContains: "missing required attributes: " + POLICY_RULE_ID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applier := NewApplier(zap.NewNop(), WithExtraction(tt.extraction))
+			resource := pcommon.NewResource()
+			scope := pcommon.NewInstrumentationScope()
+			logRecord := plog.NewLogRecord()
+			tt.setup(resource, scope, logRecord)
+
+			policy, result, err := applier.ExtractFrom(resource, scope, logRecord)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPolicy, policy)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
+
+func TestApplier_IsEvidence(t *testing.T) {
+	tests := []struct {
+		name       string
+		extraction Extraction
+		attrs      map[string]any
+		expected   bool
+	}{
+		{name: "default rule ID attribute", attrs: map[string]any{POLICY_RULE_ID: "rule-1"}, expected: true},
+		{name: "other attributes only", attrs: map[string]any{POLICY_ENGINE_NAME: "engine-1", "http.route": "/"}, expected: false},
+		{
+			name:       "engine default does not count",
+			extraction: Extraction{PolicyEngineName: Field{Default: "engine-1"}},
+			attrs:      map[string]any{"http.route": "/"},
+			expected:   false,
+		},
+		{
+			name: "rule ID from resource falls back to other fields",
+			extraction: Extraction{
+				PolicyRuleID:     Field{Sources: []Source{{ResourceAttribute: "rule"}}},
+				PolicyEngineName: Field{Sources: []Source{{Attribute: "engine"}}},
+			},
+			attrs:    map[string]any{"engine": "engine-1"},
+			expected: true,
+		},
+		{
+			name: "no attribute sources",
+			extraction: Extraction{
+				PolicyRuleID:           Field{Sources: []Source{{ResourceAttribute: "rule"}}},
+				PolicyEngineName:       Field{Sources: []Source{{ScopeAttribute: "engine"}}},
+				PolicyEvaluationResult: Field{Default: "Passed", Sources: []Source{{ResourceAttribute: "result"}}},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applier := NewApplier(zap.NewNop(), WithExtraction(tt.extraction))
+			attrs := pcommon.NewMap()
+			require.NoError(t, attrs.FromRaw(tt.attrs))
+			assert.Equal(t, tt.expected, applier.IsEvidence(attrs))
+		})
+	}
+}
+
+func TestExtraction_Validate(t *testing.T) {
+	extraction := Extraction{}
+	require.NoError(t, extraction.Validate())
+	assert.Equal(t, DefaultExtraction(), extraction)
+
+	extraction = Extraction{
+		PolicyRuleID: Field{Sources: []Source{{Attribute: "rule", BodyPath: "$.rule"}}},
+	}
+	assert.ErrorContains(t, extraction.Validate(), "policy_rule_id")
+
+	extraction = Extraction{
+		PolicyEngineName: Field{Sources: []Source{{}}},
+	}
+	assert.ErrorContains(t, extraction.Validate(), "policy_engine_name")
+}
@@ -17,6 +17,9 @@ const COMPLIANCE_CONTROL_CATEGORY = "compliance.control.category"
 // Unique identifier for the security control and assessment requirement being assessed
 const COMPLIANCE_CONTROL_ID = "compliance.control.id"
 
+// Reason the compliance context could not be added to the event, set when the enrichment status is Skipped or Unknown
+const COMPLIANCE_ENRICHMENT_REASON = "compliance.enrichment.reason"
+
 // Result of the compliance framework mapping and enrichment process, indicating whether compliance context was successfully added to the event
 const COMPLIANCE_ENRICHMENT_STATUS = "compliance.enrichment.status"
 
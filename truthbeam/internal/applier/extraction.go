@@ -0,0 +1,249 @@
+package applier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Source is one place a lookup field is read from. Exactly one of its
+// fields is set.
+type Source struct {
+	Attribute         string `mapstructure:"attribute"`          // Log record, span or span event attribute
+	ResourceAttribute string `mapstructure:"resource_attribute"` // Resource attribute
+	ScopeAttribute    string `mapstructure:"scope_attribute"`    // Instrumentation scope attribute
+	BodyPath          string `mapstructure:"body_path"`          // Dotted path into a map or JSON log body, such as $.rule.id or results[0].id
+}
+
+// Validate checks that exactly one location is set.
+func (s Source) Validate() error {
+	set := 0
+	for _, location := range []string{s.Attribute, s.ResourceAttribute, s.ScopeAttribute, s.BodyPath} {
+		if location != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("a source needs exactly one of attribute, resource_attribute, scope_attribute or body_path")
+	}
+	return nil
+}
+
+// Field configures how a lookup field is extracted from a record.
+type Field struct {
+	Sources   []Source `mapstructure:"sources"`   // Locations tried in order; the first non-empty value wins
+	Default   string   `mapstructure:"default"`   // Value used when no source has one
+	Trim      bool     `mapstructure:"trim"`      // Trim surrounding whitespace
+	Lowercase bool     `mapstructure:"lowercase"` // Convert to lower case
+}
+
+// Extraction configures the lookup fields read from each record.
+type Extraction struct {
+	PolicyRuleID           Field `mapstructure:"policy_rule_id"`           // Sets the policy rule ID
+	PolicyEngineName       Field `mapstructure:"policy_engine_name"`       // Sets the policy engine name
+	PolicyEvaluationResult Field `mapstructure:"policy_evaluation_result"` // Sets the evaluation result
+}
+
+// DefaultExtraction reads the lookup fields from the log record attributes
+// of the beacon semantic conventions.
+func DefaultExtraction() Extraction {
+	return Extraction{
+		PolicyRuleID:           Field{Sources: []Source{{Attribute: POLICY_RULE_ID}}, Trim: true},
+		PolicyEngineName:       Field{Sources: []Source{{Attribute: POLICY_ENGINE_NAME}}, Trim: true},
+		PolicyEvaluationResult: Field{Sources: []Source{{Attribute: POLICY_EVALUATION_RESULT}}},
+	}
+}
+
+// Validate fills fields without sources from DefaultExtraction and checks
+// every source.
+func (e *Extraction) Validate() error {
+	e.setDefaults()
+	fields := []struct {
+		name  string
+		field Field
+	}{
+		{name: "policy_rule_id", field: e.PolicyRuleID},
+		{name: "policy_engine_name", field: e.PolicyEngineName},
+		{name: "policy_evaluation_result", field: e.PolicyEvaluationResult},
+	}
+	for _, f := range fields {
+		for _, source := range f.field.Sources {
+			if err := source.Validate(); err != nil {
+				return fmt.Errorf("extraction %s: %w", f.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setDefaults reads fields without sources from their default attribute,
+// with the default normalization.
+func (e *Extraction) setDefaults() {
+	defaults := DefaultExtraction()
+	for _, f := range []struct{ field, fallback *Field }{
+		{field: &e.PolicyRuleID, fallback: &defaults.PolicyRuleID},
+		{field: &e.PolicyEngineName, fallback: &defaults.PolicyEngineName},
+		{field: &e.PolicyEvaluationResult, fallback: &defaults.PolicyEvaluationResult},
+	} {
+		if len(f.field.Sources) == 0 {
+			f.field.Sources = f.fallback.Sources
+			f.field.Trim = f.field.Trim || f.fallback.Trim
+		}
+	}
+}
+
+// record gives sources access to the attributes and body of a log record,
+// span or span event and its resource and scope. Spans and span events have
+// an empty body. A JSON body is parsed at most once.
+type record struct {
+	resource   pcommon.Resource
+	scope      pcommon.InstrumentationScope
+	attributes pcommon.Map
+	body       pcommon.Value
+
+	bodyParsed bool
+	parsed     any
+}
+
+// hasAttributeSource reports whether the field is read from a record, span
+// or span event attribute.
+func (f Field) hasAttributeSource() bool {
+	for _, source := range f.Sources {
+		if source.Attribute != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// extract returns the first non-empty value of the field. present is false
+// when no source holds the field at all and there is no default.
+func (f Field) extract(r *record) (value string, present bool) {
+	for _, source := range f.Sources {
+		raw, ok := r.lookup(source)
+		if !ok {
+			continue
+		}
+		present = true
+		if value = f.normalize(raw); value != "" {
+			return value, true
+		}
+	}
+	if f.Default != "" {
+		return f.normalize(f.Default), true
+	}
+	return "", present
+}
+
+func (f Field) normalize(value string) string {
+	if f.Trim {
+		value = strings.TrimSpace(value)
+	}
+	if f.Lowercase {
+		value = strings.ToLower(value)
+	}
+	return value
+}
+
+// lookup reads a source from the record.
+func (r *record) lookup(source Source) (string, bool) {
+	switch {
+	case source.Attribute != "":
+		return attributeValue(r.attributes, source.Attribute)
+	case source.ResourceAttribute != "":
+		return attributeValue(r.resource.Attributes(), source.ResourceAttribute)
+	case source.ScopeAttribute != "":
+		return attributeValue(r.scope.Attributes(), source.ScopeAttribute)
+	case source.BodyPath != "":
+		return r.bodyValue(source.BodyPath)
+	}
+	return "", false
+}
+
+func attributeValue(attrs pcommon.Map, key string) (string, bool) {
+	value, ok := attrs.Get(key)
+	if !ok {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+// bodyValue follows path through a map body, or a string or bytes body
+// holding JSON. JSON numbers keep their original form, so large rule IDs
+// are not turned into floats.
+func (r *record) bodyValue(path string) (string, bool) {
+	if !r.bodyParsed {
+		r.bodyParsed = true
+		var raw []byte
+		switch r.body.Type() {
+		case pcommon.ValueTypeMap, pcommon.ValueTypeSlice:
+			r.parsed = r.body.AsRaw()
+		case pcommon.ValueTypeStr:
+			raw = []byte(r.body.Str())
+		case pcommon.ValueTypeBytes:
+			raw = r.body.Bytes().AsRaw()
+		}
+		if raw != nil {
+			decoder := json.NewDecoder(bytes.NewReader(raw))
+			decoder.UseNumber()
+			_ = decoder.Decode(&r.parsed)
+		}
+	}
+
+	current := r.parsed
+	for _, segment := range splitPath(path) {
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[segment]
+			if !ok {
+				return "", false
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", false
+			}
+			current = node[index]
+		default:
+			return "", false
+		}
+	}
+
+	switch value := current.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case map[string]any, []any:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(encoded), true
+	default:
+		return fmt.Sprint(value), true
+	}
+}
+
+// splitPath splits a path such as $.results[0].id into its segments.
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+
+	var segments []string
+	for _, segment := range strings.Split(path, ".") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
@@ -0,0 +1,126 @@
+package applier
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/client"
+)
+
+// OCSF compliance status_id values.
+const (
+	ocsfComplianceStatusUnknown = 0
+	ocsfComplianceStatusPass    = 1
+	ocsfComplianceStatusFail    = 3
+	ocsfComplianceStatusOther   = 99
+)
+
+// ocsfSeverityIDs maps risk levels to OCSF severity_id values.
+var ocsfSeverityIDs = map[client.ComplianceRiskLevel]int{
+	client.Informational: 1,
+	client.Low:           2,
+	client.Medium:        3,
+	client.High:          4,
+	client.Critical:      5,
+}
+
+// applyOCSFBody fills in the compliance object and severity of an OCSF event
+// held in body, as a map or as JSON in a string or bytes value. Other bodies
+// are left untouched. Fields of the compliance object that truthbeam does not
+// know about are kept.
+func applyOCSFBody(body pcommon.Value, status string, compliance client.Compliance) {
+	event, ok := ocsfEvent(body)
+	if !ok {
+		return
+	}
+
+	object, _ := event["compliance"].(map[string]any)
+	if object == nil {
+		object = make(map[string]any)
+	}
+	object["control"] = compliance.Control.Id
+	object["category"] = compliance.Control.Category
+	object["requirements"] = stringsToAny(compliance.Frameworks.Requirements)
+	object["standards"] = stringsToAny(compliance.Frameworks.Frameworks)
+	object["status"], object["status_id"] = ocsfComplianceStatus(status)
+	event["compliance"] = object
+
+	if compliance.Risk != nil {
+		if severityID, ok := ocsfSeverityIDs[compliance.Risk.Level]; ok {
+			event["severity_id"] = severityID
+			event["severity"] = string(compliance.Risk.Level)
+		}
+	}
+
+	switch body.Type() {
+	case pcommon.ValueTypeMap:
+		_ = body.Map().FromRaw(event)
+	case pcommon.ValueTypeStr:
+		if encoded, err := json.Marshal(event); err == nil {
+			body.SetStr(string(encoded))
+		}
+	case pcommon.ValueTypeBytes:
+		if encoded, err := json.Marshal(event); err == nil {
+			body.SetEmptyBytes().FromRaw(encoded)
+		}
+	}
+}
+
+// ocsfEvent returns body as a JSON object when it looks like an OCSF event,
+// with a class_uid and a metadata object. Numbers are kept as json.Number so
+// they are written back unchanged.
+func ocsfEvent(body pcommon.Value) (map[string]any, bool) {
+	var raw []byte
+	switch body.Type() {
+	case pcommon.ValueTypeMap:
+		event := body.Map().AsRaw()
+		return event, isOCSFEvent(event)
+	case pcommon.ValueTypeStr:
+		raw = []byte(body.Str())
+	case pcommon.ValueTypeBytes:
+		raw = body.Bytes().AsRaw()
+	default:
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var event map[string]any
+	if err := decoder.Decode(&event); err != nil {
+		return nil, false
+	}
+	return event, isOCSFEvent(event)
+}
+
+func isOCSFEvent(event map[string]any) bool {
+	if _, ok := event["class_uid"]; !ok {
+		return false
+	}
+	_, ok := event["metadata"].(map[string]any)
+	return ok
+}
+
+// ocsfComplianceStatus returns the OCSF compliance status caption and
+// status_id for a compliance.status value.
+func ocsfComplianceStatus(status string) (string, int) {
+	switch status {
+	case Compliant.String():
+		return "Pass", ocsfComplianceStatusPass
+	case NotCompliant.String():
+		return "Fail", ocsfComplianceStatusFail
+	case Exempt.String(), NotApplicable.String():
+		return status, ocsfComplianceStatusOther
+	default:
+		return "Unknown", ocsfComplianceStatusUnknown
+	}
+}
+
+func stringsToAny(values []string) []any {
+	out := make([]any, 0, len(values))
+	for _, v := range values {
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package applier
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/client"
+)
+
+// DefaultRiskLevel is the SeverityMatrix key used for records whose risk
+// level is missing or not listed.
+const DefaultRiskLevel = "default"
+
+// SeverityMatrix sets the severity of log records, keyed by compliance.status
+// and then by compliance.risk.level or DefaultRiskLevel. Severities are OTel
+// severity names such as INFO, WARN2 or FATAL. Records whose status is not
+// listed keep their severity.
+type SeverityMatrix map[string]map[string]string
+
+// DefaultSeverityMatrix raises the severity of Non-Compliant records with
+// their risk level.
+func DefaultSeverityMatrix() SeverityMatrix {
+	return SeverityMatrix{
+		NotCompliant.String(): {
+			string(client.Critical):      "FATAL",
+			string(client.High):          "ERROR",
+			string(client.Medium):        "WARN",
+			string(client.Low):           "INFO",
+			string(client.Informational): "INFO",
+			DefaultRiskLevel:             "WARN",
+		},
+		Compliant.String():     {DefaultRiskLevel: "INFO"},
+		Exempt.String():        {DefaultRiskLevel: "INFO"},
+		NotApplicable.String(): {DefaultRiskLevel: "INFO"},
+		Unknown.String():       {DefaultRiskLevel: "WARN"},
+	}
+}
+
+// Validate checks that the matrix only uses compliance.status values, risk
+// levels and severity names.
+func (m SeverityMatrix) Validate() error {
+	_, err := m.parse()
+	return err
+}
+
+func (m SeverityMatrix) parse() (map[string]map[string]plog.SeverityNumber, error) {
+	parsed := make(map[string]map[string]plog.SeverityNumber, len(m))
+	for status, levels := range m {
+		if _, err := ParseStatus(status); err != nil {
+			return nil, fmt.Errorf("severity matrix: %w", err)
+		}
+		parsed[status] = make(map[string]plog.SeverityNumber, len(levels))
+		for level, severity := range levels {
+			if level != DefaultRiskLevel && !validRiskLevel(level) {
+				return nil, fmt.Errorf("severity matrix %s: unknown risk level %q", status, level)
+			}
+			number, ok := parseSeverity(severity)
+			if !ok {
+				return nil, fmt.Errorf("severity matrix %s %s: unknown severity %q", status, level, severity)
+			}
+			parsed[status][level] = number
+		}
+	}
+	return parsed, nil
+}
+
+func validRiskLevel(level string) bool {
+	switch client.ComplianceRiskLevel(level) {
+	case client.Critical, client.High, client.Medium, client.Low, client.Informational:
+		return true
+	}
+	return false
+}
+
+// parseSeverity returns the severity number with the given name, ignoring
+// case.
+func parseSeverity(name string) (plog.SeverityNumber, bool) {
+	for number := plog.SeverityNumberTrace; number <= plog.SeverityNumberFatal4; number++ {
+		if strings.EqualFold(number.String(), name) {
+			return number, true
+		}
+	}
+	return plog.SeverityNumberUnspecified, false
+}
+
+// applySeverity sets the severity of logRecord from its compliance.status and
+// compliance.risk.level, keeping the original severity in the configured
+// attribute unless it is already set.
+func (a *Applier) applySeverity(logRecord plog.LogRecord) {
+	if a.severity == nil {
+		return
+	}
+	attrs := logRecord.Attributes()
+	status, _ := attributeValue(attrs, COMPLIANCE_STATUS)
+	levels, ok := a.severity[status]
+	if !ok {
+		return
+	}
+	riskLevel, _ := attributeValue(attrs, COMPLIANCE_RISK_LEVEL)
+	number, ok := levels[riskLevel]
+	if !ok {
+		if number, ok = levels[DefaultRiskLevel]; !ok {
+			return
+		}
+	}
+
+	if a.originalSeverityAttribute != "" {
+		if _, exists := attrs.Get(a.originalSeverityAttribute); !exists {
+			original := logRecord.SeverityText()
+			if original == "" {
+				original = strings.ToUpper(logRecord.SeverityNumber().String())
+			}
+			attrs.PutStr(a.originalSeverityAttribute, original)
+		}
+	}
+	logRecord.SetSeverityNumber(number)
+	logRecord.SetSeverityText(strings.ToUpper(number.String()))
+}
@@ -1,6 +1,12 @@
 package applier
 
-import "github.com/ossf/gemara/layer4"
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ossf/gemara/layer4"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
 
 // Status defines the compliance status value
 type Status int
@@ -30,6 +36,16 @@ func (s Status) String() string {
 	return toString[s]
 }
 
+// ParseStatus returns the Status with the given compliance.status value.
+func ParseStatus(status string) (Status, error) {
+	for s, str := range toString {
+		if str == status {
+			return s, nil
+		}
+	}
+	return Unknown, fmt.Errorf("unknown compliance status %q", status)
+}
+
 func parseResult(resultStr string) layer4.Result {
 	switch resultStr {
 	case "Not Run":
@@ -40,6 +56,8 @@ func parseResult(resultStr string) layer4.Result {
 		return layer4.Passed
 	case "Failed":
 		return layer4.Failed
+	case "Needs Review":
+		return layer4.NeedsReview
 	default:
 		return layer4.Unknown
 	}
@@ -52,9 +70,69 @@ func mapResult(resultStr string) Status {
 		return Compliant
 	case layer4.Failed:
 		return NotCompliant
+	case layer4.NeedsReview:
+		// A result awaiting manual review is not counted as compliant
+		// until the review passes it.
+		return NotCompliant
 	case layer4.NotApplicable, layer4.NotRun:
 		return NotApplicable
 	default:
 		return Unknown
 	}
 }
+
+// StatusMapping overrides the compliance status derived from evaluation
+// results, keyed by policy engine name and then by evaluation result. It
+// lets engines with their own result vocabulary, or a different reading of
+// a result such as "Needs Review", map onto compliance statuses.
+type StatusMapping map[string]map[string]string
+
+// Validate checks that every mapped status is a compliance.status value.
+func (m StatusMapping) Validate() error {
+	_, err := m.parse()
+	return err
+}
+
+func (m StatusMapping) parse() (map[string]map[string]Status, error) {
+	parsed := make(map[string]map[string]Status, len(m))
+	for engine, results := range m {
+		parsed[engine] = make(map[string]Status, len(results))
+		for result, status := range results {
+			s, err := ParseStatus(status)
+			if err != nil {
+				return nil, fmt.Errorf("status_mapping %s %q: %w", engine, result, err)
+			}
+			parsed[engine][result] = s
+		}
+	}
+	return parsed, nil
+}
+
+// status derives the compliance status of a record from its evaluation
+// result. A record with an active remediation exception is Exempt.
+func (a *Applier) status(attrs pcommon.Map, policyEngineName, result string) Status {
+	if exceptionActive(attrs) {
+		return Exempt
+	}
+	if status, ok := a.statusMapping[policyEngineName][result]; ok {
+		return status
+	}
+	return mapResult(result)
+}
+
+// exceptionActive reports whether compliance.remediation.exception.active
+// is set to true, as a boolean or a string.
+func exceptionActive(attrs pcommon.Map) bool {
+	value, ok := attrs.Get(COMPLIANCE_REMEDIATION_EXCEPTION_ACTIVE)
+	if !ok {
+		return false
+	}
+	switch value.Type() {
+	case pcommon.ValueTypeBool:
+		return value.Bool()
+	case pcommon.ValueTypeStr:
+		active, err := strconv.ParseBool(value.Str())
+		return err == nil && active
+	}
+	return false
+}
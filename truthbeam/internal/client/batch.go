@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// errBatchUnsupported is returned by callEnrichBatch when Compass does not
+// offer the batch enrichment endpoint.
+var errBatchUnsupported = errors.New("batch enrichment is not supported by compass")
+
+// Result is the outcome of retrieving compliance data for one policy.
+type Result struct {
+	Compliance Compliance
+	Err        error
+}
+
+// RetrieveAll gets compliance data for every distinct policy in policies.
+// Cache misses are resolved together: through the Compass batch endpoint
+// when it is available, or with bounded parallel calls otherwise. Expired
+// entries that may be served stale are refreshed individually in the
+// background. Other expired entries with an ETag are revalidated
+// individually, since the batch endpoint has no conditional requests.
+func (c *CacheableClient) RetrieveAll(ctx context.Context, policies []Policy) map[Policy]Result {
+	results := make(map[Policy]Result, len(policies))
+	var misses, revalidate []Policy
+
+	now := c.now()
+	for _, policy := range policies {
+		if _, seen := results[policy]; seen {
+			continue
+		}
+		cached, found := c.cache.Get(c.key(policy))
+		if found && cached.Fresh(now) {
+			compliance, err := cached.result()
+			results[policy] = Result{Compliance: compliance, Err: err}
+			continue
+		}
+		if found && cached.Err == "" && c.servesStale(cached, now) {
+			c.refresh(policy, &cached)
+			results[policy] = Result{Compliance: cached.Compliance}
+			continue
+		}
+		// Reserve the key so duplicates are not counted as misses.
+		results[policy] = Result{}
+		if found && cached.Err == "" && cached.ETag != "" {
+			revalidate = append(revalidate, policy)
+			continue
+		}
+		misses = append(misses, policy)
+	}
+	if len(misses) == 0 && len(revalidate) == 0 {
+		return results
+	}
+
+	var mu sync.Mutex
+	store := func(policy Policy, result Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[policy] = result
+	}
+
+	if len(misses) > 1 && !c.batchUnsupported.Load() {
+		misses = c.retrieveBatches(ctx, misses, store)
+	}
+	c.retrieveEach(ctx, append(misses, revalidate...), store)
+
+	return results
+}
+
+// retrieveBatches resolves misses through the batch endpoint, in chunks of
+// at most batchSize. It returns the policies that must still be resolved
+// individually because Compass does not support batches.
+func (c *CacheableClient) retrieveBatches(ctx context.Context, misses []Policy, store func(Policy, Result)) []Policy {
+	var mu sync.Mutex
+	var remaining []Policy
+
+	var group errgroup.Group
+	group.SetLimit(c.maxConcurrency)
+	for start := 0; start < len(misses); start += c.batchSize {
+		chunk := misses[start:min(start+c.batchSize, len(misses))]
+		group.Go(func() error {
+			entries, err := c.callEnrichBatch(ctx, chunk)
+			if errors.Is(err, errBatchUnsupported) {
+				if !c.batchUnsupported.Swap(true) {
+					c.logger.Info("compass does not support batch enrichment; falling back to individual requests")
+				}
+				mu.Lock()
+				remaining = append(remaining, chunk...)
+				mu.Unlock()
+				return nil
+			}
+			if err != nil {
+				c.logger.Error("batch enrichment API call failed",
+					zap.Int("policies", len(chunk)),
+					zap.Error(err),
+				)
+				for _, policy := range chunk {
+					// A cancelled caller or an open breaker says nothing
+					// new about Compass.
+					if ctx.Err() == nil && !errors.Is(err, ErrCircuitOpen) {
+						c.storeError(policy, err)
+					}
+					store(policy, Result{Err: fmt.Errorf("failed to fetch metadata: %w", err)})
+				}
+				return nil
+			}
+
+			for _, policy := range chunk {
+				entry, ok := entries[policy]
+				if !ok {
+					store(policy, Result{Err: fmt.Errorf("failed to fetch metadata: %w", &ResponseError{Err: errors.New("policy missing from batch response")})})
+					continue
+				}
+				c.store(policy, entry)
+				store(policy, Result{Compliance: entry.Compliance})
+			}
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	return remaining
+}
+
+// retrieveEach resolves policies with individual calls, at most
+// maxConcurrency at a time.
+func (c *CacheableClient) retrieveEach(ctx context.Context, policies []Policy, store func(Policy, Result)) {
+	var group errgroup.Group
+	group.SetLimit(c.maxConcurrency)
+	for _, policy := range policies {
+		group.Go(func() error {
+			compliance, err := c.Retrieve(ctx, policy)
+			store(policy, Result{Compliance: compliance, Err: err})
+			return nil
+		})
+	}
+	_ = group.Wait()
+}
+
+// callEnrichBatch calls the Compass batch enrich API with retries and the
+// circuit breaker.
+func (c *CacheableClient) callEnrichBatch(ctx context.Context, policies []Policy) (map[Policy]Entry, error) {
+	var entries map[Policy]Entry
+	err := c.resilience.do(ctx, func(ctx context.Context) error {
+		var err error
+		entries, err = c.enrichBatchOnce(ctx, policies)
+		return err
+	})
+	return entries, err
+}
+
+// enrichBatchOnce makes a single call to the Compass batch enrich API.
+func (c *CacheableClient) enrichBatchOnce(ctx context.Context, policies []Policy) (map[Policy]Entry, error) {
+	c.logger.Debug("calling compass batch enrich API", zap.Int("policies", len(policies)))
+
+	resp, err := c.client.PostV1EnrichBatch(ctx, BatchEnrichmentRequest{Policies: policies})
+	if err != nil {
+		return nil, err
+	}
+
+	// Compass versions without the batch endpoint do not route it.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		_ = resp.Body.Close()
+		return nil, errBatchUnsupported
+	}
+
+	parsedResp, err := ParsePostV1EnrichBatchResponse(resp)
+	if err != nil {
+		return nil, responseError(err)
+	}
+
+	if parsedResp.JSON200 != nil {
+		etag := resp.Header.Get("ETag")
+		expires := c.expiresAt(resp.Header)
+		entries := make(map[Policy]Entry, len(parsedResp.JSON200.Results))
+		for _, result := range parsedResp.JSON200.Results {
+			entries[result.Policy] = Entry{
+				Compliance: result.Compliance,
+				ETag:       etag,
+				Expires:    expires,
+			}
+		}
+		return entries, nil
+	}
+
+	if parsedResp.JSONDefault != nil {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: parsedResp.JSONDefault.Message}
+	}
+
+	return nil, &StatusError{StatusCode: resp.StatusCode}
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func testCompliance(policy Policy) Compliance {
+	return Compliance{
+		Control: ComplianceControl{
+			Id:        policy.PolicyRuleId + "-control",
+			CatalogId: "OSPS-B",
+			Category:  "Quality",
+		},
+		Frameworks: ComplianceFrameworks{
+			Requirements: []string{},
+			Frameworks:   []string{},
+		},
+		EnrichmentStatus: Success,
+	}
+}
+
+func testPolicies(n int) []Policy {
+	var policies []Policy
+	for i := 0; i < n; i++ {
+		policies = append(policies, Policy{PolicyEngineName: "test-engine", PolicyRuleId: fmt.Sprintf("rule-%d", i)})
+	}
+	return policies
+}
+
+func TestCacheableClient_RetrieveAllBatch(t *testing.T) {
+	var mu sync.Mutex
+	var batchSizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/enrich/batch", r.URL.Path)
+
+		var req BatchEnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		batchSizes = append(batchSizes, len(req.Policies))
+		mu.Unlock()
+
+		response := BatchEnrichmentResponse{Results: []EnrichmentResult{}}
+		for _, policy := range req.Policies {
+			if policy.PolicyRuleId == "rule-4" {
+				// Leave one policy out of the response.
+				continue
+			}
+			response.Results = append(response.Results, EnrichmentResult{Policy: policy, Compliance: testCompliance(policy)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0, WithBatchSize(2))
+	require.NoError(t, err)
+
+	policies := testPolicies(5)
+	// Duplicates are resolved once.
+	policies = append(policies, policies[0], policies[1])
+
+	results := cacheableClient.RetrieveAll(context.Background(), policies)
+	require.Len(t, results, 5)
+	for _, policy := range policies {
+		result := results[policy]
+		if policy.PolicyRuleId == "rule-4" {
+			assert.Error(t, result.Err)
+			continue
+		}
+		require.NoError(t, result.Err)
+		assert.Equal(t, policy.PolicyRuleId+"-control", result.Compliance.Control.Id)
+	}
+	assert.ElementsMatch(t, []int{2, 2, 1}, batchSizes)
+
+	// Cached results are not requested again.
+	results = cacheableClient.RetrieveAll(context.Background(), policies[:4])
+	assert.Len(t, results, 4)
+	assert.Len(t, batchSizes, 3)
+}
+
+func TestCacheableClient_RetrieveAllFallback(t *testing.T) {
+	var mu sync.Mutex
+	batchCalls, enrichCalls := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/v1/enrich" {
+			batchCalls++
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		enrichCalls++
+
+		var req EnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(EnrichmentResponse{Compliance: testCompliance(req.Policy)})
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0, WithMaxConcurrency(2))
+	require.NoError(t, err)
+
+	results := cacheableClient.RetrieveAll(context.Background(), testPolicies(3))
+	require.Len(t, results, 3)
+	for policy, result := range results {
+		require.NoError(t, result.Err)
+		assert.Equal(t, policy.PolicyRuleId+"-control", result.Compliance.Control.Id)
+	}
+	assert.Equal(t, 1, batchCalls)
+	assert.Equal(t, 3, enrichCalls)
+	assert.True(t, cacheableClient.batchUnsupported.Load())
+
+	// The batch endpoint is not tried again.
+	_ = cacheableClient.RetrieveAll(context.Background(), testPolicies(6)[3:])
+	assert.Equal(t, 1, batchCalls)
+	assert.Equal(t, 6, enrichCalls)
+}
+
+func TestCacheableClient_RetrieveAllError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Code: 500, Message: "Internal server error"})
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	results := cacheableClient.RetrieveAll(context.Background(), testPolicies(2))
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.ErrorContains(t, result.Err, "Internal server error")
+	}
+	assert.False(t, cacheableClient.batchUnsupported.Load())
+}
+
+func TestCacheableClient_RetrieveAllRevalidates(t *testing.T) {
+	var mu sync.Mutex
+	var batches, conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=300")
+		if r.URL.Path == "/v1/enrich" && r.Header.Get("If-None-Match") == `"v1"` {
+			mu.Lock()
+			conditional++
+			mu.Unlock()
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		require.Equal(t, "/v1/enrich/batch", r.URL.Path)
+		mu.Lock()
+		batches++
+		mu.Unlock()
+
+		var req BatchEnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		response := BatchEnrichmentResponse{Results: []EnrichmentResult{}}
+		for _, policy := range req.Policies {
+			response.Results = append(response.Results, EnrichmentResult{Policy: policy, Compliance: testCompliance(policy)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+	now := time.Now()
+	cacheableClient.now = func() time.Time { return now }
+
+	policies := testPolicies(3)
+	for _, result := range cacheableClient.RetrieveAll(context.Background(), policies) {
+		require.NoError(t, result.Err)
+	}
+
+	// Expired entries are revalidated with conditional requests instead of
+	// being downloaded again through the batch endpoint.
+	now = now.Add(10 * time.Minute)
+	results := cacheableClient.RetrieveAll(context.Background(), policies)
+	for _, policy := range policies {
+		require.NoError(t, results[policy].Err)
+		assert.Equal(t, testCompliance(policy), results[policy].Compliance)
+	}
+	assert.Equal(t, 1, batches)
+	assert.Equal(t, 3, conditional)
+}
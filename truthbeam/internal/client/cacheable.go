@@ -2,20 +2,52 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 )
 
+// Entry is a cached enrichment result along with the metadata needed to
+// revalidate it with Compass.
+type Entry struct {
+	Compliance Compliance
+	// ETag is the Compass content version the entry was produced from.
+	ETag string
+	// Expires is when the entry must be revalidated. A zero value means the
+	// entry is fresh until the cache evicts it.
+	Expires time.Time
+	// Err is set when the entry records a failed Compass call rather than
+	// compliance data.
+	Err string
+	// Transient is set with Err when the failed call may succeed later.
+	Transient bool
+	// TTL is how long the cache should keep the entry. A zero value uses
+	// the cache default.
+	TTL time.Duration
+}
+
+// Fresh reports whether the entry can be used without revalidation.
+func (e Entry) Fresh(now time.Time) bool {
+	return e.Expires.IsZero() || now.Before(e.Expires)
+}
+
 // Cache defines a simple cache interface for storing Compliance data.
 type Cache interface {
 	// Get retrieves a value from the cache by key.
-	Get(key string) (Compliance, bool)
+	Get(key string) (Entry, bool)
 	// Set stores a value in the cache with the given key.
-	Set(key string, value Compliance) error
+	Set(key string, value Entry) error
 	// Delete removes a value from the cache by key.
 	Delete(key string) error
+	// Clear removes every value from the cache.
+	Clear() error
 }
 
 // CacheableClient wraps the basic client with that leverages a caching mechanism.
@@ -23,11 +55,132 @@ type CacheableClient struct {
 	client *Client
 	cache  Cache
 	logger *zap.Logger
+	now    func() time.Time
+
+	batchSize      int
+	maxConcurrency int
+	ttl            time.Duration
+	unmappedTTL    time.Duration
+	errorTTL       time.Duration
+	maxStaleness   time.Duration
+	resilience     resilience
+	// partition prefixes every cache key, so clients sharing a backing
+	// cache never see each other's entries.
+	partition string
+	// backing lists the caches layered under cache, in order.
+	backing []Cache
+	// batchUnsupported is set once Compass is found to lack the batch
+	// enrichment endpoint.
+	batchUnsupported atomic.Bool
+
+	// inflight coalesces concurrent Compass calls for the same key, and
+	// collapsed counts the callers that shared another caller's call.
+	inflight  singleflight.Group
+	collapsed atomic.Int64
+}
+
+// Option configures optional CacheableClient behavior.
+type Option func(*CacheableClient)
+
+// WithBatchSize sets the maximum number of policies sent in one batch
+// enrichment request. A zero value keeps DefaultBatchSize.
+func WithBatchSize(size int) Option {
+	return func(c *CacheableClient) {
+		if size > 0 {
+			c.batchSize = size
+		}
+	}
+}
+
+// WithMaxConcurrency sets the maximum number of concurrent Compass calls
+// made by RetrieveAll. A zero value keeps DefaultMaxConcurrency.
+func WithMaxConcurrency(n int) Option {
+	return func(c *CacheableClient) {
+		if n > 0 {
+			c.maxConcurrency = n
+		}
+	}
+}
+
+// WithUnmappedTTL sets how long Unmapped and Partial results are cached,
+// so mappings that are still being authored are picked up sooner. A zero
+// value keeps DefaultUnmappedCacheTTL.
+func WithUnmappedTTL(ttl time.Duration) Option {
+	return func(c *CacheableClient) {
+		if ttl > 0 {
+			c.unmappedTTL = ttl
+		}
+	}
+}
+
+// WithErrorTTL sets how long failed Compass calls are cached, so a failing
+// Compass is not called for every record. A zero value keeps
+// DefaultErrorCacheTTL.
+func WithErrorTTL(ttl time.Duration) Option {
+	return func(c *CacheableClient) {
+		if ttl > 0 {
+			c.errorTTL = ttl
+		}
+	}
+}
+
+// WithRetry retries failed Compass calls that are worth retrying, with
+// jittered exponential backoff.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *CacheableClient) {
+		c.resilience.retry = policy
+	}
+}
+
+// WithCircuitBreaker stops calling Compass for openDuration after
+// threshold consecutive failed calls. Calls fail with ErrCircuitOpen
+// while the breaker is open.
+func WithCircuitBreaker(threshold int, openDuration time.Duration) Option {
+	return func(c *CacheableClient) {
+		if threshold > 0 {
+			c.resilience.breaker = newBreaker(threshold, openDuration, func() time.Time { return c.now() })
+		}
+	}
+}
+
+// WithStaleWhileRevalidate serves expired entries for up to maxStaleness
+// while a background call refreshes them. A failed refresh keeps the
+// expired entry, so enrichment continues through Compass outages. A zero
+// value disables the mode.
+func WithStaleWhileRevalidate(maxStaleness time.Duration) Option {
+	return func(c *CacheableClient) {
+		if maxStaleness > 0 {
+			c.maxStaleness = maxStaleness
+		}
+	}
+}
+
+// WithBackingCache layers the cache over l2, such as a store created by
+// NewStorageStore, so entries survive a restart, or by NewRedisStore, so
+// they are shared across a fleet. It may be given more than once; later
+// caches are consulted last.
+func WithBackingCache(l2 Cache) Option {
+	return func(c *CacheableClient) {
+		if l2 != nil {
+			c.backing = append(c.backing, l2)
+		}
+	}
+}
+
+// WithPartition prefixes every cache key with partition, so clients of
+// different Compass backends can share backing caches without sharing
+// entries. Backing caches that implement Partitioner are used through
+// their partition view, so a reset of one client leaves the entries of
+// the others alone.
+func WithPartition(partition string) Option {
+	return func(c *CacheableClient) {
+		c.partition = partition
+	}
 }
 
 // NewCacheableClient creates a new enriched client with caching capabilities.
 // To use a different cache backend, use NewCacheableClientWithCache instead.
-func NewCacheableClient(client *Client, logger *zap.Logger, ttl time.Duration, maxEntries int) (*CacheableClient, error) {
+func NewCacheableClient(client *Client, logger *zap.Logger, ttl time.Duration, maxEntries int, opts ...Option) (*CacheableClient, error) {
 	// Use default cache TTL if not specified
 	if ttl == 0 {
 		ttl = DefaultCacheTTL
@@ -42,16 +195,35 @@ func NewCacheableClient(client *Client, logger *zap.Logger, ttl time.Duration, m
 		return nil, fmt.Errorf("failed to create cache: %w", err)
 	}
 
-	return NewCacheableClientWithCache(client, logger, cache), nil
+	c := NewCacheableClientWithCache(client, logger, cache, opts...)
+	c.ttl = ttl
+	return c, nil
 }
 
 // NewCacheableClientWithCache creates a new cacheable client with a custom cache implementation.
-func NewCacheableClientWithCache(client *Client, logger *zap.Logger, cache Cache) *CacheableClient {
-	return &CacheableClient{
-		client: client,
-		cache:  cache,
-		logger: logger,
+func NewCacheableClientWithCache(client *Client, logger *zap.Logger, cache Cache, opts ...Option) *CacheableClient {
+	c := &CacheableClient{
+		client:         client,
+		cache:          cache,
+		logger:         logger,
+		now:            time.Now,
+		batchSize:      DefaultBatchSize,
+		maxConcurrency: DefaultMaxConcurrency,
+		ttl:            DefaultCacheTTL,
+		unmappedTTL:    DefaultUnmappedCacheTTL,
+		errorTTL:       DefaultErrorCacheTTL,
+	}
+	c.resilience.logger = logger
+	for _, opt := range opts {
+		opt(c)
+	}
+	for _, l2 := range c.backing {
+		if partitioner, ok := l2.(Partitioner); ok && c.partition != "" {
+			l2 = partitioner.Partition(c.partition)
+		}
+		c.cache = NewTieredCache(c.cache, l2)
 	}
+	return c
 }
 
 // cacheKey generates a composite cache key from policy engine name and policy rule id.
@@ -59,66 +231,270 @@ func cacheKey(policyEngineName, policyRuleId string) string {
 	return policyEngineName + CacheKeySeparator + policyRuleId
 }
 
+// key returns the cache key of policy in the client partition.
+func (c *CacheableClient) key(policy Policy) string {
+	key := cacheKey(policy.PolicyEngineName, policy.PolicyRuleId)
+	if c.partition != "" {
+		return c.partition + CacheKeySeparator + key
+	}
+	return key
+}
+
 // Retrieve gets compliance data for using policy data lookup values.
-// Cached metadata is used by default.
+// Cached metadata is used by default. Entries past the max-age sent by
+// Compass are revalidated with their ETag before being reused, or in the
+// background when stale-while-revalidate is enabled.
+//
+// Concurrent misses for the same policy share a single Compass call. Each
+// caller stops waiting when its own ctx is done; the shared call carries
+// on so its result is still cached.
 func (c *CacheableClient) Retrieve(ctx context.Context, policy Policy) (Compliance, error) {
-	key := cacheKey(policy.PolicyEngineName, policy.PolicyRuleId)
+	key := c.key(policy)
 
 	// Cache implementation is already concurrent, so we can check directly
-	compliance, found := c.cache.Get(key)
-	if found {
-		return compliance, nil
+	cached, found := c.cache.Get(key)
+	now := c.now()
+	if found && cached.Fresh(now) {
+		return cached.result()
 	}
 
-	// Fetch metadata from API on cache miss
-	req := EnrichmentRequest{Policy: policy}
-	resp, err := c.callEnrich(ctx, req)
+	var stale *Entry
+	if found && cached.Err == "" {
+		stale = &cached
+	}
+	if stale != nil && c.servesStale(*stale, now) {
+		c.refresh(policy, stale)
+		return stale.Compliance, nil
+	}
+
+	// Fetch metadata from API on cache miss or expiry. The shared call is
+	// detached from ctx so one caller giving up does not fail the others;
+	// it is still bounded by the HTTP client timeout.
+	var leader bool
+	results := c.inflight.DoChan(key, func() (any, error) {
+		leader = true
+		return c.fetch(context.WithoutCancel(ctx), policy, stale)
+	})
+
+	select {
+	case <-ctx.Done():
+		return Compliance{}, fmt.Errorf("failed to fetch metadata: %w", ctx.Err())
+	case result := <-results:
+		// leader is only set by the function run for this caller.
+		if !leader {
+			c.collapsed.Add(1)
+		}
+		if result.Err != nil {
+			return Compliance{}, fmt.Errorf("failed to fetch metadata: %w", result.Err)
+		}
+		return result.Val.(Entry).Compliance, nil
+	}
+}
+
+// fetch calls Compass for policy and caches the outcome. stale is the
+// expired entry being replaced, if any.
+func (c *CacheableClient) fetch(ctx context.Context, policy Policy, stale *Entry) (Entry, error) {
+	entry, err := c.callEnrich(ctx, EnrichmentRequest{Policy: policy}, stale)
 	if err != nil {
 		c.logger.Error("enrichment API call failed",
 			zap.String("policy_rule_id", policy.PolicyRuleId),
 			zap.String("policy_engine_name", policy.PolicyEngineName),
 			zap.Error(err),
 		)
-		return Compliance{}, fmt.Errorf("failed to fetch metadata: %w", err)
+		// While Compass is unavailable, an expired entry is served as if
+		// it had never expired, and stored again to be revalidated after
+		// the error TTL rather than on every use. With
+		// stale-while-revalidate, maxStaleness bounds this instead.
+		if stale != nil && c.maxStaleness == 0 && (retryable(err) || errors.Is(err, ErrCircuitOpen)) {
+			entry := *stale
+			entry.Expires = c.now().Add(c.errorTTL)
+			c.store(policy, entry)
+			return entry, nil
+		}
+		// Calls rejected by the breaker never reached Compass, and a
+		// stale entry that can still be served is better than the error.
+		if !errors.Is(err, ErrCircuitOpen) && (stale == nil || c.maxStaleness == 0) {
+			c.storeError(policy, err)
+		}
+		return Entry{}, err
+	}
+	c.store(policy, entry)
+	return entry, nil
+}
+
+// servesStale reports whether an expired entry may still be served while
+// it is refreshed in the background.
+func (c *CacheableClient) servesStale(entry Entry, now time.Time) bool {
+	return c.maxStaleness > 0 && !entry.Expires.IsZero() && now.Before(entry.Expires.Add(c.maxStaleness))
+}
+
+// refresh fetches policy in the background, unless a call for it is
+// already in flight.
+func (c *CacheableClient) refresh(policy Policy, stale *Entry) {
+	key := c.key(policy)
+	c.inflight.DoChan(key, func() (any, error) {
+		return c.fetch(context.Background(), policy, stale)
+	})
+}
+
+// CollapsedCalls returns how many Retrieve calls were answered by a
+// Compass call already in flight for the same policy.
+func (c *CacheableClient) CollapsedCalls() int64 {
+	return c.collapsed.Load()
+}
+
+// result returns the compliance data or the cached failure of an entry.
+func (e Entry) result() (Compliance, error) {
+	if e.Err != "" {
+		return Compliance{}, &cachedError{message: e.Err, transient: e.Transient}
+	}
+	return e.Compliance, nil
+}
+
+// storeError caches a failed Compass call for the error TTL.
+func (c *CacheableClient) storeError(policy Policy, err error) {
+	c.store(policy, Entry{Err: err.Error(), Transient: Transient(err)})
+}
+
+// cachedError is a failed Compass call answered from the cache.
+type cachedError struct {
+	message   string
+	transient bool
+}
+
+func (e *cachedError) Error() string {
+	return "failed to fetch metadata (cached): " + e.message
+}
+
+// store caches entry for policy, with a TTL chosen by its outcome. With
+// stale-while-revalidate, entries expire at the end of that TTL but are
+// kept for up to maxStaleness longer. Errors are logged but don't fail the
+// request.
+func (c *CacheableClient) store(policy Policy, entry Entry) {
+	switch {
+	case entry.Err != "":
+		entry.TTL = c.errorTTL
+	case entry.Compliance.EnrichmentStatus == Unmapped || entry.Compliance.EnrichmentStatus == Partial:
+		entry.TTL = c.unmappedTTL
+	}
+
+	if c.maxStaleness > 0 && entry.Err == "" {
+		ttl := entry.TTL
+		if ttl == 0 {
+			ttl = c.ttl
+		}
+		if expires := c.now().Add(ttl); entry.Expires.IsZero() || expires.Before(entry.Expires) {
+			entry.Expires = expires
+		}
+		entry.TTL = ttl + c.maxStaleness
 	}
-	compliance = resp.Compliance
 
-	// Store in cache (errors are logged but don't fail the request)
-	if setErr := c.cache.Set(key, compliance); setErr != nil {
+	if err := c.cache.Set(c.key(policy), entry); err != nil {
 		c.logger.Warn("failed to set cache value",
 			zap.String("policy_rule_id", policy.PolicyRuleId),
 			zap.String("policy_engine_name", policy.PolicyEngineName),
-			zap.Error(setErr),
+			zap.Error(err),
 		)
 	}
+}
 
-	return compliance, nil
+// callEnrich calls the Compass enrich API with retries and the circuit
+// breaker. When stale is set, the request is conditional on its ETag and a
+// 304 response reuses its compliance data.
+func (c *CacheableClient) callEnrich(ctx context.Context, req EnrichmentRequest, stale *Entry) (Entry, error) {
+	var entry Entry
+	err := c.resilience.do(ctx, func(ctx context.Context) error {
+		var err error
+		entry, err = c.enrichOnce(ctx, req, stale)
+		return err
+	})
+	return entry, err
 }
 
-func (c *CacheableClient) callEnrich(ctx context.Context, req EnrichmentRequest) (*EnrichmentResponse, error) {
+// enrichOnce makes a single call to the Compass enrich API.
+func (c *CacheableClient) enrichOnce(ctx context.Context, req EnrichmentRequest, stale *Entry) (Entry, error) {
 	c.logger.Debug("calling compass enrich API",
 		zap.String("policy_rule_id", req.Policy.PolicyRuleId),
 		zap.String("policy_engine_name", req.Policy.PolicyEngineName),
+		zap.Bool("revalidate", stale != nil && stale.ETag != ""),
 	)
 
-	resp, err := c.client.PostV1Enrich(ctx, req)
+	params := &PostV1EnrichParams{}
+	if stale != nil && stale.ETag != "" {
+		params.IfNoneMatch = &stale.ETag
+	}
+
+	resp, err := c.client.PostV1Enrich(ctx, params, req)
 	if err != nil {
-		return nil, err
+		return Entry{}, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && stale != nil {
+		entry := *stale
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			entry.ETag = etag
+		}
+		entry.Expires = c.expiresAt(resp.Header)
+		return entry, nil
+	}
+
 	parsedResp, err := ParsePostV1EnrichResponse(resp)
 	if err != nil {
-		return nil, err
+		return Entry{}, responseError(err)
 	}
 
 	if parsedResp.JSON200 != nil {
-		return parsedResp.JSON200, nil
+		return Entry{
+			Compliance: parsedResp.JSON200.Compliance,
+			ETag:       resp.Header.Get("ETag"),
+			Expires:    c.expiresAt(resp.Header),
+		}, nil
 	}
 
 	if parsedResp.JSONDefault != nil {
-		return nil, fmt.Errorf("API call failed with status %d: %s", parsedResp.JSONDefault.Code, parsedResp.JSONDefault.Message)
+		return Entry{}, &StatusError{StatusCode: resp.StatusCode, Message: parsedResp.JSONDefault.Message}
 	}
 
-	return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	return Entry{}, &StatusError{StatusCode: resp.StatusCode}
+}
+
+// BreakerState returns the state of the Compass circuit breaker. It is
+// always BreakerClosed when no breaker is configured.
+func (c *CacheableClient) BreakerState() BreakerState {
+	if c.resilience.breaker == nil {
+		return BreakerClosed
+	}
+	return c.resilience.breaker.State()
+}
+
+// Retries returns how many Compass calls were retried.
+func (c *CacheableClient) Retries() int64 {
+	return c.resilience.retries.Load()
+}
+
+// expiresAt returns when a response with the given headers must be
+// revalidated, or the zero time when Compass sent no max-age.
+func (c *CacheableClient) expiresAt(header http.Header) time.Time {
+	maxAge, ok := parseMaxAge(header.Get("Cache-Control"))
+	if !ok {
+		return time.Time{}
+	}
+	return c.now().Add(maxAge)
+}
+
+// parseMaxAge extracts the max-age directive from a Cache-Control header.
+func parseMaxAge(cacheControl string) (time.Duration, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+		if err != nil || seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
 }
@@ -2,9 +2,13 @@ package client
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -354,6 +358,148 @@ func TestCacheableClient_Retrieve(t *testing.T) {
 	}
 }
 
+func TestCacheableClient_Revalidate(t *testing.T) {
+	var requests []string
+	etag := `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"compliance": {"control": {"id": "OSPS-QA-01.01", "catalogId": "OSPS-B", "category": "Quality"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Success"}}`))
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	now := time.Now()
+	cacheableClient.now = func() time.Time { return now }
+	policy := Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"}
+
+	// Initial fetch stores the ETag and max-age.
+	compliance, err := cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, "OSPS-QA-01.01", compliance.Control.Id)
+	assert.Equal(t, []string{""}, requests)
+
+	entry, found := cacheableClient.cache.Get(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId))
+	require.True(t, found)
+	assert.Equal(t, `"v1"`, entry.ETag)
+	assert.Equal(t, now.Add(time.Minute), entry.Expires)
+
+	// Within max-age the cached entry is used without a request.
+	now = now.Add(30 * time.Second)
+	_, err = cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+
+	// After max-age the entry is revalidated and a 304 reuses it.
+	now = now.Add(time.Minute)
+	compliance, err = cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, "OSPS-QA-01.01", compliance.Control.Id)
+	assert.Equal(t, []string{"", `"v1"`}, requests)
+
+	entry, found = cacheableClient.cache.Get(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId))
+	require.True(t, found)
+	assert.Equal(t, now.Add(time.Minute), entry.Expires)
+
+	// When the content version changes the full response is fetched again.
+	etag = `"v2"`
+	now = now.Add(2 * time.Minute)
+	_, err = cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", `"v1"`, `"v1"`}, requests)
+
+	entry, found = cacheableClient.cache.Get(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId))
+	require.True(t, found)
+	assert.Equal(t, `"v2"`, entry.ETag)
+}
+
+func TestCacheableClient_RevalidateWhileUnavailable(t *testing.T) {
+	var down atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=300")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"compliance": {"control": {"id": "OSPS-QA-01.01", "catalogId": "OSPS-B", "category": "Quality"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Success"}}`))
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	now := time.Now()
+	cacheableClient.now = func() time.Time { return now }
+	policy := Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"}
+
+	_, err = cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+
+	// Past max-age with Compass down, the revalidation fails but the
+	// cached entry is still served and not replaced by the error. It is
+	// only revalidated again after the error TTL.
+	down.Store(true)
+	now = now.Add(10 * time.Minute)
+	for i := 0; i < 2; i++ {
+		compliance, err := cacheableClient.Retrieve(context.Background(), policy)
+		require.NoError(t, err)
+		assert.Equal(t, "OSPS-QA-01.01", compliance.Control.Id)
+	}
+	assert.Equal(t, int32(2), requests.Load())
+
+	now = now.Add(DefaultErrorCacheTTL)
+	compliance, err := cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, "OSPS-QA-01.01", compliance.Control.Id)
+	assert.Equal(t, int32(3), requests.Load())
+
+	entry, found := cacheableClient.cache.Get(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId))
+	require.True(t, found)
+	assert.Empty(t, entry.Err)
+	assert.Equal(t, `"v1"`, entry.ETag)
+}
+
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected time.Duration
+		ok       bool
+	}{
+		{header: "max-age=300", expected: 5 * time.Minute, ok: true},
+		{header: "public, Max-Age=60", expected: time.Minute, ok: true},
+		{header: "max-age=0", expected: 0, ok: true},
+		{header: "no-cache", ok: false},
+		{header: "max-age=-1", ok: false},
+		{header: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			maxAge, ok := parseMaxAge(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, maxAge)
+		})
+	}
+}
+
 func workingServer() (*httptest.Server, *int) {
 	apiCallCount := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -378,3 +524,199 @@ func workingServer() (*httptest.Server, *int) {
 	}))
 	return server, &apiCallCount
 }
+
+func TestCacheableClient_RetrieveCollapsesConcurrentMisses(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"compliance": {"control": {"id": "OSPS-QA-01.01", "catalogId": "OSPS-B", "category": "Quality"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Success"}}`))
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	policy := Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"}
+	const callers = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			compliance, err := cacheableClient.Retrieve(context.Background(), policy)
+			if err == nil && compliance.Control.Id != "OSPS-QA-01.01" {
+				err = fmt.Errorf("unexpected control %q", compliance.Control.Id)
+			}
+			errs <- err
+		}()
+	}
+
+	// Let every caller join the in-flight call before it completes.
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int64(callers-1), cacheableClient.CollapsedCalls())
+}
+
+func TestCacheableClient_RetrieveWaiterCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"compliance": {"control": {"id": "OSPS-QA-01.01", "catalogId": "OSPS-B", "category": "Quality"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Success"}}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	policy := Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = cacheableClient.Retrieve(ctx, policy)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The shared call carries on and caches its result.
+	release <- struct{}{}
+	require.Eventually(t, func() bool {
+		_, found := cacheableClient.cache.Get(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId))
+		return found
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCacheableClient_OutcomeTTLs(t *testing.T) {
+	var calls atomic.Int32
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"code": 500, "message": "Internal server error"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"compliance": {"control": {"id": "UNMAPPED", "catalogId": "UNMAPPED", "category": "UNCATEGORIZED"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Unmapped"}}`))
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), time.Hour, 0,
+		WithUnmappedTTL(100*time.Millisecond),
+		WithErrorTTL(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+	policy := Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"}
+
+	// Errors are cached for the error TTL.
+	_, err = cacheableClient.Retrieve(context.Background(), policy)
+	require.Error(t, err)
+	_, err = cacheableClient.Retrieve(context.Background(), policy)
+	assert.ErrorContains(t, err, "cached")
+	assert.Equal(t, int32(1), calls.Load())
+
+	entry, found := cacheableClient.cache.Get(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId))
+	require.True(t, found)
+	assert.Equal(t, 50*time.Millisecond, entry.TTL)
+
+	// Once the error entry expires, Compass is called again.
+	status = http.StatusOK
+	require.Eventually(t, func() bool {
+		_, err := cacheableClient.Retrieve(context.Background(), policy)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Unmapped results use the unmapped TTL.
+	entry, found = cacheableClient.cache.Get(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId))
+	require.True(t, found)
+	assert.Equal(t, 100*time.Millisecond, entry.TTL)
+	require.Eventually(t, func() bool {
+		_, found := cacheableClient.cache.Get(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId))
+		return !found
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCacheableClient_StaleWhileRevalidate(t *testing.T) {
+	var calls atomic.Int32
+	var status atomic.Int32
+	var controlID atomic.Value
+	status.Store(http.StatusOK)
+	controlID.Store("AC-1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if code := int(status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(`{"code": 500, "message": "Internal server error"}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"compliance": {"control": {"id": %q, "catalogId": "NIST-800-53", "category": "Access Control"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Success"}}`, controlID.Load())
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), time.Hour, 0, WithStaleWhileRevalidate(30*time.Minute))
+	require.NoError(t, err)
+	var now atomic.Int64
+	now.Store(time.Now().UnixNano())
+	cacheableClient.now = func() time.Time { return time.Unix(0, now.Load()) }
+	policy := Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"}
+	key := cacheKey(policy.PolicyEngineName, policy.PolicyRuleId)
+
+	compliance, err := cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, "AC-1", compliance.Control.Id)
+	entry, found := cacheableClient.cache.Get(key)
+	require.True(t, found)
+	assert.Equal(t, 90*time.Minute, entry.TTL)
+
+	// An expired entry is served while Compass is failing, and the failed
+	// refresh does not replace it.
+	now.Add(int64(time.Hour + time.Minute))
+	status.Store(http.StatusInternalServerError)
+	compliance, err = cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, "AC-1", compliance.Control.Id)
+	require.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, 5*time.Millisecond)
+	entry, found = cacheableClient.cache.Get(key)
+	require.True(t, found)
+	assert.Empty(t, entry.Err)
+
+	// Once Compass recovers, the background refresh replaces the entry.
+	status.Store(http.StatusOK)
+	controlID.Store("AC-2")
+	require.Eventually(t, func() bool {
+		compliance, err := cacheableClient.Retrieve(context.Background(), policy)
+		return err == nil && compliance.Control.Id == "AC-2"
+	}, time.Second, 5*time.Millisecond)
+
+	// Past the staleness bound, the caller waits for Compass.
+	now.Add(int64(2 * time.Hour))
+	status.Store(http.StatusInternalServerError)
+	_, err = cacheableClient.Retrieve(context.Background(), policy)
+	assert.Error(t, err)
+}
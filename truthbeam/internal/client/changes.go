@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WatchChanges follows the Compass change feed until ctx is done, deleting
+// cache entries for the policy rules Compass reports as changed. When
+// Compass asks for a reset, for example when the watcher fell too far
+// behind or the epoch it passed is not known after a restart, the whole
+// cache is cleared. A new epoch alone is not a reset: it changes with the
+// content Compass serves, and replicas serving the same content share it.
+//
+// Each request long polls for up to wait. Failed requests are retried
+// after retryInterval.
+func (c *CacheableClient) WatchChanges(ctx context.Context, wait, retryInterval time.Duration) {
+	if wait <= 0 {
+		wait = DefaultChangeFeedWait
+	}
+	if retryInterval <= 0 {
+		retryInterval = DefaultChangeFeedRetryInterval
+	}
+
+	var revision *int64
+	var epoch *string
+	for ctx.Err() == nil {
+		feed, err := c.pollChanges(ctx, revision, epoch, wait)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Warn("change feed request failed",
+				zap.Duration("retry_interval", retryInterval),
+				zap.Error(err),
+			)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+
+		if revision != nil {
+			c.applyChanges(feed)
+		}
+		revision, epoch = &feed.Revision, &feed.Epoch
+	}
+}
+
+// applyChanges invalidates the cache entries listed in a change feed.
+func (c *CacheableClient) applyChanges(feed ChangeFeed) {
+	if feed.Reset {
+		c.logger.Info("change feed reset; clearing enrichment cache",
+			zap.String("epoch", feed.Epoch),
+			zap.Int64("revision", feed.Revision),
+		)
+		if err := c.cache.Clear(); err != nil {
+			c.logger.Warn("failed to clear cache", zap.Error(err))
+		}
+		return
+	}
+
+	if len(feed.Changes) == 0 {
+		return
+	}
+	c.logger.Debug("invalidating changed enrichments",
+		zap.Int64("revision", feed.Revision),
+		zap.Int("changes", len(feed.Changes)),
+	)
+	for _, policy := range feed.Changes {
+		if err := c.cache.Delete(c.key(policy)); err != nil {
+			c.logger.Warn("failed to delete cache value",
+				zap.String("policy_rule_id", policy.PolicyRuleId),
+				zap.String("policy_engine_name", policy.PolicyEngineName),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// pollChanges requests the changes after revision of epoch from Compass.
+func (c *CacheableClient) pollChanges(ctx context.Context, revision *int64, epoch *string, wait time.Duration) (ChangeFeed, error) {
+	params := &GetV1ChangesParams{Since: revision, Epoch: epoch}
+	if revision != nil {
+		seconds := int32(wait / time.Second)
+		params.Wait = &seconds
+	}
+
+	resp, err := c.client.GetV1Changes(ctx, params)
+	if err != nil {
+		return ChangeFeed{}, err
+	}
+
+	parsedResp, err := ParseGetV1ChangesResponse(resp)
+	if err != nil {
+		return ChangeFeed{}, err
+	}
+
+	if parsedResp.JSON200 != nil {
+		return *parsedResp.JSON200, nil
+	}
+
+	if parsedResp.JSONDefault != nil {
+		return ChangeFeed{}, fmt.Errorf("API call failed with status %d: %s", parsedResp.JSONDefault.Code, parsedResp.JSONDefault.Message)
+	}
+
+	return ChangeFeed{}, fmt.Errorf("unexpected response status: %s", resp.Status)
+}
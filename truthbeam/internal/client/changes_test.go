@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCacheableClient_WatchChanges(t *testing.T) {
+	// Each request receives the next scripted feed; the last one is
+	// repeated until the watcher is stopped.
+	feeds := []ChangeFeed{
+		{Epoch: "a", Revision: 1, Changes: []Policy{}},
+		{Epoch: "a", Revision: 2, Changes: []Policy{{PolicyEngineName: "test-engine", PolicyRuleId: "changed"}}},
+		{Epoch: "a", Revision: 3, Changes: []Policy{}, Reset: true},
+		{Epoch: "a", Revision: 3, Changes: []Policy{}},
+	}
+
+	var mu sync.Mutex
+	var since, epochs []string
+	served := make(chan struct{}, len(feeds))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		since = append(since, r.URL.Query().Get("since"))
+		epochs = append(epochs, r.URL.Query().Get("epoch"))
+		feed := feeds[min(len(since), len(feeds))-1]
+		mu.Unlock()
+
+		if len(served) < cap(served) {
+			served <- struct{}{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(feed)
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	changed := cacheKey("test-engine", "changed")
+	unchanged := cacheKey("test-engine", "unchanged")
+	require.NoError(t, cacheableClient.cache.Set(changed, Entry{}))
+	require.NoError(t, cacheableClient.cache.Set(unchanged, Entry{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cacheableClient.WatchChanges(ctx, time.Second, time.Second)
+	}()
+
+	// Wait for the initial request and the changed-key response.
+	<-served
+	<-served
+	require.Eventually(t, func() bool {
+		_, found := cacheableClient.cache.Get(changed)
+		return !found
+	}, time.Second, 10*time.Millisecond)
+
+	// The reset clears the rest of the cache.
+	<-served
+	<-served
+	require.Eventually(t, func() bool {
+		_, found := cacheableClient.cache.Get(unchanged)
+		return !found
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not stop after cancel")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"", "1", "2", "3"}, since[:4])
+	assert.Equal(t, []string{"", "a", "a", "a"}, epochs[:4])
+}
+
+func TestCacheableClient_WatchChangesEpoch(t *testing.T) {
+	// Replicas serving the same content answer with the same epoch but
+	// their own revisions, and a reload moves to a new epoch. Only an
+	// explicit reset clears the cache.
+	feeds := []ChangeFeed{
+		{Epoch: "v1", Revision: 5, Changes: []Policy{}},
+		{Epoch: "v1", Revision: 1, Changes: []Policy{}},
+		{Epoch: "v2", Revision: 6, Changes: []Policy{{PolicyEngineName: "test-engine", PolicyRuleId: "changed"}}},
+		{Epoch: "v2", Revision: 2, Changes: []Policy{}},
+	}
+
+	var mu sync.Mutex
+	var epochs []string
+	served := make(chan struct{}, len(feeds))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		epochs = append(epochs, r.URL.Query().Get("epoch"))
+		feed := feeds[min(len(epochs), len(feeds))-1]
+		mu.Unlock()
+
+		if len(served) < cap(served) {
+			served <- struct{}{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(feed)
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	changed := cacheKey("test-engine", "changed")
+	unchanged := cacheKey("test-engine", "unchanged")
+	require.NoError(t, cacheableClient.cache.Set(changed, Entry{}))
+	require.NoError(t, cacheableClient.cache.Set(unchanged, Entry{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cacheableClient.WatchChanges(ctx, time.Second, time.Second)
+	}()
+	for range feeds {
+		<-served
+	}
+	require.Eventually(t, func() bool {
+		_, found := cacheableClient.cache.Get(changed)
+		return !found
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	_, found := cacheableClient.cache.Get(unchanged)
+	assert.True(t, found)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"", "v1", "v1", "v2"}, epochs[:4])
+}
+
+func TestCacheableClient_WatchChangesRetry(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	cacheableClient.WatchChanges(ctx, time.Second, 100*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, requests, 2, "failed requests should be retried")
+	assert.LessOrEqual(t, requests, 4, "retries should wait for the retry interval")
+}
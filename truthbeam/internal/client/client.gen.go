@@ -12,6 +12,9 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
+
+	"github.com/oapi-codegen/runtime"
 )
 
 // Defines values for ComplianceEnrichmentStatus.
@@ -32,6 +35,38 @@ const (
 	Medium        ComplianceRiskLevel = "Medium"
 )
 
+// Defines values for SnapshotSignatureAlgorithm.
+const (
+	ES256   SnapshotSignatureAlgorithm = "ES256"
+	Ed25519 SnapshotSignatureAlgorithm = "Ed25519"
+	RS256   SnapshotSignatureAlgorithm = "RS256"
+)
+
+// BatchEnrichmentRequest Request payload for enriching several policies at once
+type BatchEnrichmentRequest struct {
+	Policies []Policy `json:"policies"`
+}
+
+// BatchEnrichmentResponse Compliance metadata for each policy in a batch request, in request order.
+type BatchEnrichmentResponse struct {
+	Results []EnrichmentResult `json:"results"`
+}
+
+// ChangeFeed Policy rules whose enrichment changed after a revision.
+type ChangeFeed struct {
+	// Changes Policy engine and rule keys whose enrichment changed
+	Changes []Policy `json:"changes"`
+
+	// Epoch Identifies the content served; it changes with every published revision
+	Epoch string `json:"epoch"`
+
+	// Reset When true, the changes are not known and all cached enrichment must be discarded
+	Reset bool `json:"reset"`
+
+	// Revision Current mapping revision, to be passed as `since` on the next request
+	Revision int64 `json:"revision"`
+}
+
 // Compliance Compliance details from OCSF Security Control Profile.
 type Compliance struct {
 	// Control Security control information for compliance assessment
@@ -40,6 +75,9 @@ type Compliance struct {
 	// EnrichmentStatus Status of the compliance enrichment process: Success, Unmapped, Partial, Unknown, or Skipped.
 	EnrichmentStatus ComplianceEnrichmentStatus `json:"enrichmentStatus"`
 
+	// Exceptions Approved exceptions active for the control and policy rule. Exceptions with a target only apply to evidence about that target.
+	Exceptions *[]ComplianceException `json:"exceptions,omitempty"`
+
 	// Frameworks Compliance framework and requirement information
 	Frameworks ComplianceFrameworks `json:"frameworks"`
 
@@ -68,6 +106,24 @@ type ComplianceControl struct {
 	RemediationDescription *string `json:"remediationDescription,omitempty"`
 }
 
+// ComplianceException Approved risk exception or waiver for a control and policy rule
+type ComplianceException struct {
+	// Approver Who approved the exception
+	Approver string `json:"approver"`
+
+	// Expires When the exception stops applying
+	Expires time.Time `json:"expires"`
+
+	// Id Unique identifier for the approved exception
+	Id string `json:"id"`
+
+	// Reason Why the exception was approved
+	Reason string `json:"reason"`
+
+	// Target Policy target the exception is limited to; the exception applies to every target when omitted
+	Target *string `json:"target,omitempty"`
+}
+
 // ComplianceFrameworks Compliance framework and requirement information
 type ComplianceFrameworks struct {
 	// Frameworks Regulatory or industry standards being evaluated for compliance
@@ -98,6 +154,15 @@ type EnrichmentResponse struct {
 	Compliance Compliance `json:"compliance"`
 }
 
+// EnrichmentResult Compliance metadata for a single policy in a batch response.
+type EnrichmentResult struct {
+	// Compliance Compliance details from OCSF Security Control Profile.
+	Compliance Compliance `json:"compliance"`
+
+	// Policy Complete evidence log from policy engines and compliance assessment tools
+	Policy Policy `json:"policy"`
+}
+
 // Error defines model for Error.
 type Error struct {
 	// Code HTTP status code
@@ -116,9 +181,75 @@ type Policy struct {
 	PolicyRuleId string `json:"policyRuleId"`
 }
 
+// Snapshot Resolved compliance enrichment for every known policy rule.
+type Snapshot struct {
+	// Complete False when a configured mapper cannot list its policy rules, so some rules are missing
+	Complete bool `json:"complete"`
+
+	// ContentVersion Content version of the catalogs and evaluation plans the snapshot was resolved from
+	ContentVersion string `json:"contentVersion"`
+
+	// Entries Enrichment by policy engine and rule, sorted by engine name then rule ID
+	Entries []SnapshotEntry `json:"entries"`
+
+	// FormatVersion Version of the snapshot document format
+	FormatVersion int32 `json:"formatVersion"`
+
+	// GeneratedAt Time the snapshot was generated
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	// Signature Signature over the snapshot serialized as JSON without its `signature` property.
+	Signature *SnapshotSignature `json:"signature,omitempty"`
+}
+
+// SnapshotEntry Enrichment for a single policy rule.
+type SnapshotEntry struct {
+	// Compliance Compliance details from OCSF Security Control Profile.
+	Compliance Compliance `json:"compliance"`
+
+	// Policy Complete evidence log from policy engines and compliance assessment tools
+	Policy Policy `json:"policy"`
+}
+
+// SnapshotSignature Signature over the snapshot serialized as JSON without its `signature` property.
+type SnapshotSignature struct {
+	// Algorithm Signature algorithm
+	Algorithm SnapshotSignatureAlgorithm `json:"algorithm"`
+
+	// KeyId Hex encoded prefix of the SHA-256 digest of the DER encoded public key
+	KeyId string `json:"keyId"`
+
+	// Value Base64 encoded signature
+	Value []byte `json:"value"`
+}
+
+// SnapshotSignatureAlgorithm Signature algorithm
+type SnapshotSignatureAlgorithm string
+
+// GetV1ChangesParams defines parameters for GetV1Changes.
+type GetV1ChangesParams struct {
+	// Since Revision previously returned by this endpoint. When omitted, only the current revision is returned.
+	Since *int64 `form:"since,omitempty" json:"since,omitempty"`
+
+	// Epoch Epoch previously returned by this endpoint along with `since`. An unknown epoch resets the feed.
+	Epoch *string `form:"epoch,omitempty" json:"epoch,omitempty"`
+
+	// Wait Seconds to wait for a change when none are pending. Capped by the server.
+	Wait *int32 `form:"wait,omitempty" json:"wait,omitempty"`
+}
+
+// PostV1EnrichParams defines parameters for PostV1Enrich.
+type PostV1EnrichParams struct {
+	// IfNoneMatch ETag of a previously cached enrichment response
+	IfNoneMatch *string `json:"If-None-Match,omitempty"`
+}
+
 // PostV1EnrichJSONRequestBody defines body for PostV1Enrich for application/json ContentType.
 type PostV1EnrichJSONRequestBody = EnrichmentRequest
 
+// PostV1EnrichBatchJSONRequestBody defines body for PostV1EnrichBatch for application/json ContentType.
+type PostV1EnrichBatchJSONRequestBody = BatchEnrichmentRequest
+
 // RequestEditorFn  is the function signature for the RequestEditor callback function
 type RequestEditorFn func(ctx context.Context, req *http.Request) error
 
@@ -192,14 +323,73 @@ func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
 
 // The interface specification for the client above.
 type ClientInterface interface {
+	// GetV1Changes request
+	GetV1Changes(ctx context.Context, params *GetV1ChangesParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// PostV1EnrichWithBody request with any body
-	PostV1EnrichWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+	PostV1EnrichWithBody(ctx context.Context, params *PostV1EnrichParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PostV1Enrich(ctx context.Context, params *PostV1EnrichParams, body PostV1EnrichJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostV1EnrichBatchWithBody request with any body
+	PostV1EnrichBatchWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PostV1EnrichBatch(ctx context.Context, body PostV1EnrichBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetV1Snapshot request
+	GetV1Snapshot(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+}
+
+func (c *Client) GetV1Changes(ctx context.Context, params *GetV1ChangesParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetV1ChangesRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostV1EnrichWithBody(ctx context.Context, params *PostV1EnrichParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostV1EnrichRequestWithBody(c.Server, params, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
 
-	PostV1Enrich(ctx context.Context, body PostV1EnrichJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+func (c *Client) PostV1Enrich(ctx context.Context, params *PostV1EnrichParams, body PostV1EnrichJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostV1EnrichRequest(c.Server, params, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostV1EnrichBatchWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostV1EnrichBatchRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-func (c *Client) PostV1EnrichWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostV1EnrichRequestWithBody(c.Server, contentType, body)
+func (c *Client) PostV1EnrichBatch(ctx context.Context, body PostV1EnrichBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostV1EnrichBatchRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
@@ -210,8 +400,8 @@ func (c *Client) PostV1EnrichWithBody(ctx context.Context, contentType string, b
 	return c.Client.Do(req)
 }
 
-func (c *Client) PostV1Enrich(ctx context.Context, body PostV1EnrichJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostV1EnrichRequest(c.Server, body)
+func (c *Client) GetV1Snapshot(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetV1SnapshotRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -222,19 +412,100 @@ func (c *Client) PostV1Enrich(ctx context.Context, body PostV1EnrichJSONRequestB
 	return c.Client.Do(req)
 }
 
+// NewGetV1ChangesRequest generates requests for GetV1Changes
+func NewGetV1ChangesRequest(server string, params *GetV1ChangesParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/v1/changes")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Since != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "since", runtime.ParamLocationQuery, *params.Since); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Epoch != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "epoch", runtime.ParamLocationQuery, *params.Epoch); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Wait != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "wait", runtime.ParamLocationQuery, *params.Wait); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 // NewPostV1EnrichRequest calls the generic PostV1Enrich builder with application/json body
-func NewPostV1EnrichRequest(server string, body PostV1EnrichJSONRequestBody) (*http.Request, error) {
+func NewPostV1EnrichRequest(server string, params *PostV1EnrichParams, body PostV1EnrichJSONRequestBody) (*http.Request, error) {
 	var bodyReader io.Reader
 	buf, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 	bodyReader = bytes.NewReader(buf)
-	return NewPostV1EnrichRequestWithBody(server, "application/json", bodyReader)
+	return NewPostV1EnrichRequestWithBody(server, params, "application/json", bodyReader)
 }
 
 // NewPostV1EnrichRequestWithBody generates requests for PostV1Enrich with any type of body
-func NewPostV1EnrichRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+func NewPostV1EnrichRequestWithBody(server string, params *PostV1EnrichParams, contentType string, body io.Reader) (*http.Request, error) {
 	var err error
 
 	serverURL, err := url.Parse(server)
@@ -259,6 +530,88 @@ func NewPostV1EnrichRequestWithBody(server string, contentType string, body io.R
 
 	req.Header.Add("Content-Type", contentType)
 
+	if params != nil {
+
+		if params.IfNoneMatch != nil {
+			var headerParam0 string
+
+			headerParam0, err = runtime.StyleParamWithLocation("simple", false, "If-None-Match", runtime.ParamLocationHeader, *params.IfNoneMatch)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("If-None-Match", headerParam0)
+		}
+
+	}
+
+	return req, nil
+}
+
+// NewPostV1EnrichBatchRequest calls the generic PostV1EnrichBatch builder with application/json body
+func NewPostV1EnrichBatchRequest(server string, body PostV1EnrichBatchJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPostV1EnrichBatchRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewPostV1EnrichBatchRequestWithBody generates requests for PostV1EnrichBatch with any type of body
+func NewPostV1EnrichBatchRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/v1/enrich/batch")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewGetV1SnapshotRequest generates requests for GetV1Snapshot
+func NewGetV1SnapshotRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/v1/snapshot")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
 	return req, nil
 }
 
@@ -305,10 +658,44 @@ func WithBaseURL(baseURL string) ClientOption {
 
 // ClientWithResponsesInterface is the interface specification for the client with responses above.
 type ClientWithResponsesInterface interface {
+	// GetV1ChangesWithResponse request
+	GetV1ChangesWithResponse(ctx context.Context, params *GetV1ChangesParams, reqEditors ...RequestEditorFn) (*GetV1ChangesResponse, error)
+
 	// PostV1EnrichWithBodyWithResponse request with any body
-	PostV1EnrichWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostV1EnrichResponse, error)
+	PostV1EnrichWithBodyWithResponse(ctx context.Context, params *PostV1EnrichParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostV1EnrichResponse, error)
+
+	PostV1EnrichWithResponse(ctx context.Context, params *PostV1EnrichParams, body PostV1EnrichJSONRequestBody, reqEditors ...RequestEditorFn) (*PostV1EnrichResponse, error)
+
+	// PostV1EnrichBatchWithBodyWithResponse request with any body
+	PostV1EnrichBatchWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostV1EnrichBatchResponse, error)
+
+	PostV1EnrichBatchWithResponse(ctx context.Context, body PostV1EnrichBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*PostV1EnrichBatchResponse, error)
+
+	// GetV1SnapshotWithResponse request
+	GetV1SnapshotWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetV1SnapshotResponse, error)
+}
+
+type GetV1ChangesResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ChangeFeed
+	JSONDefault  *Error
+}
 
-	PostV1EnrichWithResponse(ctx context.Context, body PostV1EnrichJSONRequestBody, reqEditors ...RequestEditorFn) (*PostV1EnrichResponse, error)
+// Status returns HTTPResponse.Status
+func (r GetV1ChangesResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetV1ChangesResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
 }
 
 type PostV1EnrichResponse struct {
@@ -334,23 +721,137 @@ func (r PostV1EnrichResponse) StatusCode() int {
 	return 0
 }
 
+type PostV1EnrichBatchResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *BatchEnrichmentResponse
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r PostV1EnrichBatchResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostV1EnrichBatchResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetV1SnapshotResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Snapshot
+	JSONDefault  *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r GetV1SnapshotResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetV1SnapshotResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+// GetV1ChangesWithResponse request returning *GetV1ChangesResponse
+func (c *ClientWithResponses) GetV1ChangesWithResponse(ctx context.Context, params *GetV1ChangesParams, reqEditors ...RequestEditorFn) (*GetV1ChangesResponse, error) {
+	rsp, err := c.GetV1Changes(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetV1ChangesResponse(rsp)
+}
+
 // PostV1EnrichWithBodyWithResponse request with arbitrary body returning *PostV1EnrichResponse
-func (c *ClientWithResponses) PostV1EnrichWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostV1EnrichResponse, error) {
-	rsp, err := c.PostV1EnrichWithBody(ctx, contentType, body, reqEditors...)
+func (c *ClientWithResponses) PostV1EnrichWithBodyWithResponse(ctx context.Context, params *PostV1EnrichParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostV1EnrichResponse, error) {
+	rsp, err := c.PostV1EnrichWithBody(ctx, params, contentType, body, reqEditors...)
 	if err != nil {
 		return nil, err
 	}
 	return ParsePostV1EnrichResponse(rsp)
 }
 
-func (c *ClientWithResponses) PostV1EnrichWithResponse(ctx context.Context, body PostV1EnrichJSONRequestBody, reqEditors ...RequestEditorFn) (*PostV1EnrichResponse, error) {
-	rsp, err := c.PostV1Enrich(ctx, body, reqEditors...)
+func (c *ClientWithResponses) PostV1EnrichWithResponse(ctx context.Context, params *PostV1EnrichParams, body PostV1EnrichJSONRequestBody, reqEditors ...RequestEditorFn) (*PostV1EnrichResponse, error) {
+	rsp, err := c.PostV1Enrich(ctx, params, body, reqEditors...)
 	if err != nil {
 		return nil, err
 	}
 	return ParsePostV1EnrichResponse(rsp)
 }
 
+// PostV1EnrichBatchWithBodyWithResponse request with arbitrary body returning *PostV1EnrichBatchResponse
+func (c *ClientWithResponses) PostV1EnrichBatchWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostV1EnrichBatchResponse, error) {
+	rsp, err := c.PostV1EnrichBatchWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostV1EnrichBatchResponse(rsp)
+}
+
+func (c *ClientWithResponses) PostV1EnrichBatchWithResponse(ctx context.Context, body PostV1EnrichBatchJSONRequestBody, reqEditors ...RequestEditorFn) (*PostV1EnrichBatchResponse, error) {
+	rsp, err := c.PostV1EnrichBatch(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostV1EnrichBatchResponse(rsp)
+}
+
+// GetV1SnapshotWithResponse request returning *GetV1SnapshotResponse
+func (c *ClientWithResponses) GetV1SnapshotWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetV1SnapshotResponse, error) {
+	rsp, err := c.GetV1Snapshot(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetV1SnapshotResponse(rsp)
+}
+
+// ParseGetV1ChangesResponse parses an HTTP response from a GetV1ChangesWithResponse call
+func ParseGetV1ChangesResponse(rsp *http.Response) (*GetV1ChangesResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetV1ChangesResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ChangeFeed
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParsePostV1EnrichResponse parses an HTTP response from a PostV1EnrichWithResponse call
 func ParsePostV1EnrichResponse(rsp *http.Response) (*PostV1EnrichResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -383,3 +884,69 @@ func ParsePostV1EnrichResponse(rsp *http.Response) (*PostV1EnrichResponse, error
 
 	return response, nil
 }
+
+// ParsePostV1EnrichBatchResponse parses an HTTP response from a PostV1EnrichBatchWithResponse call
+func ParsePostV1EnrichBatchResponse(rsp *http.Response) (*PostV1EnrichBatchResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PostV1EnrichBatchResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest BatchEnrichmentResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetV1SnapshotResponse parses an HTTP response from a GetV1SnapshotWithResponse call
+func ParseGetV1SnapshotResponse(rsp *http.Response) (*GetV1SnapshotResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetV1SnapshotResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Snapshot
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
@@ -17,3 +17,73 @@ const DefaultCacheCapacity = 100000
 // CacheKeySeparator is the separator used to create composite cache keys
 // from policy engine name and policy rule id.
 const CacheKeySeparator = ":"
+
+// DefaultChangeFeedWait is how long a change feed request waits for
+// Compass to publish a change before returning empty.
+const DefaultChangeFeedWait = 20 * time.Second
+
+// DefaultChangeFeedRetryInterval is how long the change feed watcher waits
+// before retrying after a failed request.
+const DefaultChangeFeedRetryInterval = 5 * time.Second
+
+// DefaultBatchSize is the default maximum number of policies sent in one
+// batch enrichment request.
+const DefaultBatchSize = 100
+
+// MaxBatchSize is the largest batch accepted by Compass.
+const MaxBatchSize = 1000
+
+// DefaultMaxConcurrency is the default maximum number of concurrent
+// Compass calls made while resolving a batch of cache misses.
+const DefaultMaxConcurrency = 8
+
+// DefaultUnmappedCacheTTL is the default cache TTL for Unmapped and Partial
+// results, which often change while evaluation plans are being authored.
+const DefaultUnmappedCacheTTL = 5 * time.Minute
+
+// DefaultErrorCacheTTL is the default cache TTL for failed Compass calls.
+// It is kept short so recovery is picked up quickly.
+const DefaultErrorCacheTTL = 10 * time.Second
+
+// DefaultMaxRetries is the default number of retries of a failed Compass
+// call.
+const DefaultMaxRetries = 2
+
+// DefaultRetryInitialInterval is the default backoff before the first
+// retry of a failed Compass call.
+const DefaultRetryInitialInterval = 100 * time.Millisecond
+
+// DefaultRetryMaxInterval is the default upper bound of the retry backoff.
+const DefaultRetryMaxInterval = 2 * time.Second
+
+// DefaultBreakerFailureThreshold is the default number of consecutive
+// failed Compass calls that open the circuit breaker.
+const DefaultBreakerFailureThreshold = 5
+
+// DefaultBreakerOpenDuration is how long the circuit breaker stays open
+// before a trial call is let through.
+const DefaultBreakerOpenDuration = 30 * time.Second
+
+// DefaultMaxStaleness is the default bound on how long an expired entry is
+// served while it is refreshed in stale-while-revalidate mode.
+const DefaultMaxStaleness = time.Hour
+
+// DefaultWarmupTimeout bounds how long cache warmup may take at startup.
+const DefaultWarmupTimeout = 30 * time.Second
+
+// DefaultRedisKeyPrefix is prepended to the keys of the shared Redis cache.
+const DefaultRedisKeyPrefix = "truthbeam:"
+
+// DefaultRedisTimeout bounds every command sent to the shared Redis cache.
+const DefaultRedisTimeout = 500 * time.Millisecond
+
+// DefaultRedisRetryInterval is how long a failed Redis server is skipped
+// before it is tried again.
+const DefaultRedisRetryInterval = 30 * time.Second
+
+// DefaultRedisPoolSize is the default number of pooled Redis connections.
+const DefaultRedisPoolSize = 10
+
+// DefaultStorageSweepInterval is how often expired and cleared entries are
+// removed from the persistent cache.
+const DefaultStorageSweepInterval = 10 * time.Minute
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// failoverDoer sends each request to the endpoints of a Compass backend in
+// turn until one answers without a server error. It starts from the
+// endpoint that last answered, so a failed endpoint is only tried again
+// once the others fail too.
+type failoverDoer struct {
+	doer      HttpRequestDoer
+	endpoints []*url.URL
+	current   atomic.Int32
+}
+
+// NewFailoverClient creates a client for a Compass backend served from
+// several equivalent endpoints. Requests that fail in transport or with a
+// server error are repeated against the next endpoint.
+func NewFailoverClient(endpoints []string, doer HttpRequestDoer) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no compass endpoints")
+	}
+	if doer == nil {
+		doer = &http.Client{}
+	}
+
+	failover := &failoverDoer{doer: doer}
+	for _, endpoint := range endpoints {
+		parsed, err := url.Parse(strings.TrimSuffix(endpoint, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid compass endpoint %q: %w", endpoint, err)
+		}
+		failover.endpoints = append(failover.endpoints, parsed)
+	}
+	return NewClient(failover.endpoints[0].String(), WithHTTPClient(failover))
+}
+
+func (d *failoverDoer) Do(req *http.Request) (*http.Response, error) {
+	start := int(d.current.Load())
+	var resp *http.Response
+	var err error
+	for i := range d.endpoints {
+		index := (start + i) % len(d.endpoints)
+		attempt, rewriteErr := d.rewrite(req, index)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		if rewriteErr != nil {
+			if err == nil && resp != nil {
+				err = &StatusError{StatusCode: resp.StatusCode}
+			}
+			return nil, errors.Join(err, rewriteErr)
+		}
+
+		resp, err = d.doer.Do(attempt)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			if index != start {
+				d.current.Store(int32(index))
+			}
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			break
+		}
+	}
+	return resp, err
+}
+
+// rewrite returns req sent to the endpoint at index instead of the first
+// one, which the client builds every request from.
+func (d *failoverDoer) rewrite(req *http.Request, index int) (*http.Request, error) {
+	if index == 0 && req.Body == nil {
+		return req, nil
+	}
+
+	attempt := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("request body cannot be sent to another endpoint")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attempt.Body = body
+	}
+
+	endpoint := d.endpoints[index]
+	path := strings.TrimPrefix(req.URL.Path, d.endpoints[0].Path)
+	attempt.URL.Scheme = endpoint.Scheme
+	attempt.URL.Host = endpoint.Host
+	attempt.URL.Path = endpoint.Path + path
+	attempt.URL.RawPath = ""
+	attempt.Host = ""
+	return attempt, nil
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFailoverClient(t *testing.T) {
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+
+	var primaryCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+
+	var secondaryCalls atomic.Int32
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls.Add(1)
+		assert.Equal(t, "/compass/v1/enrich", r.URL.Path)
+		var req EnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, policy.PolicyEngineName, req.Policy.PolicyEngineName)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(EnrichmentResponse{Compliance: testCompliance(req.Policy)})
+	}))
+	defer secondary.Close()
+
+	baseClient, err := NewFailoverClient([]string{primary.URL, secondary.URL + "/compass"}, nil)
+	require.NoError(t, err)
+	cacheable, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	compliance, err := cacheable.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, testCompliance(policy), compliance)
+	assert.Equal(t, int32(1), primaryCalls.Load())
+	assert.Equal(t, int32(1), secondaryCalls.Load())
+
+	// The endpoint that answered is tried first from then on.
+	_, err = cacheable.Retrieve(context.Background(), Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-2"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), primaryCalls.Load())
+	assert.Equal(t, int32(2), secondaryCalls.Load())
+}
+
+func TestFailoverClientAllEndpointsFail(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	baseClient, err := NewFailoverClient([]string{server.URL, server.URL}, nil)
+	require.NoError(t, err)
+	cacheable, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	_, err = cacheable.Retrieve(context.Background(), Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"})
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+
+	_, err = NewFailoverClient(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestFailoverClientBodyNotReplayable(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var closed atomic.Bool
+	doer := &failoverDoer{doer: doerFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body = &closeTracker{ReadCloser: resp.Body, closed: &closed}
+		}
+		return resp, err
+	})}
+	for _, endpoint := range []string{server.URL + "/", server.URL + "/"} {
+		parsed, err := url.Parse(endpoint)
+		require.NoError(t, err)
+		doer.endpoints = append(doer.endpoints, parsed)
+	}
+
+	// The body cannot be read again for the second endpoint, so the first
+	// response is closed and the failure reported.
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/enrich", strings.NewReader("{}"))
+	require.NoError(t, err)
+	getBody := req.GetBody
+	var bodies atomic.Int32
+	req.GetBody = func() (io.ReadCloser, error) {
+		if bodies.Add(1) > 1 {
+			return nil, errors.New("body already consumed")
+		}
+		return getBody()
+	}
+	resp, err := doer.Do(req)
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "503")
+	assert.ErrorContains(t, err, "body already consumed")
+	assert.Equal(t, int32(1), calls.Load())
+	assert.True(t, closed.Load())
+}
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type closeTracker struct {
+	io.ReadCloser
+	closed *atomic.Bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed.Store(true)
+	return c.ReadCloser.Close()
+}
+
+func TestWithPartition(t *testing.T) {
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	shared := newMemoryStorage()
+	storageL2, err := NewStorageStore(context.Background(), shared, time.Hour)
+	require.NoError(t, err)
+	redisL2 := newTestRedisStore(t, miniredis.RunT(t))
+
+	for name, l2 := range map[string]Cache{"storage": storageL2, "redis": redisL2} {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			newClient := func(partition string, compliance Compliance) *CacheableClient {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls.Add(1)
+					w.Header().Set("Content-Type", "application/json")
+					_ = json.NewEncoder(w).Encode(EnrichmentResponse{Compliance: compliance})
+				}))
+				t.Cleanup(server.Close)
+				baseClient, err := NewClient(server.URL)
+				require.NoError(t, err)
+				c, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0, WithBackingCache(l2), WithPartition(partition))
+				require.NoError(t, err)
+				return c
+			}
+
+			financeCompliance := testCompliance(policy)
+			financeCompliance.Control.Id = "finance-control"
+			retailCompliance := testCompliance(policy)
+			retailCompliance.Control.Id = "retail-control"
+			finance := newClient("finance", financeCompliance)
+			retail := newClient("retail", retailCompliance)
+
+			got, err := finance.Retrieve(context.Background(), policy)
+			require.NoError(t, err)
+			assert.Equal(t, "finance-control", got.Control.Id)
+			got, err = retail.Retrieve(context.Background(), policy)
+			require.NoError(t, err)
+			assert.Equal(t, "retail-control", got.Control.Id)
+			assert.Equal(t, int32(2), calls.Load())
+
+			// Clearing one backend leaves the shared entries of the other,
+			// which a restarted client still finds.
+			require.NoError(t, finance.cache.Clear())
+			got, err = newClient("retail", retailCompliance).Retrieve(context.Background(), policy)
+			require.NoError(t, err)
+			assert.Equal(t, "retail-control", got.Control.Id)
+			assert.Equal(t, int32(2), calls.Load())
+			got, err = newClient("finance", financeCompliance).Retrieve(context.Background(), policy)
+			require.NoError(t, err)
+			assert.Equal(t, "finance-control", got.Control.Id)
+			assert.Equal(t, int32(3), calls.Load())
+		})
+	}
+
+	assert.Contains(t, shared.data, "finance:test-engine:rule-1")
+	assert.Contains(t, shared.data, "retail:test-engine:rule-1")
+	assert.Contains(t, shared.data, "finance:generation")
+}
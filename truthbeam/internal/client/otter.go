@@ -8,18 +8,21 @@ import (
 )
 
 // Interface Check
-var _ Cache = (*otterCacheStore)(nil)
+var (
+	_ Cache      = (*otterCacheStore)(nil)
+	_ statsCache = (*otterCacheStore)(nil)
+)
 
 // otterCacheStore implements Cache using Otter.
 type otterCacheStore struct {
-	cache *otter.Cache[string, Compliance]
+	cache *otter.Cache[string, Entry]
 }
 
-func (s *otterCacheStore) Get(key string) (Compliance, bool) {
+func (s *otterCacheStore) Get(key string) (Entry, bool) {
 	return s.cache.GetIfPresent(key)
 }
 
-func (s *otterCacheStore) Set(key string, value Compliance) error {
+func (s *otterCacheStore) Set(key string, value Entry) error {
 	_, _ = s.cache.Set(key, value)
 	return nil
 }
@@ -29,11 +32,31 @@ func (s *otterCacheStore) Delete(key string) error {
 	return nil
 }
 
+func (s *otterCacheStore) Clear() error {
+	s.cache.InvalidateAll()
+	return nil
+}
+
+func (s *otterCacheStore) Stats() CacheStats {
+	stats := s.cache.Stats()
+	return CacheStats{
+		Hits:      stats.Hits,
+		Misses:    stats.Misses,
+		Evictions: stats.Evictions,
+		Size:      s.cache.EstimatedSize(),
+	}
+}
+
 func NewOtterStore(ttl time.Duration, maxEntries int) (Cache, error) {
-	opts := &otter.Options[string, Compliance]{
-		MaximumSize:      maxEntries,
-		ExpiryCalculator: otter.ExpiryWriting[string, Compliance](ttl),
-		StatsRecorder:    stats.NewCounter(),
+	opts := &otter.Options[string, Entry]{
+		MaximumSize: maxEntries,
+		ExpiryCalculator: otter.ExpiryWritingFunc(func(entry otter.Entry[string, Entry]) time.Duration {
+			if entry.Value.TTL > 0 {
+				return entry.Value.TTL
+			}
+			return ttl
+		}),
+		StatsRecorder: stats.NewCounter(),
 	}
 	cache := otter.Must(opts)
 	return &otterCacheStore{cache: cache}, nil
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Interface Check
+var (
+	_ Cache       = (*redisStore)(nil)
+	_ Partitioner = (*redisStore)(nil)
+)
+
+// errRedisUnavailable is returned while a failed Redis server is being
+// skipped.
+var errRedisUnavailable = errors.New("redis cache is unavailable")
+
+// redisGenerationKey holds the current cache generation under the store
+// prefix, and the partition name for partitions. Clear starts a new generation instead of deleting keys, so a
+// reset costs one command however many collectors share the server;
+// entries from older generations are ignored and expire with their TTL.
+const redisGenerationKey = "generation"
+
+// redisEntry is the stored form of an Entry, with the generation it was
+// written in.
+type redisEntry struct {
+	Entry      Entry `json:"entry"`
+	Generation int64 `json:"generation"`
+}
+
+// RedisOptions configures a Redis-protocol cache.
+type RedisOptions struct {
+	// Client is the pooled connection to the Redis server.
+	Client redis.UniversalClient
+	// KeyPrefix is prepended to every key, so several fleets can share a
+	// server.
+	KeyPrefix string
+	// TTL is used for entries without their own TTL.
+	TTL time.Duration
+	// Timeout bounds every Redis command.
+	Timeout time.Duration
+	// RetryInterval is how long a failed server is skipped before it is
+	// tried again.
+	RetryInterval time.Duration
+}
+
+// redisStore implements Cache over a Redis-protocol server shared by a
+// fleet of collectors.
+type redisStore struct {
+	opts RedisOptions
+	now  func() time.Time
+	// downUntil is when, in Unix nanoseconds, a failed server is tried
+	// again. It is shared by the partitions of a store.
+	downUntil *atomic.Int64
+	// generationKey holds the generation of the store or partition.
+	generationKey string
+	// generation is the cache generation last read from the server, which
+	// new entries are written in.
+	generation atomic.Int64
+}
+
+// NewRedisStore creates a Cache backed by a Redis-protocol server. After a
+// failed command the server is skipped for RetryInterval: lookups miss and
+// writes are dropped instead of waiting on it, so it is best layered under
+// a local cache with WithBackingCache.
+func NewRedisStore(opts RedisOptions) Cache {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultRedisTimeout
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultRedisRetryInterval
+	}
+	return &redisStore{
+		opts:          opts,
+		now:           time.Now,
+		downUntil:     new(atomic.Int64),
+		generationKey: opts.KeyPrefix + redisGenerationKey,
+	}
+}
+
+// Partition returns the view of the store for the named partition, with
+// its own generation.
+func (s *redisStore) Partition(name string) Cache {
+	return &redisStore{
+		opts:          s.opts,
+		now:           s.now,
+		downUntil:     s.downUntil,
+		generationKey: s.opts.KeyPrefix + partitionKey(name, redisGenerationKey),
+	}
+}
+
+// do runs a Redis command unless the server is being skipped. A failure
+// other than a missing key marks the server down for RetryInterval.
+func (s *redisStore) do(command func(ctx context.Context) error) error {
+	if s.now().UnixNano() < s.downUntil.Load() {
+		return errRedisUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+	err := command(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		s.downUntil.Store(s.now().Add(s.opts.RetryInterval).UnixNano())
+		return fmt.Errorf("redis cache command failed: %w", err)
+	}
+	return err
+}
+
+// Get returns the entry for key, with its TTL set to its remaining
+// lifetime on the server. Entries from an older generation are not
+// returned.
+func (s *redisStore) Get(key string) (Entry, bool) {
+	var value string
+	var remaining time.Duration
+	var generation int64
+	err := s.do(func(ctx context.Context) error {
+		pipe := s.opts.Client.Pipeline()
+		gen := pipe.Get(ctx, s.generationKey)
+		get := pipe.Get(ctx, s.opts.KeyPrefix+key)
+		ttl := pipe.PTTL(ctx, s.opts.KeyPrefix+key)
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		// A missing generation key is generation 0. The generation is
+		// kept even on a miss, since the entry is written next.
+		generation, _ = gen.Int64()
+		s.generation.Store(generation)
+		value, remaining = get.Val(), ttl.Val()
+		return get.Err()
+	})
+	if err != nil {
+		return Entry{}, false
+	}
+
+	var stored redisEntry
+	if err := json.Unmarshal([]byte(value), &stored); err != nil || stored.Generation != generation {
+		return Entry{}, false
+	}
+	entry := stored.Entry
+	if remaining > 0 {
+		entry.TTL = remaining
+	}
+	return entry, true
+}
+
+func (s *redisStore) Set(key string, value Entry) error {
+	ttl := value.TTL
+	if ttl <= 0 {
+		ttl = s.opts.TTL
+	}
+	encoded, err := json.Marshal(redisEntry{Entry: value, Generation: s.generation.Load()})
+	if err != nil {
+		return err
+	}
+	err = s.do(func(ctx context.Context) error {
+		return s.opts.Client.Set(ctx, s.opts.KeyPrefix+key, encoded, ttl).Err()
+	})
+	// The shared copy is best effort; the local cache still has the entry.
+	if errors.Is(err, errRedisUnavailable) {
+		return nil
+	}
+	return err
+}
+
+func (s *redisStore) Delete(key string) error {
+	return s.do(func(ctx context.Context) error {
+		return s.opts.Client.Del(ctx, s.opts.KeyPrefix+key).Err()
+	})
+}
+
+// Clear starts a new cache generation of the store or partition, so
+// entries written before it are no longer returned by any collector
+// sharing the server.
+func (s *redisStore) Clear() error {
+	return s.do(func(ctx context.Context) error {
+		generation, err := s.opts.Client.Incr(ctx, s.generationKey).Result()
+		if err != nil {
+			return err
+		}
+		s.generation.Store(generation)
+		return nil
+	})
+}
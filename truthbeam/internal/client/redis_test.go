@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestRedisStore(t *testing.T, server *miniredis.Miniredis) *redisStore {
+	t.Helper()
+	redisClient := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = redisClient.Close() })
+	return NewRedisStore(RedisOptions{
+		Client:        redisClient,
+		KeyPrefix:     "fleet-a:",
+		TTL:           time.Hour,
+		RetryInterval: time.Minute,
+	}).(*redisStore)
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := newTestRedisStore(t, server)
+
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	require.NoError(t, store.Set("default-ttl", Entry{Compliance: testCompliance(policy), ETag: `"v1"`}))
+	require.NoError(t, store.Set("own-ttl", Entry{Compliance: testCompliance(policy), TTL: time.Minute}))
+
+	// Keys are prefixed, and entry TTLs are passed through.
+	assert.True(t, server.Exists("fleet-a:default-ttl"))
+	assert.Equal(t, time.Hour, server.TTL("fleet-a:default-ttl"))
+	assert.Equal(t, time.Minute, server.TTL("fleet-a:own-ttl"))
+
+	entry, found := store.Get("default-ttl")
+	require.True(t, found)
+	assert.Equal(t, "rule-1-control", entry.Compliance.Control.Id)
+	assert.Equal(t, `"v1"`, entry.ETag)
+	assert.Equal(t, time.Hour, entry.TTL)
+
+	server.FastForward(2 * time.Minute)
+	_, found = store.Get("own-ttl")
+	assert.False(t, found)
+
+	// Clear starts a new generation under the store prefix instead of
+	// deleting keys, and every store sharing the prefix ignores older
+	// entries.
+	other := newTestRedisStore(t, server)
+	_, found = other.Get("default-ttl")
+	require.True(t, found)
+	require.NoError(t, store.Clear())
+	assert.True(t, server.Exists("fleet-a:default-ttl"))
+	generation, err := server.Get("fleet-a:generation")
+	require.NoError(t, err)
+	assert.Equal(t, "1", generation)
+	_, found = store.Get("default-ttl")
+	assert.False(t, found)
+	_, found = other.Get("default-ttl")
+	assert.False(t, found)
+
+	require.NoError(t, other.Set("default-ttl", Entry{Compliance: testCompliance(policy)}))
+	_, found = store.Get("default-ttl")
+	assert.True(t, found, "entries written after a reset are shared again")
+
+	require.NoError(t, store.Set("key", Entry{Compliance: testCompliance(policy)}))
+	require.NoError(t, store.Delete("key"))
+	assert.False(t, server.Exists("fleet-a:key"))
+}
+
+func TestRedisStore_Unavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := newTestRedisStore(t, server)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	l1, err := NewOtterStore(time.Hour, 100)
+	require.NoError(t, err)
+	cache := NewTieredCache(l1, store)
+
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	server.Close()
+
+	// The first failure is reported, and the local cache keeps working.
+	assert.Error(t, cache.Set("key", Entry{Compliance: testCompliance(policy)}))
+	_, found := cache.Get("key")
+	assert.True(t, found)
+
+	// While the server is skipped, writes are dropped without error.
+	require.NoError(t, cache.Set("other", Entry{Compliance: testCompliance(policy)}))
+	assert.ErrorIs(t, store.Delete("other"), errRedisUnavailable)
+
+	// After the retry interval the server is tried again.
+	require.NoError(t, server.Restart())
+	now = now.Add(time.Minute)
+	require.NoError(t, cache.Set("key", Entry{Compliance: testCompliance(policy)}))
+	assert.True(t, server.Exists("fleet-a:key"))
+}
+
+func TestCacheableClient_SharedCache(t *testing.T) {
+	var calls atomic.Int32
+	compass := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req EnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(EnrichmentResponse{Compliance: testCompliance(req.Policy)})
+	}))
+	defer compass.Close()
+	server := miniredis.RunT(t)
+
+	// Two replicas sharing one Redis server call Compass once.
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	for i := 0; i < 2; i++ {
+		baseClient, err := NewClient(compass.URL)
+		require.NoError(t, err)
+		replica, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0, WithBackingCache(newTestRedisStore(t, server)))
+		require.NoError(t, err)
+
+		compliance, err := replica.Retrieve(context.Background(), policy)
+		require.NoError(t, err)
+		assert.Equal(t, "rule-1-control", compliance.Control.Id)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,289 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned without calling Compass while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("compass circuit breaker is open")
+
+// StatusError is returned when Compass answers with an error status.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected response status: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("API call failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// ResponseError is returned when a Compass response cannot be decoded or
+// does not answer the request. Repeating the call would fail the same way.
+type ResponseError struct {
+	Err error
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("invalid compass response: %s", e.Err)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// responseError marks a response body that could not be parsed as a
+// ResponseError, unless reading it failed in transport.
+func responseError(err error) error {
+	if retryable(err) {
+		return err
+	}
+	return &ResponseError{Err: err}
+}
+
+// retryable reports whether a failed call may succeed when repeated:
+// network errors, responses cut short, throttling and server errors.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var responseErr *ResponseError
+	if errors.As(err, &responseErr) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Transient reports whether a failed lookup may succeed later: Compass
+// could not be reached, was throttled, failed with a server error or has
+// an open circuit breaker. Client errors and invalid responses fail again
+// unchanged. Cached failures keep the class of the call that failed.
+func Transient(err error) bool {
+	var cached *cachedError
+	if errors.As(err, &cached) {
+		return cached.transient
+	}
+	return errors.Is(err, ErrCircuitOpen) || retryable(err)
+}
+
+// timedOut reports whether a failed call ran out of time. Timed out calls
+// are not retried, since every attempt would wait for the full timeout
+// again; they still count against the circuit breaker.
+func timedOut(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// RetryPolicy configures retries of failed Compass calls.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// InitialInterval is the backoff before the first retry. It doubles
+	// for each further retry, up to MaxInterval.
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+// backoff returns the jittered delay before the given retry, counting from
+// zero. The delay is between half and all of the exponential backoff.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialInterval
+	for i := 0; i < retry && delay < p.MaxInterval; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxInterval)
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// BreakerState is the state of the Compass circuit breaker.
+type BreakerState int32
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects every call until the open duration has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial call through.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is a consecutive failure circuit breaker.
+type breaker struct {
+	threshold    int
+	openDuration time.Duration
+	now          func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openUntil time.Time
+	// trial is set while the half-open trial call is outstanding.
+	trial bool
+}
+
+func newBreaker(threshold int, openDuration time.Duration, now func() time.Time) *breaker {
+	return &breaker{threshold: threshold, openDuration: openDuration, now: now}
+}
+
+// allow reports whether a call may be made.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Before(b.openUntil) {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.trial = true
+		return true
+	case BreakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of an allowed call. It
+// returns the new state and whether it changed.
+func (b *breaker) record(failed bool) (BreakerState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previous := b.state
+	b.trial = false
+	if !failed {
+		b.state = BreakerClosed
+		b.failures = 0
+		return b.state, b.state != previous
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openUntil = b.now().Add(b.openDuration)
+	}
+	return b.state, b.state != previous
+}
+
+// abort releases the half-open trial without recording an outcome, for
+// calls abandoned by their caller.
+func (b *breaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// resilience wraps Compass calls with retries and the circuit breaker.
+type resilience struct {
+	retry    RetryPolicy
+	breaker  *breaker
+	logger   *zap.Logger
+	observer CallObserver
+	retries  atomic.Int64
+}
+
+// attempt makes one call and reports it to the observer.
+func (r *resilience) attempt(ctx context.Context, call func(context.Context) error) error {
+	if r.observer == nil {
+		return call(ctx)
+	}
+	start := time.Now()
+	err := call(ctx)
+	r.observer(ctx, time.Since(start), err)
+	return err
+}
+
+// do runs call, retrying failures that are worth retrying except
+// timeouts. While the circuit breaker is open, ErrCircuitOpen is returned
+// without calling.
+func (r *resilience) do(ctx context.Context, call func(context.Context) error) error {
+	if r.breaker != nil && !r.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := r.attempt(ctx, call)
+retries:
+	for retry := 0; err != nil && retry < r.retry.MaxRetries && retryable(err) && !timedOut(err); retry++ {
+		delay := r.retry.backoff(retry)
+		r.logger.Debug("retrying compass call",
+			zap.Int("retry", retry+1),
+			zap.Duration("backoff", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// The breaker below still has to release a half-open trial.
+			err = errors.Join(err, ctx.Err())
+			break retries
+		case <-timer.C:
+		}
+		r.retries.Add(1)
+		err = r.attempt(ctx, call)
+	}
+
+	if r.breaker != nil && err != nil && ctx.Err() != nil {
+		r.breaker.abort()
+	} else if r.breaker != nil {
+		// Only failures that point at Compass itself count against it.
+		state, changed := r.breaker.record(err != nil && retryable(err))
+		if changed {
+			r.logger.Warn("compass circuit breaker state changed", zap.Stringer("state", state))
+		}
+	}
+	return err
+}
@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 5, InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second}
+
+	tests := []struct {
+		retry    int
+		expected time.Duration
+	}{
+		{retry: 0, expected: 100 * time.Millisecond},
+		{retry: 1, expected: 200 * time.Millisecond},
+		{retry: 2, expected: 400 * time.Millisecond},
+		{retry: 3, expected: 800 * time.Millisecond},
+		{retry: 4, expected: time.Second},
+		{retry: 10, expected: time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			delay := policy.backoff(tt.retry)
+			assert.GreaterOrEqual(t, delay, tt.expected/2)
+			assert.LessOrEqual(t, delay, tt.expected)
+		}
+	}
+
+	assert.Zero(t, RetryPolicy{}.backoff(0))
+}
+
+func TestRetryable(t *testing.T) {
+	var syntaxErr *json.SyntaxError
+	decodeErr := json.Unmarshal([]byte("{"), &struct{}{})
+	require.ErrorAs(t, decodeErr, &syntaxErr)
+
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+		transient bool
+	}{
+		{name: "server error", err: &StatusError{StatusCode: http.StatusServiceUnavailable}, retryable: true, transient: true},
+		{name: "throttled", err: &StatusError{StatusCode: http.StatusTooManyRequests}, retryable: true, transient: true},
+		{name: "bad request", err: &StatusError{StatusCode: http.StatusBadRequest}},
+		{name: "network error", err: &url.Error{Op: "Post", URL: "http://compass", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, retryable: true, transient: true},
+		{name: "timeout", err: context.DeadlineExceeded, retryable: true, transient: true},
+		{name: "truncated response", err: io.ErrUnexpectedEOF, retryable: true, transient: true},
+		{name: "undecodable response", err: responseError(decodeErr)},
+		{name: "policy missing from batch response", err: fmt.Errorf("failed to fetch metadata: %w", &ResponseError{Err: errors.New("policy missing from batch response")})},
+		{name: "batch unsupported", err: errBatchUnsupported},
+		{name: "cancelled", err: &url.Error{Op: "Post", URL: "http://compass", Err: context.Canceled}},
+		{name: "other error", err: errors.New("request body cannot be sent to another endpoint")},
+		{name: "circuit open", err: ErrCircuitOpen, transient: true},
+		{name: "cached server error", err: &cachedError{message: "unavailable", transient: true}, transient: true},
+		{name: "cached client error", err: &cachedError{message: "bad request"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.retryable, retryable(tt.err))
+			assert.Equal(t, tt.transient, Transient(tt.err))
+		})
+	}
+}
+
+func TestCacheableClient_RetrieveInvalidResponse(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"compliance": `))
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0,
+		WithRetry(RetryPolicy{MaxRetries: 2, InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}),
+		WithCircuitBreaker(1, time.Minute),
+	)
+	require.NoError(t, err)
+
+	// A response that cannot be decoded is neither retried nor held
+	// against the breaker.
+	_, err = cacheableClient.Retrieve(context.Background(), Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"})
+	var responseErr *ResponseError
+	require.ErrorAs(t, err, &responseErr)
+	assert.False(t, Transient(err))
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, BreakerClosed, cacheableClient.BreakerState())
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(2, time.Minute, func() time.Time { return now })
+
+	// Failures below the threshold keep the breaker closed, and a success
+	// resets the count.
+	require.True(t, b.allow())
+	_, changed := b.record(true)
+	assert.False(t, changed)
+	b.record(false)
+	b.record(true)
+	assert.Equal(t, BreakerClosed, b.State())
+
+	state, changed := b.record(true)
+	assert.True(t, changed)
+	assert.Equal(t, BreakerOpen, state)
+	assert.False(t, b.allow())
+
+	// After the open duration a single trial call is let through.
+	now = now.Add(time.Minute)
+	assert.True(t, b.allow())
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.False(t, b.allow())
+
+	// A failed trial opens the breaker again.
+	state, _ = b.record(true)
+	assert.Equal(t, BreakerOpen, state)
+	assert.False(t, b.allow())
+
+	// A successful trial closes it.
+	now = now.Add(time.Minute)
+	require.True(t, b.allow())
+	state, changed = b.record(false)
+	assert.True(t, changed)
+	assert.Equal(t, BreakerClosed, state)
+	assert.True(t, b.allow())
+}
+
+func TestResilience_CancelledTrial(t *testing.T) {
+	now := time.Now()
+	r := &resilience{
+		retry:   RetryPolicy{MaxRetries: 1, InitialInterval: time.Minute, MaxInterval: time.Minute},
+		breaker: newBreaker(1, time.Minute, func() time.Time { return now }),
+		logger:  zap.NewNop(),
+	}
+	r.breaker.record(true)
+	now = now.Add(time.Minute)
+
+	// The half-open trial is abandoned while waiting to retry.
+	ctx, cancel := context.WithCancel(context.Background())
+	err := r.do(ctx, func(context.Context) error {
+		cancel()
+		return &StatusError{StatusCode: http.StatusServiceUnavailable}
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, BreakerHalfOpen, r.breaker.State())
+
+	// The next call is the new trial and closes the breaker.
+	err = r.do(context.Background(), func(context.Context) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, BreakerClosed, r.breaker.State())
+}
+
+func TestCacheableClient_RetrieveRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"code": 503, "message": "Service unavailable"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"compliance": {"control": {"id": "AC-1", "catalogId": "NIST-800-53", "category": "Access Control"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Success"}}`))
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0,
+		WithRetry(RetryPolicy{MaxRetries: 2, InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	compliance, err := cacheableClient.Retrieve(context.Background(), Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"})
+	require.NoError(t, err)
+	assert.Equal(t, "AC-1", compliance.Control.Id)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, int64(2), cacheableClient.Retries())
+}
+
+func TestCacheableClient_RetrieveTimeoutNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	baseClient, err := NewClient(server.URL, WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}))
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0,
+		WithRetry(RetryPolicy{MaxRetries: 2, InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}),
+		WithCircuitBreaker(1, time.Minute),
+	)
+	require.NoError(t, err)
+
+	_, err = cacheableClient.Retrieve(context.Background(), Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"})
+	require.Error(t, err)
+	assert.True(t, timedOut(err))
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int64(0), cacheableClient.Retries())
+	assert.Equal(t, BreakerOpen, cacheableClient.BreakerState(), "timeouts count against the breaker")
+}
+
+func TestCacheableClient_RetrieveCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"code": 500, "message": "Internal server error"}`))
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0,
+		WithCircuitBreaker(2, time.Minute),
+	)
+	require.NoError(t, err)
+
+	policies := testPolicies(3)
+	_, err = cacheableClient.Retrieve(context.Background(), policies[0])
+	require.Error(t, err)
+	_, err = cacheableClient.Retrieve(context.Background(), policies[1])
+	require.Error(t, err)
+	assert.Equal(t, BreakerOpen, cacheableClient.BreakerState())
+
+	// The open breaker fails fast without calling Compass or caching.
+	_, err = cacheableClient.Retrieve(context.Background(), policies[2])
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load())
+	_, found := cacheableClient.cache.Get(cacheKey(policies[2].PolicyEngineName, policies[2].PolicyRuleId))
+	assert.False(t, found)
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// CacheStats is a point-in-time view of the in-memory cache.
+type CacheStats struct {
+	// Hits and Misses count lookups since the cache was created.
+	Hits   uint64
+	Misses uint64
+	// Evictions counts entries removed to stay within capacity or
+	// because they expired.
+	Evictions uint64
+	// Size is the approximate number of cached entries.
+	Size int
+}
+
+// statsCache is implemented by caches that can report CacheStats.
+type statsCache interface {
+	Stats() CacheStats
+}
+
+// CallObserver is notified after every call made to Compass, including
+// retries, with how long it took and the error it returned, if any.
+type CallObserver func(ctx context.Context, elapsed time.Duration, err error)
+
+// WithCallObserver sets a function notified after every Compass call.
+func WithCallObserver(observer CallObserver) Option {
+	return func(c *CacheableClient) {
+		c.resilience.observer = observer
+	}
+}
+
+// CacheStats reports the in-memory cache statistics. It returns false when
+// the cache does not keep them.
+func (c *CacheableClient) CacheStats() (CacheStats, bool) {
+	cache, ok := c.cache.(statsCache)
+	if !ok {
+		return CacheStats{}, false
+	}
+	return cache.Stats(), true
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCacheableClient_CacheStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"compliance": {"control": {"id": "AC-1", "catalogId": "NIST-800-53", "category": "Access Control"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Success"}}`))
+	}))
+	defer server.Close()
+
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	l2, err := NewStorageStore(context.Background(), newMemoryStorage(), time.Hour)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0, WithBackingCache(l2))
+	require.NoError(t, err)
+
+	policy := Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"}
+	for range 3 {
+		_, err = cacheableClient.Retrieve(context.Background(), policy)
+		require.NoError(t, err)
+	}
+
+	stats, ok := cacheableClient.CacheStats()
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Size)
+
+	// Custom caches may not keep statistics.
+	_, ok = NewCacheableClientWithCache(baseClient, zap.NewNop(), l2).CacheStats()
+	assert.False(t, ok)
+}
+
+func TestCacheableClient_CallObserver(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"code": 503, "message": "Service unavailable"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"compliance": {"control": {"id": "AC-1", "catalogId": "NIST-800-53", "category": "Access Control"},
+			"frameworks": {"requirements": [], "frameworks": []}, "enrichmentStatus": "Success"}}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var observed []error
+	baseClient, err := NewClient(server.URL)
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0,
+		WithRetry(RetryPolicy{MaxRetries: 1, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}),
+		WithCallObserver(func(_ context.Context, elapsed time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Positive(t, elapsed)
+			observed = append(observed, err)
+		}),
+	)
+	require.NoError(t, err)
+
+	_, err = cacheableClient.Retrieve(context.Background(), Policy{PolicyRuleId: "test-policy-123", PolicyEngineName: "test-engine"})
+	require.NoError(t, err)
+
+	require.Len(t, observed, 2)
+	var statusErr *StatusError
+	require.True(t, errors.As(observed[0], &statusErr))
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.NoError(t, observed[1])
+}
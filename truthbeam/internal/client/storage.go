@@ -0,0 +1,341 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+)
+
+// Interface Check
+var (
+	_ Cache       = (*storageStore)(nil)
+	_ Sweeper     = (*storageStore)(nil)
+	_ Partitioner = (*storageStore)(nil)
+	_ Cache       = (*tieredCache)(nil)
+	_ statsCache  = (*tieredCache)(nil)
+)
+
+// generationKey holds the current cache generation in storage, prefixed
+// with the partition name for partitions. Clear starts a new generation,
+// since storage clients cannot list their keys; entries from older
+// generations are ignored and removed when read or swept.
+const generationKey = "generation"
+
+// indexKey holds the index of stored keys, so entries that are never read
+// again can still be swept.
+const indexKey = "index"
+
+// Sweeper is implemented by caches that remove expired entries in bulk
+// rather than only when they are read.
+type Sweeper interface {
+	// Sweep removes expired entries and returns how many were removed.
+	Sweep(ctx context.Context) (int, error)
+}
+
+// Partitioner is implemented by backing caches that clients of several
+// Compass backends can share. Partition returns the view of the cache for
+// one partition, whose Clear leaves the entries of the others alone.
+type Partitioner interface {
+	Partition(name string) Cache
+}
+
+// partitionKey returns the key of name in partition.
+func partitionKey(partition, name string) string {
+	if partition == "" {
+		return name
+	}
+	return partition + CacheKeySeparator + name
+}
+
+// storedEntry is the persisted form of an Entry, with its own expiry so
+// entries outlive the process but not their TTL.
+type storedEntry struct {
+	Entry      Entry     `json:"entry"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Generation uint64    `json:"generation"`
+}
+
+// indexEntry records when a stored key expires, and its partition and
+// generation.
+type indexEntry struct {
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Partition  string    `json:"partition,omitempty"`
+	Generation uint64    `json:"generation"`
+}
+
+// storageStore implements Cache over a collector storage extension client.
+// The partitions of a store share its client and key index.
+type storageStore struct {
+	*storageState
+	partition string
+}
+
+// storageState is shared by the partitions of a storageStore.
+type storageState struct {
+	client storage.Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu sync.Mutex
+	// generations holds the current generation of every partition in
+	// use, with "" for the store itself.
+	generations map[string]uint64
+	// index lists every stored key. It is written back by Sweep, so keys
+	// stored after the last sweep before a crash are only removed when
+	// they are read again.
+	index map[string]indexEntry
+	dirty bool
+}
+
+// NewStorageStore creates a Cache persisted through a collector storage
+// extension client, such as file_storage. Entries expire after their TTL,
+// or ttl when they have none, and are removed by Sweep.
+func NewStorageStore(ctx context.Context, client storage.Client, ttl time.Duration) (Cache, error) {
+	s := &storageStore{storageState: &storageState{
+		client:      client,
+		ttl:         ttl,
+		now:         time.Now,
+		generations: make(map[string]uint64),
+		index:       make(map[string]indexEntry),
+	}}
+
+	if err := s.loadGeneration(ctx, ""); err != nil {
+		return nil, err
+	}
+
+	value, err := client.Get(ctx, indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache index: %w", err)
+	}
+	if value != nil && json.Unmarshal(value, &s.index) != nil {
+		// An unreadable index is rebuilt as entries are stored again.
+		s.index = make(map[string]indexEntry)
+	}
+	return s, nil
+}
+
+// loadGeneration reads the stored generation of partition.
+func (s *storageState) loadGeneration(ctx context.Context, partition string) error {
+	value, err := s.client.Get(ctx, partitionKey(partition, generationKey))
+	if err != nil {
+		return fmt.Errorf("failed to read cache generation: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(value) == 8 {
+		s.generations[partition] = binary.BigEndian.Uint64(value)
+	} else {
+		s.generations[partition] = 0
+	}
+	return nil
+}
+
+// Partition returns the view of the store for the named partition. When
+// its generation cannot be read, the partition starts from generation 0,
+// which at worst ignores its older entries until they expire.
+func (s *storageStore) Partition(name string) Cache {
+	_ = s.loadGeneration(context.Background(), name)
+	return &storageStore{storageState: s.storageState, partition: name}
+}
+
+// generation returns the current generation of the partition.
+func (s *storageStore) generation() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generations[s.partition]
+}
+
+// Get returns the entry for key, with its TTL set to its remaining
+// lifetime.
+func (s *storageStore) Get(key string) (Entry, bool) {
+	ctx := context.Background()
+	value, err := s.client.Get(ctx, key)
+	if err != nil || value == nil {
+		return Entry{}, false
+	}
+
+	var stored storedEntry
+	if err := json.Unmarshal(value, &stored); err != nil {
+		_ = s.Delete(key)
+		return Entry{}, false
+	}
+	remaining := stored.ExpiresAt.Sub(s.now())
+	if stored.Generation != s.generation() || remaining <= 0 {
+		_ = s.Delete(key)
+		return Entry{}, false
+	}
+
+	stored.Entry.TTL = remaining
+	return stored.Entry, true
+}
+
+func (s *storageStore) Set(key string, value Entry) error {
+	ttl := value.TTL
+	if ttl <= 0 {
+		ttl = s.ttl
+	}
+	stored := storedEntry{
+		Entry:      value,
+		ExpiresAt:  s.now().Add(ttl),
+		Generation: s.generation(),
+	}
+	encoded, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Set(context.Background(), key, encoded); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index[key] = indexEntry{ExpiresAt: stored.ExpiresAt, Partition: s.partition, Generation: stored.Generation}
+	s.dirty = true
+	return nil
+}
+
+func (s *storageStore) Delete(key string) error {
+	if err := s.client.Delete(context.Background(), key); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.index[key]; ok {
+		delete(s.index, key)
+		s.dirty = true
+	}
+	return nil
+}
+
+// Clear starts a new generation of the partition.
+func (s *storageStore) Clear() error {
+	s.mu.Lock()
+	s.generations[s.partition]++
+	value := binary.BigEndian.AppendUint64(nil, s.generations[s.partition])
+	s.mu.Unlock()
+	return s.client.Set(context.Background(), partitionKey(s.partition, generationKey), value)
+}
+
+// Sweep removes the indexed entries of every partition that expired or
+// belong to an older generation, and writes the index back to storage.
+func (s *storageStore) Sweep(ctx context.Context) (int, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	var ops []*storage.Operation
+	for key, entry := range s.index {
+		if s.stale(entry, now) {
+			ops = append(ops, storage.DeleteOperation(key))
+		}
+	}
+	s.mu.Unlock()
+
+	if len(ops) > 0 {
+		if err := s.client.Batch(ctx, ops...); err != nil {
+			return 0, fmt.Errorf("failed to remove expired cache entries: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	for _, op := range ops {
+		// A key stored again since the scan stays indexed. Its new value
+		// may have been removed with the old one, which only costs a miss.
+		if entry, ok := s.index[op.Key]; ok && s.stale(entry, now) {
+			delete(s.index, op.Key)
+		}
+	}
+	dirty := s.dirty || len(ops) > 0
+	var encoded []byte
+	var err error
+	if dirty {
+		encoded, err = json.Marshal(s.index)
+		s.dirty = false
+	}
+	s.mu.Unlock()
+	if err != nil || !dirty {
+		return len(ops), err
+	}
+
+	if err := s.client.Set(ctx, indexKey, encoded); err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return len(ops), fmt.Errorf("failed to write cache index: %w", err)
+	}
+	return len(ops), nil
+}
+
+// stale reports whether an indexed entry expired or belongs to an older
+// generation. Entries of partitions not in use are kept until they expire.
+// It must be called with mu held.
+func (s *storageState) stale(entry indexEntry, now time.Time) bool {
+	if !now.Before(entry.ExpiresAt) {
+		return true
+	}
+	generation, ok := s.generations[entry.Partition]
+	return ok && entry.Generation != generation
+}
+
+// tieredCache layers an in-memory cache over a persistent one.
+type tieredCache struct {
+	l1 Cache
+	l2 Cache
+}
+
+// NewTieredCache creates a Cache that serves from l1 and falls back to l2,
+// copying l2 hits into l1. Failed Compass calls are only kept in l1.
+func NewTieredCache(l1, l2 Cache) Cache {
+	return &tieredCache{l1: l1, l2: l2}
+}
+
+// Stats reports the statistics of the in-memory cache.
+func (t *tieredCache) Stats() CacheStats {
+	if l1, ok := t.l1.(statsCache); ok {
+		return l1.Stats()
+	}
+	return CacheStats{}
+}
+
+func (t *tieredCache) Get(key string) (Entry, bool) {
+	if entry, ok := t.l1.Get(key); ok {
+		return entry, true
+	}
+	entry, ok := t.l2.Get(key)
+	if !ok {
+		return Entry{}, false
+	}
+	_ = t.l1.Set(key, entry)
+	return entry, true
+}
+
+func (t *tieredCache) Set(key string, value Entry) error {
+	if err := t.l1.Set(key, value); err != nil {
+		return err
+	}
+	if value.Err != "" {
+		// A cached failure must not outlive the process, so drop any
+		// older persisted entry instead.
+		return t.l2.Delete(key)
+	}
+	return t.l2.Set(key, value)
+}
+
+func (t *tieredCache) Delete(key string) error {
+	if err := t.l1.Delete(key); err != nil {
+		return err
+	}
+	return t.l2.Delete(key)
+}
+
+func (t *tieredCache) Clear() error {
+	if err := t.l1.Clear(); err != nil {
+		return err
+	}
+	return t.l2.Clear()
+}
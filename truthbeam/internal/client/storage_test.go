@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+	"go.uber.org/zap"
+)
+
+// memoryStorage is a storage client that keeps its data in a map, like a
+// file_storage database that survives restarts.
+type memoryStorage struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{data: make(map[string][]byte)}
+}
+
+func (m *memoryStorage) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[key], nil
+}
+
+func (m *memoryStorage) Set(_ context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func (m *memoryStorage) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memoryStorage) Batch(ctx context.Context, ops ...*storage.Operation) error {
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value, _ = m.Get(ctx, op.Key)
+		case storage.Set:
+			_ = m.Set(ctx, op.Key, op.Value)
+		case storage.Delete:
+			_ = m.Delete(ctx, op.Key)
+		}
+	}
+	return nil
+}
+
+func (m *memoryStorage) Close(context.Context) error { return nil }
+
+func TestStorageStore(t *testing.T) {
+	backend := newMemoryStorage()
+	cache, err := NewStorageStore(context.Background(), backend, time.Hour)
+	require.NoError(t, err)
+	store := cache.(*storageStore)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	entry := Entry{Compliance: testCompliance(policy), ETag: `"v1"`}
+	require.NoError(t, store.Set("short", Entry{Compliance: testCompliance(policy), TTL: time.Minute}))
+	require.NoError(t, store.Set("long", entry))
+
+	got, found := store.Get("long")
+	require.True(t, found)
+	assert.Equal(t, entry.Compliance, got.Compliance)
+	assert.Equal(t, `"v1"`, got.ETag)
+	assert.Equal(t, time.Hour, got.TTL)
+
+	// Entries expire by their own metadata and are removed when read.
+	now = now.Add(30 * time.Minute)
+	_, found = store.Get("short")
+	assert.False(t, found)
+	assert.NotContains(t, backend.data, "short")
+	got, found = store.Get("long")
+	require.True(t, found)
+	assert.Equal(t, 30*time.Minute, got.TTL)
+
+	// Entries written before a restart are still served.
+	restarted, err := NewStorageStore(context.Background(), backend, time.Hour)
+	require.NoError(t, err)
+	_, found = restarted.Get("long")
+	assert.True(t, found)
+
+	// Clear survives a restart too, so entries written before it are
+	// ignored.
+	persisted := backend.data["long"]
+	require.NoError(t, restarted.Clear())
+	restarted, err = NewStorageStore(context.Background(), backend, time.Hour)
+	require.NoError(t, err)
+	_, found = restarted.Get("long")
+	assert.False(t, found)
+	assert.NotContains(t, backend.data, "long")
+	require.NotNil(t, persisted)
+}
+
+func TestStorageStore_Sweep(t *testing.T) {
+	backend := newMemoryStorage()
+	cache, err := NewStorageStore(context.Background(), backend, time.Hour)
+	require.NoError(t, err)
+	store := cache.(*storageStore)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	require.NoError(t, store.Set("short", Entry{Compliance: testCompliance(policy), TTL: time.Minute}))
+	require.NoError(t, store.Set("long", Entry{Compliance: testCompliance(policy)}))
+
+	removed, err := store.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	assert.Contains(t, backend.data, indexKey)
+
+	// Expired entries are removed without being read, and the index
+	// survives a restart.
+	now = now.Add(30 * time.Minute)
+	removed, err = store.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NotContains(t, backend.data, "short")
+	assert.Contains(t, backend.data, "long")
+
+	restarted, err := NewStorageStore(context.Background(), backend, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"long"}, slices.Collect(maps.Keys(restarted.(*storageStore).index)))
+
+	// Entries from before a Clear are removed by the next sweep.
+	require.NoError(t, restarted.Clear())
+	removed, err = restarted.(Sweeper).Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NotContains(t, backend.data, "long")
+	assert.Empty(t, restarted.(*storageStore).index)
+
+	// Clearing a partition only sweeps its own entries.
+	finance := restarted.(Partitioner).Partition("finance")
+	retail := restarted.(Partitioner).Partition("retail")
+	require.NoError(t, finance.Set("finance:rule", Entry{Compliance: testCompliance(policy)}))
+	require.NoError(t, retail.Set("retail:rule", Entry{Compliance: testCompliance(policy)}))
+	require.NoError(t, finance.Clear())
+	removed, err = restarted.(Sweeper).Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NotContains(t, backend.data, "finance:rule")
+	_, found := retail.Get("retail:rule")
+	assert.True(t, found)
+}
+
+func TestTieredCache(t *testing.T) {
+	l1, err := NewOtterStore(time.Hour, 100)
+	require.NoError(t, err)
+	l2, err := NewStorageStore(context.Background(), newMemoryStorage(), time.Hour)
+	require.NoError(t, err)
+	cache := NewTieredCache(l1, l2)
+
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	require.NoError(t, cache.Set("key", Entry{Compliance: testCompliance(policy)}))
+
+	// A cold L1, as after a restart, is filled from L2.
+	require.NoError(t, l1.Clear())
+	got, found := cache.Get("key")
+	require.True(t, found)
+	assert.Equal(t, "rule-1-control", got.Compliance.Control.Id)
+	_, found = l1.Get("key")
+	assert.True(t, found)
+
+	// Failed calls are not persisted and replace the persisted entry.
+	require.NoError(t, cache.Set("key", Entry{Err: "unavailable"}))
+	_, found = l2.Get("key")
+	assert.False(t, found)
+
+	require.NoError(t, cache.Set("other", Entry{Compliance: testCompliance(policy)}))
+	require.NoError(t, cache.Delete("other"))
+	_, found = l2.Get("other")
+	assert.False(t, found)
+}
+
+func TestCacheableClient_BackingCache(t *testing.T) {
+	backend := newMemoryStorage()
+	l2, err := NewStorageStore(context.Background(), backend, time.Hour)
+	require.NoError(t, err)
+
+	baseClient, err := NewClient("http://localhost:0")
+	require.NoError(t, err)
+	cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0, WithBackingCache(l2))
+	require.NoError(t, err)
+
+	// An entry persisted by a previous process is served without Compass.
+	policy := Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	require.NoError(t, l2.Set(cacheKey(policy.PolicyEngineName, policy.PolicyRuleId), Entry{Compliance: testCompliance(policy)}))
+	compliance, err := cacheableClient.Retrieve(context.Background(), policy)
+	require.NoError(t, err)
+	assert.Equal(t, "rule-1-control", compliance.Control.Id)
+}
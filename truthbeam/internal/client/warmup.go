@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Warm fills the cache ahead of the first records. When policies is empty,
+// the full mapping snapshot is fetched from Compass; otherwise only the
+// given policies are retrieved. It returns the number of entries cached.
+func (c *CacheableClient) Warm(ctx context.Context, policies []Policy) (int, error) {
+	if len(policies) > 0 {
+		warmed := 0
+		for policy, result := range c.RetrieveAll(ctx, policies) {
+			if result.Err != nil {
+				c.logger.Debug("failed to warm enrichment",
+					zap.String("policy_rule_id", policy.PolicyRuleId),
+					zap.String("policy_engine_name", policy.PolicyEngineName),
+					zap.Error(result.Err),
+				)
+				continue
+			}
+			warmed++
+		}
+		return warmed, ctx.Err()
+	}
+
+	var snapshot *Snapshot
+	var entry Entry
+	err := c.resilience.do(ctx, func(ctx context.Context) error {
+		resp, err := c.client.GetV1Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+		parsedResp, err := ParseGetV1SnapshotResponse(resp)
+		if err != nil {
+			return responseError(err)
+		}
+		if parsedResp.JSON200 == nil {
+			if parsedResp.JSONDefault != nil {
+				return &StatusError{StatusCode: resp.StatusCode, Message: parsedResp.JSONDefault.Message}
+			}
+			return &StatusError{StatusCode: resp.StatusCode}
+		}
+		snapshot = parsedResp.JSON200
+		entry = Entry{ETag: resp.Header.Get("ETag"), Expires: c.expiresAt(resp.Header)}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, snapshotEntry := range snapshot.Entries {
+		entry.Compliance = snapshotEntry.Compliance
+		c.store(snapshotEntry.Policy, entry)
+	}
+	if !snapshot.Complete {
+		c.logger.Info("compass snapshot is incomplete; some policies will be fetched on first use")
+	}
+	return len(snapshot.Entries), nil
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCacheableClient_Warm(t *testing.T) {
+	var snapshotCalls, batchCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/snapshot":
+			snapshotCalls.Add(1)
+			snapshot := Snapshot{FormatVersion: 1, Complete: true, Entries: []SnapshotEntry{}}
+			for _, policy := range testPolicies(3) {
+				snapshot.Entries = append(snapshot.Entries, SnapshotEntry{Policy: policy, Compliance: testCompliance(policy)})
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_ = json.NewEncoder(w).Encode(snapshot)
+		case "/v1/enrich/batch":
+			batchCalls.Add(1)
+			var req BatchEnrichmentRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			response := BatchEnrichmentResponse{Results: []EnrichmentResult{}}
+			for _, policy := range req.Policies {
+				response.Results = append(response.Results, EnrichmentResult{Policy: policy, Compliance: testCompliance(policy)})
+			}
+			_ = json.NewEncoder(w).Encode(response)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("snapshot", func(t *testing.T) {
+		baseClient, err := NewClient(server.URL)
+		require.NoError(t, err)
+		cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+		require.NoError(t, err)
+
+		warmed, err := cacheableClient.Warm(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3, warmed)
+		assert.Equal(t, int32(1), snapshotCalls.Load())
+
+		entry, found := cacheableClient.cache.Get(cacheKey("test-engine", "rule-2"))
+		require.True(t, found)
+		assert.Equal(t, "rule-2-control", entry.Compliance.Control.Id)
+		assert.Equal(t, `"v1"`, entry.ETag)
+	})
+
+	t.Run("policies", func(t *testing.T) {
+		baseClient, err := NewClient(server.URL)
+		require.NoError(t, err)
+		cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+		require.NoError(t, err)
+
+		warmed, err := cacheableClient.Warm(context.Background(), testPolicies(2))
+		require.NoError(t, err)
+		assert.Equal(t, 2, warmed)
+		assert.Equal(t, int32(1), batchCalls.Load())
+		_, found := cacheableClient.cache.Get(cacheKey("test-engine", "rule-1"))
+		assert.True(t, found)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer failing.Close()
+
+		baseClient, err := NewClient(failing.URL)
+		require.NoError(t, err)
+		cacheableClient, err := NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+		require.NoError(t, err)
+
+		warmed, err := cacheableClient.Warm(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Zero(t, warmed)
+	})
+}
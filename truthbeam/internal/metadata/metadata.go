@@ -5,5 +5,9 @@ import "go.opentelemetry.io/collector/component"
 var Type = component.MustNewType("truthbeam")
 
 const (
-	LogsStability = component.StabilityLevelAlpha
+	LogsStability   = component.StabilityLevelAlpha
+	TracesStability = component.StabilityLevelAlpha
 )
+
+// ScopeName is the instrumentation scope of the processor's own telemetry.
+const ScopeName = "github.com/complytime/complybeacon/truthbeam"
@@ -0,0 +1,183 @@
+package offline
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/client"
+)
+
+// DefaultReloadInterval is how often the snapshot file is checked for
+// changes.
+const DefaultReloadInterval = 10 * time.Second
+
+// Source resolves enrichment lookups from a local mapping snapshot written
+// by `compass export`, with no Compass service. Mappings are resolved by
+// compass when the snapshot is exported, so offline lookups return exactly
+// what the service would.
+type Source struct {
+	path      string
+	publicKey crypto.PublicKey
+	logger    *zap.Logger
+
+	mu      sync.RWMutex
+	entries map[client.Policy]client.Compliance
+	// state is the modification state of the snapshot file the entries
+	// were loaded from, to detect changes.
+	state fileState
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewSource loads the snapshot at path. When publicKey is not nil, only
+// snapshots with a valid signature from that key are loaded.
+func NewSource(path string, publicKey crypto.PublicKey, logger *zap.Logger) (*Source, error) {
+	s := &Source{path: filepath.Clean(path), publicKey: publicKey, logger: logger}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload loads the snapshot again. The previous entries are kept when the
+// snapshot cannot be loaded.
+func (s *Source) Reload() error {
+	state, err := stat(s.path)
+	if err != nil {
+		return err
+	}
+	snapshot, err := load(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to load snapshot %s: %w", s.path, err)
+	}
+	if s.publicKey != nil {
+		if err := verify(snapshot, s.publicKey); err != nil {
+			return fmt.Errorf("failed to verify snapshot %s: %w", s.path, err)
+		}
+	} else if snapshot.Signature != nil {
+		s.logger.Warn("mapping snapshot is signed but no public key is configured; signature not verified",
+			zap.String("path", s.path),
+			zap.String("key_id", snapshot.Signature.KeyId),
+		)
+	}
+
+	entries := make(map[client.Policy]client.Compliance, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		entries[entry.Policy] = entry.Compliance
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = entries
+	s.state = state
+	s.logger.Info("mapping snapshot loaded",
+		zap.String("path", s.path),
+		zap.Int("entries", len(entries)),
+	)
+	return nil
+}
+
+// RetrieveAll resolves every policy in policies. Policies missing from the
+// snapshot are Unmapped, as Compass reports them.
+func (s *Source) RetrieveAll(_ context.Context, policies []client.Policy) map[client.Policy]client.Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make(map[client.Policy]client.Result, len(policies))
+	for _, policy := range policies {
+		compliance, ok := s.entries[policy]
+		if !ok {
+			compliance = unmapped()
+		}
+		results[policy] = client.Result{Compliance: compliance}
+	}
+	return results
+}
+
+// Watch reloads the snapshot whenever its file changes, checking
+// every interval until ctx is done.
+func (s *Source) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !s.changed() {
+			continue
+		}
+		if err := s.Reload(); err != nil {
+			s.logger.Warn("failed to reload mapping snapshot; keeping previous entries", zap.Error(err))
+		}
+	}
+}
+
+// changed reports whether the snapshot file was modified or removed since
+// the last load.
+func (s *Source) changed() bool {
+	s.mu.RLock()
+	previous := s.state
+	s.mu.RUnlock()
+
+	current, err := stat(s.path)
+	return err != nil || current != previous
+}
+
+// load reads a snapshot exported by compass.
+func load(path string) (client.Snapshot, error) {
+	var snapshot client.Snapshot
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" {
+		return snapshot, fmt.Errorf("unsupported snapshot file extension %q: expected a .json snapshot written by compass export", ext)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+func stat(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// unmapped is the compliance data Compass returns for unknown policies.
+func unmapped() client.Compliance {
+	return client.Compliance{
+		Control: client.ComplianceControl{
+			Id:        "UNMAPPED",
+			CatalogId: "UNMAPPED",
+			Category:  "UNCATEGORIZED",
+		},
+		Frameworks: client.ComplianceFrameworks{
+			Requirements: []string{},
+			Frameworks:   []string{},
+		},
+		EnrichmentStatus: client.Unmapped,
+	}
+}
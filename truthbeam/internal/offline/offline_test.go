@@ -0,0 +1,186 @@
+package offline
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/client"
+)
+
+// testdata/snapshot.json is written by `compass export` from a catalog
+// with controls AC-1 and AC-2, a YAML plan mapping rule-1 to AC-1 and a
+// JSON plan mapping rule-2 to AC-2, and signed with the Ed25519 key whose
+// public half is testdata/public.pem.
+const testSnapshot = "testdata/snapshot.json"
+
+// testEntry returns a snapshot with a single entry for policy.
+func testEntry(policy client.Policy, controlID string) client.Snapshot {
+	return client.Snapshot{
+		FormatVersion: 1,
+		Complete:      true,
+		Entries: []client.SnapshotEntry{
+			{
+				Policy: policy,
+				Compliance: client.Compliance{
+					Control:          client.ComplianceControl{Id: controlID, CatalogId: "test-catalog", Category: "Access Control"},
+					Frameworks:       client.ComplianceFrameworks{Requirements: []string{}, Frameworks: []string{}},
+					EnrichmentStatus: client.Success,
+				},
+			},
+		},
+	}
+}
+
+func writeSnapshot(t *testing.T, path string, snapshot client.Snapshot) {
+	t.Helper()
+	content, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	writeFile(t, path, string(content))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestSource_Snapshot(t *testing.T) {
+	publicKey, err := LoadPublicKey("testdata/public.pem")
+	require.NoError(t, err)
+	source, err := NewSource(testSnapshot, publicKey, zap.NewNop())
+	require.NoError(t, err)
+
+	known := client.Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	fromJSONPlan := client.Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-2"}
+	otherEngine := client.Policy{PolicyEngineName: "other-engine", PolicyRuleId: "rule-1"}
+	results := source.RetrieveAll(context.Background(), []client.Policy{known, fromJSONPlan, otherEngine})
+	require.Len(t, results, 3)
+
+	compliance := results[known].Compliance
+	require.NoError(t, results[known].Err)
+	assert.Equal(t, client.Success, compliance.EnrichmentStatus)
+	assert.Equal(t, "AC-1.1", compliance.Control.Id)
+	assert.Equal(t, "test-catalog", compliance.Control.CatalogId)
+	assert.Equal(t, "Access Control", compliance.Control.Category)
+	require.NotNil(t, compliance.Control.RemediationDescription)
+	assert.Equal(t, "Enable access control", *compliance.Control.RemediationDescription)
+	assert.Equal(t, []string{"AC-1(a)"}, compliance.Frameworks.Requirements)
+	assert.Equal(t, []string{"NIST-800-53"}, compliance.Frameworks.Frameworks)
+
+	assert.Equal(t, "AC-2.1", results[fromJSONPlan].Compliance.Control.Id)
+	assert.Equal(t, client.Unmapped, results[otherEngine].Compliance.EnrichmentStatus)
+}
+
+func TestSource_Verify(t *testing.T) {
+	publicKey, err := LoadPublicKey("testdata/public.pem")
+	require.NoError(t, err)
+	otherKey, err := LoadPublicKey("testdata/other.pem")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(testSnapshot)
+	require.NoError(t, err)
+	var signed client.Snapshot
+	require.NoError(t, json.Unmarshal(content, &signed))
+
+	dir := t.TempDir()
+	tampered := signed
+	tampered.Entries = append([]client.SnapshotEntry{}, signed.Entries...)
+	tampered.Entries[0].Compliance.Control.Id = "AC-2.1"
+	writeSnapshot(t, filepath.Join(dir, "tampered.json"), tampered)
+	unsigned := signed
+	unsigned.Signature = nil
+	writeSnapshot(t, filepath.Join(dir, "unsigned.json"), unsigned)
+
+	tests := []struct {
+		name      string
+		path      string
+		publicKey crypto.PublicKey
+		errMsg    string
+	}{
+		{name: "signed", path: testSnapshot, publicKey: publicKey},
+		{name: "signed without public key", path: testSnapshot},
+		{name: "unsigned without public key", path: filepath.Join(dir, "unsigned.json")},
+		{name: "unsigned", path: filepath.Join(dir, "unsigned.json"), publicKey: publicKey, errMsg: "not signed"},
+		{name: "other key", path: testSnapshot, publicKey: otherKey, errMsg: "expected key"},
+		{name: "tampered", path: filepath.Join(dir, "tampered.json"), publicKey: publicKey, errMsg: "signature is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSource(tt.path, tt.publicKey, zap.NewNop())
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestNewSource_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "snapshot.txt"), "")
+	writeFile(t, filepath.Join(dir, "snapshot.yaml"), "catalogs:\n  - catalog.yaml\n")
+	writeFile(t, filepath.Join(dir, "invalid.json"), "not json")
+
+	tests := []string{"snapshot.txt", "snapshot.yaml", "invalid.json", "missing.json"}
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewSource(filepath.Join(dir, name), nil, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadPublicKey_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "empty.pem"), "")
+
+	for _, path := range []string{filepath.Join(dir, "empty.pem"), filepath.Join(dir, "missing.pem"), testSnapshot} {
+		_, err := LoadPublicKey(path)
+		assert.Error(t, err)
+	}
+}
+
+func TestSource_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	first := client.Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-1"}
+	writeSnapshot(t, path, testEntry(first, "AC-1.1"))
+
+	source, err := NewSource(path, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		source.Watch(ctx, 10*time.Millisecond)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// A new snapshot is picked up.
+	policy := client.Policy{PolicyEngineName: "test-engine", PolicyRuleId: "rule-2"}
+	writeSnapshot(t, path, testEntry(policy, "AC-1.10"))
+	require.Eventually(t, func() bool {
+		result := source.RetrieveAll(context.Background(), []client.Policy{policy})[policy]
+		return result.Compliance.EnrichmentStatus == client.Success
+	}, time.Second, 10*time.Millisecond)
+
+	// A broken file keeps the previous entries.
+	writeFile(t, path, "{")
+	time.Sleep(50 * time.Millisecond)
+	result := source.RetrieveAll(context.Background(), []client.Policy{policy})[policy]
+	assert.Equal(t, client.Success, result.Compliance.EnrichmentStatus)
+}
@@ -0,0 +1,95 @@
+package offline
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/client"
+)
+
+// LoadPublicKey reads a PEM encoded PKIX public key to verify snapshots
+// signed by `compass export`.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// keyID returns the key ID compass records in snapshot signatures: the hex
+// encoded first 16 bytes of the SHA-256 digest of the DER encoded key.
+func keyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	digest := sha256.Sum256(der)
+	return hex.EncodeToString(digest[:16]), nil
+}
+
+// verify checks the signature of snapshot against pub. The signature
+// covers the snapshot serialized as JSON without its signature, as
+// compass signs it.
+func verify(snapshot client.Snapshot, pub crypto.PublicKey) error {
+	signature := snapshot.Signature
+	if signature == nil {
+		return errors.New("snapshot is not signed")
+	}
+
+	id, err := keyID(pub)
+	if err != nil {
+		return err
+	}
+	if signature.KeyId != id {
+		return fmt.Errorf("snapshot is signed with key %s, expected key %s", signature.KeyId, id)
+	}
+
+	snapshot.Signature = nil
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(payload)
+
+	var valid bool
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		valid = signature.Algorithm == client.Ed25519 && ed25519.Verify(key, payload, signature.Value)
+	case *ecdsa.PublicKey:
+		valid = signature.Algorithm == client.ES256 && ecdsa.VerifyASN1(key, digest[:], signature.Value)
+	case *rsa.PublicKey:
+		valid = signature.Algorithm == client.RS256 && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature.Value) == nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	if !valid {
+		return errors.New("snapshot signature is invalid")
+	}
+	return nil
+}
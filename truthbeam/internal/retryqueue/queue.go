@@ -0,0 +1,301 @@
+// Package retryqueue holds log records that could not be enriched in a
+// collector storage extension until they are retried.
+package retryqueue
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// DefaultInitialInterval is the default delay before the first retry of
+// a held record.
+const DefaultInitialInterval = 5 * time.Second
+
+// DefaultMaxInterval is the default upper bound of the retry backoff.
+const DefaultMaxInterval = 5 * time.Minute
+
+// DefaultMaxAge is the default time a record is held before it is
+// released marked as failed.
+const DefaultMaxAge = 24 * time.Hour
+
+// DefaultMaxItems is the default number of items held before the oldest
+// are dropped.
+const DefaultMaxItems = 10000
+
+// PageSize is the number of items read from storage by each call to Due.
+const PageSize = 100
+
+// Storage keys. Storage clients cannot list their keys, so items are
+// numbered and the range of numbers in use is stored alongside them.
+const (
+	headKey    = "head"
+	tailKey    = "tail"
+	itemPrefix = "item/"
+)
+
+// Item is a held batch of log records.
+type Item struct {
+	Seq         uint64
+	Logs        plog.Logs
+	Enqueued    time.Time
+	Attempts    int
+	NextAttempt time.Time
+}
+
+// storedItem is the persisted form of an Item.
+type storedItem struct {
+	Logs        []byte    `json:"logs"`
+	Enqueued    time.Time `json:"enqueued"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt"`
+}
+
+// schedule is the in-memory index of an item.
+type schedule struct {
+	seq         uint64
+	nextAttempt time.Time
+}
+
+// Queue is a durable queue of log records over a storage client. It is
+// safe for concurrent use.
+type Queue struct {
+	mu       sync.Mutex
+	client   storage.Client
+	maxItems int
+	tail     uint64
+	items    []schedule
+
+	marshaler   plog.ProtoMarshaler
+	unmarshaler plog.ProtoUnmarshaler
+}
+
+// Open loads the queue held in client. Once maxItems items are held, the
+// oldest are dropped to make room for new ones; 0 means no limit.
+func Open(ctx context.Context, client storage.Client, maxItems int) (*Queue, error) {
+	q := &Queue{client: client, maxItems: maxItems}
+
+	head, err := q.readCounter(ctx, headKey)
+	if err != nil {
+		return nil, err
+	}
+	tail, err := q.readCounter(ctx, tailKey)
+	if err != nil {
+		return nil, err
+	}
+	q.tail = tail
+
+	for seq := head; seq < tail; seq++ {
+		value, err := client.Get(ctx, itemKey(seq))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read retry queue item %d: %w", seq, err)
+		}
+		if value == nil {
+			continue
+		}
+		var stored storedItem
+		if err := json.Unmarshal(value, &stored); err != nil {
+			_ = client.Delete(ctx, itemKey(seq))
+			continue
+		}
+		q.items = append(q.items, schedule{seq: seq, nextAttempt: stored.NextAttempt})
+	}
+	return q, nil
+}
+
+// Len returns the number of held items.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Push holds logs, to be retried at nextAttempt. When the queue is full,
+// the oldest items are dropped first, and their number is returned.
+func (q *Queue) Push(ctx context.Context, logs plog.Logs, enqueued, nextAttempt time.Time) (int, error) {
+	payload, err := q.marshaler.MarshalLogs(logs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal held logs: %w", err)
+	}
+	value, err := json.Marshal(storedItem{Logs: payload, Enqueued: enqueued, NextAttempt: nextAttempt})
+	if err != nil {
+		return 0, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	seq := q.tail
+	ops := []*storage.Operation{
+		storage.SetOperation(itemKey(seq), value),
+		storage.SetOperation(tailKey, encodeCounter(seq+1)),
+	}
+	dropped := 0
+	if q.maxItems > 0 && len(q.items) >= q.maxItems {
+		dropped = len(q.items) - q.maxItems + 1
+		for _, item := range q.items[:dropped] {
+			ops = append(ops, storage.DeleteOperation(itemKey(item.seq)))
+		}
+		head := seq
+		if dropped < len(q.items) {
+			head = q.items[dropped].seq
+		}
+		ops = append(ops, storage.SetOperation(headKey, encodeCounter(head)))
+	}
+	if err := q.client.Batch(ctx, ops...); err != nil {
+		return 0, fmt.Errorf("failed to store held logs: %w", err)
+	}
+	q.tail = seq + 1
+	q.items = append(q.items[dropped:], schedule{seq: seq, nextAttempt: nextAttempt})
+	return dropped, nil
+}
+
+// Due returns up to limit items whose next attempt is not after now and
+// whose sequence number is at least from, oldest first, along with the
+// sequence number to continue from. When no items are left to read, the
+// returned sequence number is from. Items that cannot be decoded are
+// deleted and skipped.
+func (q *Queue) Due(ctx context.Context, now time.Time, from uint64, limit int) ([]Item, uint64, error) {
+	q.mu.Lock()
+	var due []uint64
+	for _, item := range q.items {
+		if len(due) == limit {
+			break
+		}
+		if item.seq >= from && !item.nextAttempt.After(now) {
+			due = append(due, item.seq)
+		}
+	}
+	q.mu.Unlock()
+
+	items := make([]Item, 0, len(due))
+	next := from
+	for _, seq := range due {
+		value, err := q.client.Get(ctx, itemKey(seq))
+		if err != nil {
+			return items, next, fmt.Errorf("failed to read retry queue item %d: %w", seq, err)
+		}
+		next = seq + 1
+		if value == nil {
+			continue
+		}
+		item, err := q.decode(seq, value)
+		if err != nil {
+			if err := q.Remove(ctx, seq); err != nil {
+				return items, next, err
+			}
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, next, nil
+}
+
+// decode reads a stored item.
+func (q *Queue) decode(seq uint64, value []byte) (Item, error) {
+	var stored storedItem
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return Item{}, err
+	}
+	logs, err := q.unmarshaler.UnmarshalLogs(stored.Logs)
+	if err != nil {
+		return Item{}, err
+	}
+	return Item{
+		Seq:         seq,
+		Logs:        logs,
+		Enqueued:    stored.Enqueued,
+		Attempts:    stored.Attempts,
+		NextAttempt: stored.NextAttempt,
+	}, nil
+}
+
+// Retry records a failed attempt of item and schedules the next one. Items
+// dropped or removed in the meantime are left alone.
+func (q *Queue) Retry(ctx context.Context, item Item, nextAttempt time.Time) error {
+	payload, err := q.marshaler.MarshalLogs(item.Logs)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(storedItem{
+		Logs:        payload,
+		Enqueued:    item.Enqueued,
+		Attempts:    item.Attempts + 1,
+		NextAttempt: nextAttempt,
+	})
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := sort.Search(len(q.items), func(i int) bool { return q.items[i].seq >= item.Seq })
+	if i == len(q.items) || q.items[i].seq != item.Seq {
+		return nil
+	}
+	if err := q.client.Set(ctx, itemKey(item.Seq), value); err != nil {
+		return fmt.Errorf("failed to update retry queue item %d: %w", item.Seq, err)
+	}
+	q.items[i].nextAttempt = nextAttempt
+	return nil
+}
+
+// Remove deletes a released item.
+func (q *Queue) Remove(ctx context.Context, seq uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := sort.Search(len(q.items), func(i int) bool { return q.items[i].seq >= seq })
+	if i == len(q.items) || q.items[i].seq != seq {
+		return nil
+	}
+	q.items = append(q.items[:i], q.items[i+1:]...)
+
+	head := q.tail
+	if len(q.items) > 0 {
+		head = q.items[0].seq
+	}
+	return q.client.Batch(ctx,
+		storage.DeleteOperation(itemKey(seq)),
+		storage.SetOperation(headKey, encodeCounter(head)),
+	)
+}
+
+func (q *Queue) readCounter(ctx context.Context, key string) (uint64, error) {
+	value, err := q.client.Get(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read retry queue %s: %w", key, err)
+	}
+	if len(value) != 8 {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
+func encodeCounter(n uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, n)
+}
+
+func itemKey(seq uint64) string {
+	return itemPrefix + strconv.FormatUint(seq, 10)
+}
+
+// Backoff returns the delay before the attempt after attempts failed ones,
+// doubling from initial up to max.
+func Backoff(attempts int, initial, max time.Duration) time.Duration {
+	delay := initial
+	for i := 0; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,224 @@
+package retryqueue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// memoryStorage is a storage client that keeps its data in a map, like a
+// file_storage database that survives restarts.
+type memoryStorage struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{data: make(map[string][]byte)}
+}
+
+func (m *memoryStorage) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[key], nil
+}
+
+func (m *memoryStorage) Set(_ context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func (m *memoryStorage) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memoryStorage) Batch(ctx context.Context, ops ...*storage.Operation) error {
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value, _ = m.Get(ctx, op.Key)
+		case storage.Set:
+			_ = m.Set(ctx, op.Key, op.Value)
+		case storage.Delete:
+			_ = m.Delete(ctx, op.Key)
+		}
+	}
+	return nil
+}
+
+func (m *memoryStorage) Close(context.Context) error { return nil }
+
+func testLogs(body string) plog.Logs {
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(body)
+	return logs
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryStorage()
+	queue, err := Open(ctx, backend, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, queue.Len())
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = queue.Push(ctx, testLogs("first"), now, now.Add(time.Minute))
+	require.NoError(t, err)
+	_, err = queue.Push(ctx, testLogs("second"), now, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, queue.Len())
+
+	due, _, err := queue.Due(ctx, now, 0, PageSize)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	due, _, err = queue.Due(ctx, now.Add(time.Minute), 0, PageSize)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	first := due[0]
+	assert.Equal(t, "first", first.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.True(t, first.Enqueued.Equal(now))
+	assert.Equal(t, 0, first.Attempts)
+
+	require.NoError(t, queue.Retry(ctx, first, now.Add(2*time.Hour)))
+	due, _, err = queue.Due(ctx, now.Add(time.Hour), 0, PageSize)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "second", due[0].Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+
+	// The queue survives a restart.
+	reopened, err := Open(ctx, backend, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.Len())
+	due, _, err = reopened.Due(ctx, now.Add(2*time.Hour), 0, PageSize)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, 1, due[0].Attempts)
+
+	require.NoError(t, reopened.Remove(ctx, due[0].Seq))
+	require.NoError(t, reopened.Remove(ctx, due[1].Seq))
+	assert.Equal(t, 0, reopened.Len())
+
+	reopened, err = Open(ctx, backend, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, reopened.Len())
+	_, err = reopened.Push(ctx, testLogs("third"), now, now)
+	require.NoError(t, err)
+	due, _, err = reopened.Due(ctx, now, 0, PageSize)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, uint64(2), due[0].Seq)
+}
+
+func TestQueue_Due(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryStorage()
+	queue, err := Open(ctx, backend, 0)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, body := range []string{"first", "corrupt", "third", "fourth"} {
+		_, err = queue.Push(ctx, testLogs(body), now, now)
+		require.NoError(t, err)
+	}
+	require.NoError(t, backend.Set(ctx, itemKey(1), []byte("not json")))
+
+	// Items are read in pages, and corrupt items are deleted and skipped
+	// without failing the page.
+	due, next, err := queue.Due(ctx, now, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, uint64(0), due[0].Seq)
+	assert.Equal(t, uint64(2), next)
+	assert.NotContains(t, backend.data, itemKey(1))
+	assert.Equal(t, 3, queue.Len())
+
+	due, next, err = queue.Due(ctx, now, next, 2)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, uint64(2), due[0].Seq)
+	assert.Equal(t, uint64(3), due[1].Seq)
+
+	due, last, err := queue.Due(ctx, now, next, 2)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	assert.Equal(t, next, last, "no items are left to read")
+}
+
+func TestQueue_MaxItems(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryStorage()
+	queue, err := Open(ctx, backend, 2)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, body := range []string{"first", "second"} {
+		dropped, err := queue.Push(ctx, testLogs(body), now, now)
+		require.NoError(t, err)
+		assert.Zero(t, dropped)
+	}
+
+	// A full queue drops its oldest item, also after a restart.
+	dropped, err := queue.Push(ctx, testLogs("third"), now, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 2, queue.Len())
+	assert.NotContains(t, backend.data, itemKey(0))
+
+	reopened, err := Open(ctx, backend, 2)
+	require.NoError(t, err)
+	due, _, err := reopened.Due(ctx, now, 0, PageSize)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, "second", due[0].Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.Equal(t, "third", due[1].Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+}
+
+func TestQueue_RetryDropped(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryStorage()
+	queue, err := Open(ctx, backend, 1)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = queue.Push(ctx, testLogs("first"), now, now)
+	require.NoError(t, err)
+	due, _, err := queue.Due(ctx, now, 0, PageSize)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+
+	// The item is dropped while its retry is in progress, and is not
+	// written back below the head of the queue.
+	dropped, err := queue.Push(ctx, testLogs("second"), now, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	require.NoError(t, queue.Retry(ctx, due[0], now.Add(time.Minute)))
+	assert.NotContains(t, backend.data, itemKey(due[0].Seq))
+	assert.Equal(t, 1, queue.Len())
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Second},
+		{attempts: 1, want: 2 * time.Second},
+		{attempts: 3, want: 8 * time.Second},
+		{attempts: 10, want: time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Backoff(tt.attempts, time.Second, time.Minute), "attempts %d", tt.attempts)
+	}
+}
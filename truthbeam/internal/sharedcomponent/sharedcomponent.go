@@ -0,0 +1,99 @@
+// Package sharedcomponent lets the pipeline instances of a component share
+// one underlying component, so they share its caches, background tasks,
+// metrics and storage clients.
+package sharedcomponent
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Map keeps the components created for each key, such as a component
+// configuration.
+type Map[K comparable, V component.Component] struct {
+	mu         sync.Mutex
+	components map[K]*Component[V]
+}
+
+// NewMap creates an empty Map.
+func NewMap[K comparable, V component.Component]() *Map[K, V] {
+	return &Map[K, V]{components: make(map[K]*Component[V])}
+}
+
+// LoadOrStore returns the component created for key, creating it with
+// create if there is none. Every call takes a reference to the component,
+// which is released by a call to Shutdown.
+func (m *Map[K, V]) LoadOrStore(key K, create func() (V, error)) (*Component[V], error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.components[key]; ok {
+		c.refs++
+		return c, nil
+	}
+
+	created, err := create()
+	if err != nil {
+		return nil, err
+	}
+	c := &Component[V]{component: created, refs: 1}
+	c.remove = func() {
+		delete(m.components, key)
+	}
+	c.mu = &m.mu
+	m.components[key] = c
+	return c, nil
+}
+
+// Load returns the component created for key without taking a reference.
+func (m *Map[K, V]) Load(key K) (*Component[V], bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.components[key]
+	return c, ok
+}
+
+// Component starts a shared component on the first call to Start, and
+// shuts it down once every pipeline that took a reference to it has shut
+// down. It is then removed from its Map.
+type Component[V component.Component] struct {
+	component V
+
+	startOnce sync.Once
+	// mu is the lock of the Map, which guards refs and remove.
+	mu     *sync.Mutex
+	refs   int
+	remove func()
+}
+
+// Unwrap returns the shared component.
+func (c *Component[V]) Unwrap() V {
+	return c.component
+}
+
+// Start starts the component on the first call.
+func (c *Component[V]) Start(ctx context.Context, host component.Host) error {
+	var err error
+	c.startOnce.Do(func() {
+		err = c.component.Start(ctx, host)
+	})
+	return err
+}
+
+// Shutdown releases a reference to the component, and shuts it down when
+// it was the last one.
+func (c *Component[V]) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.refs--
+	last := c.refs == 0
+	if last {
+		c.remove()
+	}
+	c.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+	return c.component.Shutdown(ctx)
+}
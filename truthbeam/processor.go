@@ -2,19 +2,31 @@ package truthbeam
 
 import (
 	"context"
+	"crypto"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 
 	"github.com/complytime/complybeacon/truthbeam/internal/applier"
 	"github.com/complytime/complybeacon/truthbeam/internal/client"
+	"github.com/complytime/complybeacon/truthbeam/internal/offline"
+	"github.com/complytime/complybeacon/truthbeam/internal/retryqueue"
 )
 
 type truthBeamProcessor struct {
+	id        component.ID
 	telemetry component.TelemetrySettings
 	config    *Config
 
@@ -22,6 +34,36 @@ type truthBeamProcessor struct {
 
 	client  *client.CacheableClient
 	applier *applier.Applier
+	// backends holds a client per named Compass backend when backends are
+	// configured, in place of client.
+	backends map[string]*client.CacheableClient
+	// snapshot resolves lookups in-process when offline mode is
+	// configured, in place of client.
+	snapshot *offline.Source
+
+	// backgroundCtx is cancelled on shutdown to stop the background
+	// tasks, such as watchers and warmup, tracked by background.
+	backgroundCtx  context.Context
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
+
+	// storageClient backs the persistent cache, and redisClient the
+	// shared cache, if configured.
+	storageClient storage.Client
+	redisClient   *redis.Client
+
+	// logsPipelines lists the next consumers of the logs pipelines that
+	// share the processor. next, the consumer of the only one, receives
+	// log records released from queue, which holds the records that could
+	// not be enriched when fail-closed mode is enabled. queueClient is the
+	// storage client backing queue.
+	logsPipelines []consumer.Logs
+	next          consumer.Logs
+	queue         *retryqueue.Queue
+	queueClient   storage.Client
+
+	metrics               *processorMetrics
+	telemetryRegistration metric.Registration
 }
 
 func newTruthBeamProcessor(conf component.Config, set processor.Settings) (*truthBeamProcessor, error) {
@@ -30,16 +72,82 @@ func newTruthBeamProcessor(conf component.Config, set processor.Settings) (*trut
 		return nil, errors.New("invalid configuration provided")
 	}
 
+	metrics, err := newProcessorMetrics(set.MeterProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	applierOpts := []applier.Option{
+		applier.WithExtraction(cfg.Extraction),
+		applier.WithStatusMapping(cfg.StatusMapping),
+		applier.WithOCSFBody(cfg.OCSFBody),
+	}
+	if cfg.Severity.Enabled {
+		applierOpts = append(applierOpts, applier.WithSeverity(cfg.Severity.Matrix, cfg.Severity.OriginalAttribute))
+	}
+
+	// The start context is only valid during start, so background tasks
+	// get their own context that is cancelled on shutdown.
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+
 	return &truthBeamProcessor{
-		config:    cfg,
-		telemetry: set.TelemetrySettings,
-		logger:    set.Logger,
-		client:    nil,
-		applier:   applier.NewApplier(set.Logger),
+		id:             set.ID,
+		backgroundCtx:  backgroundCtx,
+		stopBackground: stopBackground,
+		config:         cfg,
+		telemetry:      set.TelemetrySettings,
+		metrics:        metrics,
+		logger:         set.Logger,
+		client:         nil,
+		applier:        applier.NewApplier(set.Logger, applierOpts...),
 	}, nil
 }
 
+// addLogsPipeline registers the next consumer of a logs pipeline that
+// uses the processor.
+func (t *truthBeamProcessor) addLogsPipeline(next consumer.Logs) {
+	t.logsPipelines = append(t.logsPipelines, next)
+}
+
+// pendingRecord is a log record, span or span event waiting for its
+// enrichment from the Compass backend named backend. logRecord and
+// position are set for log records.
+type pendingRecord struct {
+	attributes pcommon.Map
+	logRecord  *plog.LogRecord
+	position   recordPosition
+	backend    string
+	policy     client.Policy
+	result     string
+}
+
+// recordPosition locates a log record in a batch by resource, scope and
+// record index.
+type recordPosition struct {
+	resource, scope, record int
+}
+
+// failedRecord is a record that could not be enriched.
+type failedRecord struct {
+	pendingRecord
+	err error
+}
+
 func (t *truthBeamProcessor) processLogs(ctx context.Context, ld plog.Logs) (plog.Logs, error) {
+	failed := t.enrich(ctx, t.collectLogs(ctx, ld))
+	if t.queue != nil {
+		failed = t.hold(ctx, ld, failed)
+	}
+	t.fail(ctx, failed)
+	return ld, nil
+}
+
+// collectLogs returns the records of ld to enrich, marking those whose
+// lookup fields cannot be extracted. Records are collected first so each
+// distinct policy is resolved once per batch.
+func (t *truthBeamProcessor) collectLogs(ctx context.Context, ld plog.Logs) []pendingRecord {
+	var pending []pendingRecord
+
 	allResourceLogs := ld.ResourceLogs()
 	for i := 0; i < allResourceLogs.Len(); i++ {
 		resourceLogs := allResourceLogs.At(i)
@@ -49,54 +157,647 @@ func (t *truthBeamProcessor) processLogs(ctx context.Context, ld plog.Logs) (plo
 			logRecords := scopeLogs.LogRecords()
 			for k := 0; k < logRecords.Len(); k++ {
 				logRecord := logRecords.At(k)
+				record := pendingRecord{
+					attributes: logRecord.Attributes(),
+					logRecord:  &logRecord,
+					position:   recordPosition{resource: i, scope: j, record: k},
+				}
 
-				policy, status, err := t.applier.Extract(logRecord)
+				policy, status, err := t.applier.ExtractFrom(resourceLogs.Resource(), scopeLogs.Scope(), logRecord)
 				if err != nil {
 					t.logger.Error("Failed to extract evidence from log record", zap.Error(err))
+					t.skip(ctx, record, err)
 					continue
 				}
 
-				// Get cached data
-				enrichment, err := t.client.Retrieve(ctx, policy)
-				if err != nil {
-					// We don't want to return an error here to ensure the evidence
-					// is not dropped. It will just be unmapped.
+				record.policy, record.result = policy, status
+				record.backend = t.backendFor(resourceLogs.Resource())
+				pending = append(pending, record)
+			}
+		}
+	}
+	return pending
+}
 
-					t.logger.Error("failed to get enrichment",
-						zap.String("policy_id", policy.PolicyRuleId),
-						zap.Error(err))
-					continue
-				}
+// processTraces enriches the spans and span events that carry evidence.
+// Unlike log records, spans without any of the lookup fields are ordinary
+// traces and are passed through unmarked.
+func (t *truthBeamProcessor) processTraces(ctx context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	var pending []pendingRecord
 
-				err = t.applier.Apply(logRecord, enrichment, status)
-				if err != nil {
-					t.logger.Error("failed to apply enrichment",
-						zap.String("policy_id", policy.PolicyRuleId),
-						zap.Error(err))
+	collect := func(resource pcommon.Resource, scope pcommon.InstrumentationScope, attrs pcommon.Map) {
+		// Most spans are not evidence and pass through unmarked.
+		if !t.applier.IsEvidence(attrs) {
+			return
+		}
+		policy, status, err := t.applier.ExtractAttributes(resource, scope, attrs)
+		if err != nil {
+			t.logger.Error("Failed to extract evidence from span", zap.Error(err))
+			t.skip(ctx, pendingRecord{attributes: attrs}, err)
+			return
+		}
+		pending = append(pending, pendingRecord{attributes: attrs, backend: t.backendFor(resource), policy: policy, result: status})
+	}
+
+	allResourceSpans := td.ResourceSpans()
+	for i := 0; i < allResourceSpans.Len(); i++ {
+		resourceSpans := allResourceSpans.At(i)
+		resourceScopeSpans := resourceSpans.ScopeSpans()
+		for j := 0; j < resourceScopeSpans.Len(); j++ {
+			scopeSpans := resourceScopeSpans.At(j)
+			spans := scopeSpans.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				collect(resourceSpans.Resource(), scopeSpans.Scope(), span.Attributes())
+
+				events := span.Events()
+				for l := 0; l < events.Len(); l++ {
+					collect(resourceSpans.Resource(), scopeSpans.Scope(), events.At(l).Attributes())
 				}
 			}
 		}
 	}
-	return ld, nil
+
+	t.fail(ctx, t.enrich(ctx, pending))
+	return td, nil
+}
+
+// skip marks a record whose lookup fields could not be extracted.
+func (t *truthBeamProcessor) skip(ctx context.Context, record pendingRecord, err error) {
+	t.applyFailure(record, client.Skipped, err)
+	t.metrics.recordExtractionFailure(ctx, err)
+	t.metrics.recordEnrichment(ctx, client.Skipped)
+}
+
+// applyFailure marks a record that could not be enriched.
+func (t *truthBeamProcessor) applyFailure(record pendingRecord, enrichmentStatus client.ComplianceEnrichmentStatus, reason error) {
+	if record.logRecord != nil {
+		t.applier.ApplyFailure(*record.logRecord, record.policy, enrichmentStatus, record.result, reason)
+		return
+	}
+	t.applier.ApplyFailureAttributes(record.attributes, record.policy, enrichmentStatus, record.result, reason)
+}
+
+// enrich resolves the policies of the pending records and applies the
+// results. Records that could not be enriched are returned unmarked.
+func (t *truthBeamProcessor) enrich(ctx context.Context, pending []pendingRecord) []failedRecord {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	policies := make(map[string][]client.Policy)
+	for _, record := range pending {
+		policies[record.backend] = append(policies[record.backend], record.policy)
+	}
+	results := make(map[string]map[client.Policy]client.Result, len(policies))
+	for backend, backendPolicies := range policies {
+		results[backend] = t.retrieveAll(ctx, backend, backendPolicies)
+	}
+
+	var failed []failedRecord
+	for _, record := range pending {
+		enrichment := results[record.backend][record.policy]
+		if enrichment.Err != nil {
+			failed = append(failed, failedRecord{pendingRecord: record, err: enrichment.Err})
+			continue
+		}
+		t.metrics.recordEnrichment(ctx, enrichment.Compliance.EnrichmentStatus)
+
+		var err error
+		if record.logRecord != nil {
+			err = t.applier.Apply(*record.logRecord, record.policy, enrichment.Compliance, record.result)
+		} else {
+			err = t.applier.ApplyAttributes(record.attributes, record.policy, enrichment.Compliance, record.result)
+		}
+		if err != nil {
+			t.logger.Error("failed to apply enrichment",
+				zap.String("policy_id", record.policy.PolicyRuleId),
+				zap.Error(err))
+		}
+	}
+	return failed
+}
+
+// hold moves the records of ld that could not be enriched because Compass
+// was unavailable to the retry queue. Records that failed for good, such
+// as on a client error, or could not be queued are returned to be passed
+// through as failed.
+func (t *truthBeamProcessor) hold(ctx context.Context, ld plog.Logs, failed []failedRecord) []failedRecord {
+	now := time.Now()
+	nextAttempt := now.Add(t.config.FailClosed.InitialInterval)
+
+	var unheld []failedRecord
+	held := make(map[recordPosition]bool, len(failed))
+	for _, record := range failed {
+		if !client.Transient(record.err) {
+			unheld = append(unheld, record)
+			continue
+		}
+		dropped, err := t.queue.Push(ctx, singleRecord(ld, record.position), now, nextAttempt)
+		if err != nil {
+			t.logger.Error("failed to hold record", zap.Error(err))
+			unheld = append(unheld, record)
+			continue
+		}
+		if dropped > 0 {
+			t.logger.Warn("retry queue is full; dropped the oldest held records",
+				zap.Int("dropped", dropped),
+				zap.Int("max_items", t.config.FailClosed.MaxItems),
+			)
+			t.metrics.recordHeldDropped(ctx, dropped)
+		}
+		held[record.position] = true
+	}
+	if len(held) == 0 {
+		return unheld
+	}
+
+	t.logger.Debug("holding records until they are enriched", zap.Int("records", len(held)))
+	allResourceLogs := ld.ResourceLogs()
+	for i := 0; i < allResourceLogs.Len(); i++ {
+		resourceScopeLogs := allResourceLogs.At(i).ScopeLogs()
+		for j := 0; j < resourceScopeLogs.Len(); j++ {
+			k := 0
+			resourceScopeLogs.At(j).LogRecords().RemoveIf(func(plog.LogRecord) bool {
+				remove := held[recordPosition{resource: i, scope: j, record: k}]
+				k++
+				return remove
+			})
+		}
+	}
+	return unheld
+}
+
+// singleRecord copies the log record at position in ld, with its resource
+// and scope, into a batch of its own.
+func singleRecord(ld plog.Logs, position recordPosition) plog.Logs {
+	resourceLogs := ld.ResourceLogs().At(position.resource)
+	scopeLogs := resourceLogs.ScopeLogs().At(position.scope)
+
+	single := plog.NewLogs()
+	destResource := single.ResourceLogs().AppendEmpty()
+	resourceLogs.Resource().CopyTo(destResource.Resource())
+	destResource.SetSchemaUrl(resourceLogs.SchemaUrl())
+	destScope := destResource.ScopeLogs().AppendEmpty()
+	scopeLogs.Scope().CopyTo(destScope.Scope())
+	destScope.SetSchemaUrl(scopeLogs.SchemaUrl())
+	scopeLogs.LogRecords().At(position.record).CopyTo(destScope.LogRecords().AppendEmpty())
+	return single
+}
+
+// retryHeld retries the held records due at now, reading them from
+// storage in pages. Enriched records are released downstream. Records
+// held for longer than the maximum age are released marked as Unknown, and
+// the others are retried later.
+func (t *truthBeamProcessor) retryHeld(ctx context.Context, now time.Time) {
+	for from := uint64(0); ; {
+		items, next, err := t.queue.Due(ctx, now, from, retryqueue.PageSize)
+		if err != nil {
+			t.logger.Error("failed to read held records", zap.Error(err))
+		}
+		for _, item := range items {
+			t.retryItem(ctx, item, now)
+		}
+		if err != nil || next == from {
+			return
+		}
+		from = next
+	}
+}
+
+// retryItem retries a held item and releases it once enriched or too old.
+func (t *truthBeamProcessor) retryItem(ctx context.Context, item retryqueue.Item, now time.Time) {
+	failed := t.enrich(ctx, t.collectLogs(ctx, item.Logs))
+	if len(failed) > 0 {
+		if now.Sub(item.Enqueued) < t.config.FailClosed.MaxAge {
+			t.reschedule(ctx, item, now)
+			return
+		}
+		for i := range failed {
+			failed[i].err = fmt.Errorf("not enriched within %s: %w", t.config.FailClosed.MaxAge, failed[i].err)
+		}
+		t.fail(ctx, failed)
+	}
+
+	if err := t.next.ConsumeLogs(ctx, item.Logs); err != nil {
+		t.logger.Error("failed to release held records", zap.Error(err))
+		t.reschedule(ctx, item, now)
+		return
+	}
+	if err := t.queue.Remove(ctx, item.Seq); err != nil {
+		t.logger.Error("failed to remove released records", zap.Error(err))
+	}
+}
+
+// reschedule schedules the next retry of item with backoff.
+func (t *truthBeamProcessor) reschedule(ctx context.Context, item retryqueue.Item, now time.Time) {
+	delay := retryqueue.Backoff(item.Attempts+1, t.config.FailClosed.InitialInterval, t.config.FailClosed.MaxInterval)
+	if err := t.queue.Retry(ctx, item, now.Add(delay)); err != nil {
+		t.logger.Error("failed to reschedule held records", zap.Error(err))
+	}
+}
+
+// fail marks records that could not be enriched as Unknown.
+func (t *truthBeamProcessor) fail(ctx context.Context, failed []failedRecord) {
+	for _, record := range failed {
+		// We don't want to return an error here to ensure the evidence
+		// is not dropped. It is passed through marked as Unknown. An
+		// open breaker is logged once by the client, not per record.
+		if !errors.Is(record.err, client.ErrCircuitOpen) {
+			t.logger.Error("failed to get enrichment",
+				zap.String("policy_id", record.policy.PolicyRuleId),
+				zap.Error(record.err))
+		}
+		t.applyFailure(record.pendingRecord, client.Unknown, record.err)
+		t.metrics.recordEnrichment(ctx, client.Unknown)
+	}
+}
+
+// retrieveAll resolves policies from the offline snapshot when one is
+// configured, or from the named Compass backend.
+func (t *truthBeamProcessor) retrieveAll(ctx context.Context, backend string, policies []client.Policy) map[client.Policy]client.Result {
+	if t.snapshot != nil {
+		return t.snapshot.RetrieveAll(ctx, policies)
+	}
+	if t.backends == nil {
+		return t.client.RetrieveAll(ctx, policies)
+	}
+
+	if backendClient, ok := t.backends[backend]; ok {
+		return backendClient.RetrieveAll(ctx, policies)
+	}
+	err := fmt.Errorf("unknown compass backend %q", backend)
+	if backend == "" {
+		err = fmt.Errorf("no compass backend: resource attribute %q is not set and there is no default_backend", t.config.BackendAttribute)
+	}
+	results := make(map[client.Policy]client.Result, len(policies))
+	for _, policy := range policies {
+		results[policy] = client.Result{Err: err}
+	}
+	return results
 }
 
-// start will add HTTP client and pre-fetch any policy data
-func (t *truthBeamProcessor) start(ctx context.Context, host component.Host) error {
-	httpClient, err := t.config.ClientConfig.ToClient(ctx, host.GetExtensions(), t.telemetry)
+// backendFor returns the name of the Compass backend of records from
+// resource: the value of the backend attribute, or the default backend
+// when it is not set. Records naming an unknown backend are not sent to
+// the default one, so tenants never share mappings.
+func (t *truthBeamProcessor) backendFor(resource pcommon.Resource) string {
+	if t.backends == nil {
+		return ""
+	}
+	if t.config.BackendAttribute != "" {
+		if value, ok := resource.Attributes().Get(t.config.BackendAttribute); ok && value.AsString() != "" {
+			return value.AsString()
+		}
+	}
+	return t.config.DefaultBackend
+}
+
+// Start will add HTTP client and pre-fetch any policy data
+func (t *truthBeamProcessor) Start(ctx context.Context, host component.Host) error {
+	// Only logs pipelines hold records; traces pass through as Unknown.
+	// Stored records do not say which pipeline they came from, so they
+	// can only be released to the right one when there is one.
+	if t.config.FailClosed.Enabled && len(t.logsPipelines) > 0 {
+		if len(t.logsPipelines) > 1 {
+			return fmt.Errorf("fail_closed needs a separate component per logs pipeline, but %s is used in %d", t.id, len(t.logsPipelines))
+		}
+		t.next = t.logsPipelines[0]
+		if err := t.startQueue(ctx, host); err != nil {
+			return err
+		}
+	}
+
+	if t.config.Snapshot.Path != "" {
+		return t.startOffline()
+	}
+
+	opts := []client.Option{
+		client.WithBatchSize(t.config.BatchSize),
+		client.WithMaxConcurrency(t.config.MaxConcurrency),
+		client.WithUnmappedTTL(t.config.UnmappedCacheTTL),
+		client.WithErrorTTL(t.config.ErrorCacheTTL),
+		client.WithCallObserver(t.metrics.observeCompassCall),
+	}
+	if t.config.Retry.Enabled {
+		opts = append(opts, client.WithRetry(client.RetryPolicy{
+			MaxRetries:      t.config.Retry.MaxRetries,
+			InitialInterval: t.config.Retry.InitialInterval,
+			MaxInterval:     t.config.Retry.MaxInterval,
+		}))
+	}
+	if t.config.StaleWhileRevalidate.Enabled {
+		opts = append(opts, client.WithStaleWhileRevalidate(t.config.StaleWhileRevalidate.MaxStaleness))
+	}
+	if t.config.Storage != nil {
+		l2, err := t.persistentCache(ctx, host)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, client.WithBackingCache(l2))
+	}
+	if t.config.Redis.Endpoint != "" {
+		shared, err := t.sharedCache(ctx)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, client.WithBackingCache(shared))
+	}
+
+	// Every backend has its own in-memory cache and circuit breaker. The
+	// backing caches are shared, with keys and generations partitioned by
+	// backend name, so one backend's reset does not clear the others.
+	clients := make(map[string]*client.CacheableClient)
+	if len(t.config.Backends) == 0 {
+		httpClient, err := t.config.ClientConfig.ToClient(ctx, host.GetExtensions(), t.telemetry)
+		if err != nil {
+			return err
+		}
+		baseClient, err := client.NewClient(t.config.ClientConfig.Endpoint, client.WithHTTPClient(httpClient))
+		if err != nil {
+			return err
+		}
+		cacheableClient, err := t.newCacheableClient(baseClient, opts)
+		if err != nil {
+			return err
+		}
+		t.client = cacheableClient
+		clients[""] = cacheableClient
+	} else {
+		t.backends = make(map[string]*client.CacheableClient, len(t.config.Backends))
+		for name, backend := range t.config.Backends {
+			httpClient, err := backend.ClientConfig.ToClient(ctx, host.GetExtensions(), t.telemetry)
+			if err != nil {
+				return fmt.Errorf("backend %q: %w", name, err)
+			}
+			baseClient, err := client.NewFailoverClient(backend.Endpoints, httpClient)
+			if err != nil {
+				return fmt.Errorf("backend %q: %w", name, err)
+			}
+			backendOpts := append([]client.Option{client.WithPartition(name)}, opts...)
+			cacheableClient, err := t.newCacheableClient(baseClient, backendOpts)
+			if err != nil {
+				return fmt.Errorf("backend %q: %w", name, err)
+			}
+			t.backends[name] = cacheableClient
+			clients[name] = cacheableClient
+		}
+	}
+
+	registration, err := registerTelemetry(t.telemetry.MeterProvider, clients)
 	if err != nil {
 		return err
 	}
+	t.telemetryRegistration = registration
+
+	for _, cacheableClient := range clients {
+		if t.config.ChangeFeed.Enabled {
+			t.goBackground(func(ctx context.Context) {
+				cacheableClient.WatchChanges(ctx, t.config.ChangeFeed.Wait, t.config.ChangeFeed.RetryInterval)
+			})
+		}
+
+		if t.config.Warmup.Enabled {
+			if t.config.Warmup.Background {
+				t.goBackground(func(ctx context.Context) {
+					t.warmup(ctx, cacheableClient)
+				})
+			} else {
+				t.warmup(ctx, cacheableClient)
+			}
+		}
+	}
+
+	return nil
+}
+
+// newCacheableClient wraps baseClient in a cache configured from opts and
+// the processor config.
+func (t *truthBeamProcessor) newCacheableClient(baseClient *client.Client, opts []client.Option) (*client.CacheableClient, error) {
+	if t.config.CircuitBreaker.Enabled {
+		opts = append(opts, client.WithCircuitBreaker(t.config.CircuitBreaker.FailureThreshold, t.config.CircuitBreaker.OpenDuration))
+	}
+	cacheableClient, err := client.NewCacheableClient(baseClient, t.logger, t.config.CacheTTL, t.config.CacheCapacity, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cacheable client: %w", err)
+	}
+	return cacheableClient, nil
+}
+
+// persistentCache opens the configured storage extension as the
+// persistent cache layer.
+func (t *truthBeamProcessor) persistentCache(ctx context.Context, host component.Host) (client.Cache, error) {
+	storageClient, err := t.getStorageClient(ctx, host, *t.config.Storage, "cache")
+	if err != nil {
+		return nil, err
+	}
+	l2, err := client.NewStorageStore(ctx, storageClient, t.config.CacheTTL)
+	if err != nil {
+		_ = storageClient.Close(ctx)
+		return nil, err
+	}
+	t.storageClient = storageClient
+
+	if sweeper, ok := l2.(client.Sweeper); ok {
+		t.goBackground(func(ctx context.Context) {
+			t.sweep(ctx, sweeper)
+		})
+	}
+	return l2, nil
+}
+
+// sweep removes expired entries from the persistent cache every
+// client.DefaultStorageSweepInterval, and once more on shutdown so the
+// stored key index is current.
+func (t *truthBeamProcessor) sweep(ctx context.Context, sweeper client.Sweeper) {
+	ticker := time.NewTicker(client.DefaultStorageSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if _, err := sweeper.Sweep(context.WithoutCancel(ctx)); err != nil {
+				t.logger.Warn("failed to sweep the persistent cache", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+		}
+		removed, err := sweeper.Sweep(ctx)
+		if err != nil {
+			t.logger.Warn("failed to sweep the persistent cache", zap.Error(err))
+			continue
+		}
+		t.logger.Debug("persistent cache swept", zap.Int("removed", removed))
+	}
+}
 
-	baseClient, err := client.NewClient(t.config.ClientConfig.Endpoint, client.WithHTTPClient(httpClient))
+// startQueue opens the retry queue in the fail-closed storage extension
+// and retries the held records in the background.
+func (t *truthBeamProcessor) startQueue(ctx context.Context, host component.Host) error {
+	queueClient, err := t.getStorageClient(ctx, host, *t.config.FailClosed.Storage, "retry_queue")
 	if err != nil {
 		return err
 	}
+	queue, err := retryqueue.Open(ctx, queueClient, t.config.FailClosed.MaxItems)
+	if err != nil {
+		_ = queueClient.Close(ctx)
+		return err
+	}
+	t.queue, t.queueClient = queue, queueClient
+	if held := queue.Len(); held > 0 {
+		t.logger.Info("resuming retries of held records", zap.Int("held", held))
+	}
+
+	t.goBackground(func(ctx context.Context) {
+		ticker := time.NewTicker(t.config.FailClosed.InitialInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.retryHeld(ctx, time.Now())
+			}
+		}
+	})
+	return nil
+}
+
+// getStorageClient returns a client of the storage extension id.
+func (t *truthBeamProcessor) getStorageClient(ctx context.Context, host component.Host, id component.ID, name string) (storage.Client, error) {
+	ext, ok := host.GetExtensions()[id]
+	if !ok {
+		return nil, fmt.Errorf("storage extension %s not found", id)
+	}
+	storageExt, ok := ext.(storage.Extension)
+	if !ok {
+		return nil, fmt.Errorf("extension %s is not a storage extension", id)
+	}
 
-	cacheableClient, err := client.NewCacheableClient(baseClient, t.logger, t.config.CacheTTL, t.config.CacheCapacity)
+	storageClient, err := storageExt.GetClient(ctx, component.KindProcessor, t.id, name)
 	if err != nil {
-		return fmt.Errorf("failed to create cacheable client: %w", err)
+		return nil, fmt.Errorf("failed to get storage client: %w", err)
 	}
-	t.client = cacheableClient
+	return storageClient, nil
+}
+
+// sharedCache connects the Redis cache shared across the fleet. The
+// connection is made lazily, so an unavailable server does not fail start.
+func (t *truthBeamProcessor) sharedCache(ctx context.Context) (client.Cache, error) {
+	cfg := t.config.Redis
+	options := &redis.Options{
+		Addr:         cfg.Endpoint,
+		Username:     cfg.Username,
+		Password:     string(cfg.Password),
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.Timeout,
+		ReadTimeout:  cfg.Timeout,
+		WriteTimeout: cfg.Timeout,
+	}
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.LoadTLSConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis TLS config: %w", err)
+		}
+		options.TLSConfig = tlsConfig
+	}
+
+	t.redisClient = redis.NewClient(options)
+	return client.NewRedisStore(client.RedisOptions{
+		Client:        t.redisClient,
+		KeyPrefix:     cfg.KeyPrefix,
+		TTL:           t.config.CacheTTL,
+		Timeout:       cfg.Timeout,
+		RetryInterval: cfg.RetryInterval,
+	}), nil
+}
 
+// startOffline loads the mapping snapshot and watches it for changes.
+func (t *truthBeamProcessor) startOffline() error {
+	var publicKey crypto.PublicKey
+	if t.config.Snapshot.PublicKey != "" {
+		var err error
+		publicKey, err = offline.LoadPublicKey(t.config.Snapshot.PublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to load snapshot public key %s: %w", t.config.Snapshot.PublicKey, err)
+		}
+	}
+
+	source, err := offline.NewSource(t.config.Snapshot.Path, publicKey, t.logger)
+	if err != nil {
+		return err
+	}
+	t.snapshot = source
+
+	t.goBackground(func(ctx context.Context) {
+		source.Watch(ctx, t.config.Snapshot.ReloadInterval)
+	})
 	return nil
 }
+
+// warmup fills the cache from Compass, bounded by the warmup timeout.
+// Failures are logged; records are then enriched on first use as usual.
+func (t *truthBeamProcessor) warmup(ctx context.Context, cacheableClient *client.CacheableClient) {
+	ctx, cancel := context.WithTimeout(ctx, t.config.Warmup.Timeout)
+	defer cancel()
+
+	var policies []client.Policy
+	for _, policy := range t.config.Warmup.Policies {
+		policies = append(policies, client.Policy{PolicyEngineName: policy.PolicyEngineName, PolicyRuleId: policy.PolicyRuleID})
+	}
+
+	start := time.Now()
+	warmed, err := cacheableClient.Warm(ctx, policies)
+	if err != nil {
+		t.logger.Warn("cache warmup did not complete", zap.Int("entries", warmed), zap.Error(err))
+		return
+	}
+	t.logger.Info("cache warmed", zap.Int("entries", warmed), zap.Duration("duration", time.Since(start)))
+}
+
+// goBackground runs task until it returns or the processor shuts down.
+func (t *truthBeamProcessor) goBackground(task func(ctx context.Context)) {
+	t.background.Add(1)
+	go func() {
+		defer t.background.Done()
+		task(t.backgroundCtx)
+	}()
+}
+
+// Shutdown stops the background tasks, if running, unregisters the
+// processor metrics and closes the persistent and shared caches and the
+// retry queue. Held records stay in storage until the next start.
+func (t *truthBeamProcessor) Shutdown(ctx context.Context) error {
+	if t.telemetryRegistration != nil {
+		if err := t.telemetryRegistration.Unregister(); err != nil {
+			t.logger.Warn("failed to unregister telemetry", zap.Error(err))
+		}
+		t.telemetryRegistration = nil
+	}
+	t.stopBackground()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t.background.Wait()
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var errs []error
+	if t.redisClient != nil {
+		errs = append(errs, t.redisClient.Close())
+		t.redisClient = nil
+	}
+	if t.storageClient != nil {
+		errs = append(errs, t.storageClient.Close(ctx))
+		t.storageClient = nil
+	}
+	if t.queueClient != nil {
+		errs = append(errs, t.queueClient.Close(ctx))
+		t.queue, t.queueClient = nil, nil
+	}
+	return errors.Join(errs...)
+}
@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,12 +18,16 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/processor/processortest"
 	"go.uber.org/zap/zaptest"
 
 	"github.com/complytime/complybeacon/truthbeam/internal/client"
+	"github.com/complytime/complybeacon/truthbeam/internal/retryqueue"
 )
 
 // The processor tests validate the core processor functionality including log processing,
@@ -131,6 +139,57 @@ func TestProcessLogsWithMissingAttributes(t *testing.T) {
 	result, err := processor.processLogs(ctx, logs)
 	require.NoError(t, err, "Processor should not fail even with missing attributes")
 	require.NotNil(t, result)
+
+	attrs := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	assert.Equal(t, string(client.Skipped), attrs[applier.COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Equal(t, "missing required attributes: "+applier.POLICY_RULE_ID, attrs[applier.COMPLIANCE_ENRICHMENT_REASON])
+}
+
+func TestProcessLogsWithConfiguredExtraction(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.EnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "test-policy-123", req.Policy.PolicyRuleId)
+		assert.Equal(t, "test-source", req.Policy.PolicyEngineName)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(client.EnrichmentResponse{
+			Compliance: client.Compliance{
+				Control:          client.ComplianceControl{Id: "AC-1"},
+				EnrichmentStatus: client.Success,
+			},
+		})
+	}))
+	defer mockServer.Close()
+
+	cfg := &Config{
+		ClientConfig: confighttp.NewDefaultClientConfig(),
+		Extraction: applier.Extraction{
+			PolicyEngineName: applier.Field{
+				Sources:   []applier.Source{{ResourceAttribute: "service.name"}},
+				Lowercase: true,
+			},
+		},
+	}
+	cfg.ClientConfig.Endpoint = mockServer.URL
+	require.NoError(t, cfg.Validate())
+
+	processor, err := newTruthBeamProcessor(cfg, processortest.NewNopSettings(component.MustNewType("test")))
+	require.NoError(t, err)
+	require.NoError(t, processor.Start(context.Background(), componenttest.NewNopHost()))
+
+	logs := createTestLogs()
+	resourceLogs := logs.ResourceLogs().At(0)
+	resourceLogs.Resource().Attributes().PutStr("service.name", "Test-Source")
+	logRecord := resourceLogs.ScopeLogs().At(0).LogRecords().At(0)
+	logRecord.Attributes().PutStr(applier.POLICY_RULE_ID, "test-policy-123")
+	logRecord.Attributes().PutStr(applier.POLICY_EVALUATION_RESULT, "Passed")
+
+	result, err := processor.processLogs(context.Background(), logs)
+	require.NoError(t, err)
+
+	attrs := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	assert.Equal(t, "AC-1", attrs[applier.COMPLIANCE_CONTROL_ID])
 }
 
 func TestProcessLogsWithHTTPError(t *testing.T) {
@@ -152,11 +211,168 @@ func TestProcessLogsWithHTTPError(t *testing.T) {
 	result, err := processor.processLogs(ctx, logs)
 	require.NoError(t, err, "Processor should not fail even with HTTP errors")
 	require.NotNil(t, result)
+
+	attrs := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	assert.Equal(t, "Compliant", attrs[applier.COMPLIANCE_STATUS])
+	assert.Equal(t, string(client.Unknown), attrs[applier.COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Contains(t, attrs[applier.COMPLIANCE_ENRICHMENT_REASON], "500")
+	assert.NotContains(t, attrs, applier.COMPLIANCE_CONTROL_ID)
+}
+
+func TestProcessLogsWithOpenCircuitBreaker(t *testing.T) {
+	calls := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	processor := createTestProcessor(t, mockServer.URL)
+	baseClient, err := client.NewClient(mockServer.URL)
+	require.NoError(t, err)
+	processor.client, err = client.NewCacheableClient(baseClient, processor.logger, 0, 0,
+		client.WithCircuitBreaker(1, time.Minute))
+	require.NoError(t, err)
+
+	// The first failure opens the breaker.
+	logs := createTestLogs()
+	setRequiredAttributes(logs)
+	_, err = processor.processLogs(context.Background(), logs)
+	require.NoError(t, err)
+	assert.Equal(t, client.BreakerOpen, processor.client.BreakerState())
+
+	logs = createTestLogs()
+	setRequiredAttributes(logs)
+	logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().PutStr(applier.POLICY_RULE_ID, "other-policy")
+	result, err := processor.processLogs(context.Background(), logs)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	attrs := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	assert.Equal(t, "Compliant", attrs[applier.COMPLIANCE_STATUS])
+	assert.Equal(t, string(client.Unknown), attrs[applier.COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Contains(t, attrs[applier.COMPLIANCE_ENRICHMENT_REASON], client.ErrCircuitOpen.Error())
+	assert.NotContains(t, attrs, applier.COMPLIANCE_CONTROL_ID)
+}
+
+func TestProcessLogsOffline(t *testing.T) {
+	snapshot := client.Snapshot{
+		FormatVersion: 1,
+		Complete:      true,
+		Entries: []client.SnapshotEntry{
+			{
+				Policy: client.Policy{PolicyEngineName: "test-source", PolicyRuleId: "test-policy-123"},
+				Compliance: client.Compliance{
+					Control:          client.ComplianceControl{Id: "AC-1", CatalogId: "NIST-800-53", Category: "Access Control"},
+					Frameworks:       client.ComplianceFrameworks{Requirements: []string{"req-1"}, Frameworks: []string{"NIST-800-53"}},
+					EnrichmentStatus: client.Success,
+				},
+			},
+		},
+	}
+	content, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, os.WriteFile(path, content, 0600))
+
+	cfg := &Config{Snapshot: SnapshotConfig{Path: path}}
+	require.NoError(t, cfg.Validate())
+	processor, err := newTruthBeamProcessor(cfg, processortest.NewNopSettings(component.MustNewType("test")))
+	require.NoError(t, err)
+	require.NoError(t, processor.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() {
+		require.NoError(t, processor.Shutdown(context.Background()))
+	}()
+	assert.Nil(t, processor.client)
+
+	logs := createTestLogs()
+	setRequiredAttributes(logs)
+	result, err := processor.processLogs(context.Background(), logs)
+	require.NoError(t, err)
+
+	attrs := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	assert.Equal(t, "AC-1", attrs[applier.COMPLIANCE_CONTROL_ID])
+	assert.Equal(t, string(client.Success), attrs[applier.COMPLIANCE_ENRICHMENT_STATUS])
+}
+
+func TestStartWarmup(t *testing.T) {
+	tests := []struct {
+		name       string
+		background bool
+	}{
+		{name: "blocking"},
+		{name: "background", background: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var enrichCalls atomic.Int32
+			release := make(chan struct{})
+			mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path != "/v1/snapshot" {
+					enrichCalls.Add(1)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if tt.background {
+					// Startup must not wait for the snapshot.
+					<-release
+				}
+				policy := client.Policy{PolicyEngineName: "test-source", PolicyRuleId: "test-policy-123"}
+				_ = json.NewEncoder(w).Encode(client.Snapshot{
+					FormatVersion: 1,
+					Complete:      true,
+					Entries: []client.SnapshotEntry{{
+						Policy: policy,
+						Compliance: client.Compliance{
+							Control:          client.ComplianceControl{Id: "AC-1", CatalogId: "NIST-800-53", Category: "Access Control"},
+							Frameworks:       client.ComplianceFrameworks{Requirements: []string{}, Frameworks: []string{}},
+							EnrichmentStatus: client.Success,
+						},
+					}},
+				})
+			}))
+			defer mockServer.Close()
+
+			cfg := &Config{
+				ClientConfig: confighttp.NewDefaultClientConfig(),
+				Warmup:       WarmupConfig{Enabled: true, Background: tt.background},
+			}
+			cfg.ClientConfig.Endpoint = mockServer.URL
+			require.NoError(t, cfg.Validate())
+
+			processor, err := newTruthBeamProcessor(cfg, processortest.NewNopSettings(component.MustNewType("test")))
+			require.NoError(t, err)
+			require.NoError(t, processor.Start(context.Background(), componenttest.NewNopHost()))
+			defer func() {
+				require.NoError(t, processor.Shutdown(context.Background()))
+			}()
+
+			if tt.background {
+				close(release)
+				processor.background.Wait()
+			}
+
+			logs := createTestLogs()
+			setRequiredAttributes(logs)
+			result, err := processor.processLogs(context.Background(), logs)
+			require.NoError(t, err)
+			attrs := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+			assert.Equal(t, "AC-1", attrs[applier.COMPLIANCE_CONTROL_ID])
+			assert.Zero(t, enrichCalls.Load())
+		})
+	}
 }
 
 func TestProcessLogsWithMixedValidAndInvalidRecords(t *testing.T) {
 	callCount := 0
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Emulate a Compass without the batch endpoint.
+		if r.URL.Path != "/v1/enrich" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
 		callCount++
 		assert.Equal(t, "POST", r.Method)
 		assert.Equal(t, "/v1/enrich", r.URL.Path)
@@ -232,10 +448,12 @@ func TestProcessLogsWithMixedValidAndInvalidRecords(t *testing.T) {
 	assert.Equal(t, "AC-1", attrs1.AsRaw()[applier.COMPLIANCE_CONTROL_ID])
 	assert.Equal(t, "NIST-800-53", attrs1.AsRaw()[applier.COMPLIANCE_CONTROL_CATALOG_ID])
 
-	// Check invalid record - should remain unchanged (no compliance attributes)
+	// Check invalid record - should be marked as skipped, without compliance context
 	invalidRecordResult := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(1)
 	attrs2 := invalidRecordResult.Attributes()
-	assert.Nil(t, attrs2.AsRaw()[applier.COMPLIANCE_STATUS])
+	assert.Equal(t, "Unknown", attrs2.AsRaw()[applier.COMPLIANCE_STATUS])
+	assert.Equal(t, string(client.Skipped), attrs2.AsRaw()[applier.COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Contains(t, attrs2.AsRaw()[applier.COMPLIANCE_ENRICHMENT_REASON], "missing required attributes")
 	assert.Nil(t, attrs2.AsRaw()[applier.COMPLIANCE_CONTROL_ID])
 	assert.Nil(t, attrs2.AsRaw()[applier.COMPLIANCE_CONTROL_CATALOG_ID])
 	// Original attributes should still be there
@@ -249,6 +467,389 @@ func TestProcessLogsWithMixedValidAndInvalidRecords(t *testing.T) {
 	assert.Equal(t, "NIST-800-53", attrs3.AsRaw()[applier.COMPLIANCE_CONTROL_CATALOG_ID])
 }
 
+func TestProcessLogsBatchesDistinctPolicies(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]client.Policy
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/enrich/batch", r.URL.Path)
+
+		var req client.BatchEnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		batches = append(batches, req.Policies)
+		mu.Unlock()
+
+		response := client.BatchEnrichmentResponse{}
+		for _, policy := range req.Policies {
+			response.Results = append(response.Results, client.EnrichmentResult{
+				Policy: policy,
+				Compliance: client.Compliance{
+					Control: client.ComplianceControl{
+						CatalogId: "NIST-800-53",
+						Category:  "Access Control",
+						Id:        policy.PolicyRuleId + "-control",
+					},
+					Frameworks: client.ComplianceFrameworks{
+						Requirements: []string{},
+						Frameworks:   []string{},
+					},
+					EnrichmentStatus: client.Success,
+				},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer mockServer.Close()
+
+	processor := createTestProcessor(t, mockServer.URL)
+
+	logs := plog.NewLogs()
+	scopeLogs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	rules := []string{"rule-a", "rule-b", "rule-a", "rule-c", "rule-b", "rule-a"}
+	for _, rule := range rules {
+		record := scopeLogs.LogRecords().AppendEmpty()
+		record.Attributes().PutStr(applier.POLICY_RULE_ID, rule)
+		record.Attributes().PutStr(applier.POLICY_ENGINE_NAME, "test-source")
+		record.Attributes().PutStr(applier.POLICY_EVALUATION_RESULT, "Passed")
+	}
+
+	result, err := processor.processLogs(context.Background(), logs)
+	require.NoError(t, err)
+
+	// One request carrying each distinct policy once.
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0], 3)
+
+	records := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i, rule := range rules {
+		assert.Equal(t, rule+"-control", records.At(i).Attributes().AsRaw()[applier.COMPLIANCE_CONTROL_ID])
+	}
+
+	// A second batch is served from the cache.
+	_, err = processor.processLogs(context.Background(), logs)
+	require.NoError(t, err)
+	assert.Len(t, batches, 1)
+}
+
+func TestProcessTraces(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]client.Policy
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/enrich/batch", r.URL.Path)
+
+		var req client.BatchEnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		batches = append(batches, req.Policies)
+		mu.Unlock()
+
+		response := client.BatchEnrichmentResponse{}
+		for _, policy := range req.Policies {
+			response.Results = append(response.Results, client.EnrichmentResult{
+				Policy: policy,
+				Compliance: client.Compliance{
+					Control: client.ComplianceControl{
+						CatalogId: "NIST-800-53",
+						Category:  "Access Control",
+						Id:        policy.PolicyRuleId + "-control",
+					},
+					EnrichmentStatus: client.Success,
+				},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer mockServer.Close()
+
+	processor := createTestProcessor(t, mockServer.URL)
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+
+	evidenceSpan := spans.AppendEmpty()
+	evidenceSpan.Attributes().PutStr(applier.POLICY_RULE_ID, "rule-span")
+	evidenceSpan.Attributes().PutStr(applier.POLICY_ENGINE_NAME, "test-source")
+	evidenceSpan.Attributes().PutStr(applier.POLICY_EVALUATION_RESULT, "Failed")
+
+	parentSpan := spans.AppendEmpty()
+	parentSpan.Attributes().PutStr("http.route", "/evaluate")
+	event := parentSpan.Events().AppendEmpty()
+	event.SetName("evidence.logged")
+	event.Attributes().PutStr(applier.POLICY_RULE_ID, "rule-event")
+	event.Attributes().PutStr(applier.POLICY_ENGINE_NAME, "test-source")
+	event.Attributes().PutStr(applier.POLICY_EVALUATION_RESULT, "Passed")
+
+	partialSpan := spans.AppendEmpty()
+	partialSpan.Attributes().PutStr(applier.POLICY_RULE_ID, "rule-partial")
+
+	result, err := processor.processTraces(context.Background(), traces)
+	require.NoError(t, err)
+
+	require.Len(t, batches, 1)
+	assert.ElementsMatch(t, []client.Policy{
+		{PolicyEngineName: "test-source", PolicyRuleId: "rule-span"},
+		{PolicyEngineName: "test-source", PolicyRuleId: "rule-event"},
+	}, batches[0])
+
+	processed := result.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+
+	attrs := processed.At(0).Attributes().AsRaw()
+	assert.Equal(t, "Non-Compliant", attrs[applier.COMPLIANCE_STATUS])
+	assert.Equal(t, "rule-span-control", attrs[applier.COMPLIANCE_CONTROL_ID])
+
+	// Spans without evidence are passed through unmarked.
+	attrs = processed.At(1).Attributes().AsRaw()
+	assert.NotContains(t, attrs, applier.COMPLIANCE_STATUS)
+	assert.NotContains(t, attrs, applier.COMPLIANCE_ENRICHMENT_STATUS)
+
+	attrs = processed.At(1).Events().At(0).Attributes().AsRaw()
+	assert.Equal(t, "Compliant", attrs[applier.COMPLIANCE_STATUS])
+	assert.Equal(t, "rule-event-control", attrs[applier.COMPLIANCE_CONTROL_ID])
+
+	attrs = processed.At(2).Attributes().AsRaw()
+	assert.Equal(t, "Skipped", attrs[applier.COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Contains(t, attrs[applier.COMPLIANCE_ENRICHMENT_REASON], applier.POLICY_ENGINE_NAME)
+}
+
+func TestProcessTracesWithEngineDefault(t *testing.T) {
+	var calls atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req client.BatchEnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		response := client.BatchEnrichmentResponse{}
+		for _, policy := range req.Policies {
+			response.Results = append(response.Results, client.EnrichmentResult{
+				Policy: policy,
+				Compliance: client.Compliance{
+					Control:          client.ComplianceControl{CatalogId: "NIST-800-53", Category: "Access Control", Id: policy.PolicyRuleId + "-control"},
+					EnrichmentStatus: client.Success,
+				},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer mockServer.Close()
+
+	processor := createTestProcessor(t, mockServer.URL)
+	processor.applier = applier.NewApplier(zaptest.NewLogger(t), applier.WithExtraction(applier.Extraction{
+		PolicyEngineName:       applier.Field{Default: "test-source"},
+		PolicyEvaluationResult: applier.Field{Sources: []applier.Source{{ResourceAttribute: "evaluation.result"}}},
+	}))
+
+	traces := ptrace.NewTraces()
+	resourceSpans := traces.ResourceSpans().AppendEmpty()
+	resourceSpans.Resource().Attributes().PutStr("evaluation.result", "Passed")
+	spans := resourceSpans.ScopeSpans().AppendEmpty().Spans()
+
+	ordinarySpan := spans.AppendEmpty()
+	ordinarySpan.Attributes().PutStr("http.route", "/evaluate")
+
+	evidenceSpan := spans.AppendEmpty()
+	evidenceSpan.Attributes().PutStr(applier.POLICY_RULE_ID, "rule-span")
+	event := ordinarySpan.Events().AppendEmpty()
+	event.Attributes().PutStr(applier.POLICY_RULE_ID, "rule-event")
+
+	result, err := processor.processTraces(context.Background(), traces)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "both policies are resolved in one batch")
+
+	// The engine default and resource result apply to every span, so
+	// only the span with a rule ID is evidence.
+	processed := result.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	attrs := processed.At(0).Attributes().AsRaw()
+	assert.NotContains(t, attrs, applier.COMPLIANCE_ENRICHMENT_STATUS)
+
+	attrs = processed.At(0).Events().At(0).Attributes().AsRaw()
+	assert.Equal(t, "rule-event-control", attrs[applier.COMPLIANCE_CONTROL_ID])
+
+	attrs = processed.At(1).Attributes().AsRaw()
+	assert.Equal(t, string(client.Success), attrs[applier.COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Equal(t, "rule-span-control", attrs[applier.COMPLIANCE_CONTROL_ID])
+}
+
+func TestProcessLogsFailClosed(t *testing.T) {
+	var healthy, rejecting atomic.Bool
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rejecting.Load() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code": 400, "message": "Bad request"}`))
+			return
+		}
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		response := client.EnrichmentResponse{
+			Compliance: client.Compliance{
+				Control:          client.ComplianceControl{CatalogId: "NIST-800-53", Category: "Access Control", Id: "AC-1"},
+				EnrichmentStatus: client.Success,
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer mockServer.Close()
+
+	newProcessor := func(t *testing.T) (*truthBeamProcessor, *consumertest.LogsSink) {
+		processor := createTestProcessor(t, mockServer.URL)
+		baseClient, err := client.NewClient(mockServer.URL)
+		require.NoError(t, err)
+		processor.client, err = client.NewCacheableClient(baseClient, processor.logger, 0, 0, client.WithErrorTTL(time.Nanosecond))
+		require.NoError(t, err)
+
+		processor.config.FailClosed = FailClosedConfig{
+			Enabled:         true,
+			InitialInterval: time.Second,
+			MaxInterval:     time.Minute,
+			MaxAge:          time.Hour,
+		}
+		processor.queue, err = retryqueue.Open(context.Background(), newMemoryStorage(), 0)
+		require.NoError(t, err)
+		sink := new(consumertest.LogsSink)
+		processor.next = sink
+		return processor, sink
+	}
+
+	newLogs := func() plog.Logs {
+		logs := createTestLogs()
+		setRequiredAttributes(logs)
+		logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().AppendEmpty().Attributes().PutStr(applier.POLICY_RULE_ID, "missing-fields")
+		return logs
+	}
+
+	t.Run("released once enriched", func(t *testing.T) {
+		healthy.Store(false)
+		processor, sink := newProcessor(t)
+		ctx := context.Background()
+
+		result, err := processor.processLogs(ctx, newLogs())
+		require.NoError(t, err)
+		// Only the record that could not be extracted passes through.
+		records := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		require.Equal(t, 1, records.Len())
+		assert.Equal(t, "missing-fields", records.At(0).Attributes().AsRaw()[applier.POLICY_RULE_ID])
+		assert.Equal(t, 1, processor.queue.Len())
+
+		now := time.Now()
+		processor.retryHeld(ctx, now.Add(time.Second))
+		assert.Equal(t, 1, processor.queue.Len())
+		assert.Zero(t, sink.LogRecordCount())
+
+		healthy.Store(true)
+		// The retry is backed off.
+		processor.retryHeld(ctx, now.Add(2*time.Second))
+		assert.Equal(t, 1, processor.queue.Len())
+		processor.retryHeld(ctx, now.Add(4*time.Second))
+		assert.Equal(t, 0, processor.queue.Len())
+
+		require.Equal(t, 1, sink.LogRecordCount())
+		attrs := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		assert.Equal(t, string(client.Success), attrs[applier.COMPLIANCE_ENRICHMENT_STATUS])
+		assert.Equal(t, "AC-1", attrs[applier.COMPLIANCE_CONTROL_ID])
+	})
+
+	t.Run("released as unknown after max age", func(t *testing.T) {
+		healthy.Store(false)
+		processor, sink := newProcessor(t)
+		ctx := context.Background()
+
+		_, err := processor.processLogs(ctx, newLogs())
+		require.NoError(t, err)
+		processor.retryHeld(ctx, time.Now().Add(time.Hour))
+		assert.Equal(t, 0, processor.queue.Len())
+
+		require.Equal(t, 1, sink.LogRecordCount())
+		attrs := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		assert.Equal(t, string(client.Unknown), attrs[applier.COMPLIANCE_ENRICHMENT_STATUS])
+		assert.Contains(t, attrs[applier.COMPLIANCE_ENRICHMENT_REASON], "not enriched within 1h0m0s")
+	})
+
+	t.Run("client errors are not held", func(t *testing.T) {
+		rejecting.Store(true)
+		defer rejecting.Store(false)
+		processor, _ := newProcessor(t)
+
+		result, err := processor.processLogs(context.Background(), newLogs())
+		require.NoError(t, err)
+		assert.Equal(t, 0, processor.queue.Len())
+		records := result.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		require.Equal(t, 2, records.Len())
+		assert.Equal(t, string(client.Unknown), records.At(0).Attributes().AsRaw()[applier.COMPLIANCE_ENRICHMENT_STATUS])
+	})
+}
+
+func TestProcessLogsWithBackends(t *testing.T) {
+	newBackend := func(controlID string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			response := client.EnrichmentResponse{
+				Compliance: client.Compliance{
+					Control:          client.ComplianceControl{CatalogId: "catalog", Category: "Access Control", Id: controlID},
+					EnrichmentStatus: client.Success,
+				},
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(response)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	finance := newBackend("finance-control")
+	retail := newBackend("retail-control")
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ClientConfig.Endpoint = ""
+	cfg.Backends = map[string]BackendConfig{
+		"finance": {Endpoints: []string{unavailable.URL, finance.URL}},
+		"retail":  {Endpoints: []string{retail.URL}},
+	}
+	cfg.BackendAttribute = "service.namespace"
+	cfg.DefaultBackend = "retail"
+	require.NoError(t, cfg.Validate())
+
+	settings := processortest.NewNopSettings(component.MustNewType("test"))
+	settings.Logger = zaptest.NewLogger(t)
+	processor, err := newTruthBeamProcessor(cfg, settings)
+	require.NoError(t, err)
+	require.NoError(t, processor.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() { require.NoError(t, processor.Shutdown(context.Background())) }()
+
+	logs := plog.NewLogs()
+	for _, namespace := range []string{"finance", "", "marketing"} {
+		resourceLogs := logs.ResourceLogs().AppendEmpty()
+		if namespace != "" {
+			resourceLogs.Resource().Attributes().PutStr("service.namespace", namespace)
+		}
+		logRecord := resourceLogs.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+		logRecord.Attributes().PutStr(applier.POLICY_RULE_ID, "test-policy-123")
+		logRecord.Attributes().PutStr(applier.POLICY_ENGINE_NAME, "test-source")
+		logRecord.Attributes().PutStr(applier.POLICY_EVALUATION_RESULT, "Passed")
+	}
+
+	result, err := processor.processLogs(context.Background(), logs)
+	require.NoError(t, err)
+
+	attrsAt := func(i int) map[string]any {
+		return result.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+	}
+	// The finance backend fails over to its second endpoint.
+	assert.Equal(t, "finance-control", attrsAt(0)[applier.COMPLIANCE_CONTROL_ID])
+	assert.Equal(t, "retail-control", attrsAt(1)[applier.COMPLIANCE_CONTROL_ID])
+	// An unknown backend is not replaced by the default one.
+	assert.Equal(t, string(client.Unknown), attrsAt(2)[applier.COMPLIANCE_ENRICHMENT_STATUS])
+	assert.Contains(t, attrsAt(2)[applier.COMPLIANCE_ENRICHMENT_REASON], `unknown compass backend "marketing"`)
+}
+
 // Helper functions
 func createTestProcessor(t *testing.T, endpoint string) *truthBeamProcessor {
 	cfg := &Config{
@@ -261,7 +862,7 @@ func createTestProcessor(t *testing.T, endpoint string) *truthBeamProcessor {
 
 	processor, err := newTruthBeamProcessor(cfg, settings)
 	require.NoError(t, err)
-	err = processor.start(context.Background(), componenttest.NewNopHost())
+	err = processor.Start(context.Background(), componenttest.NewNopHost())
 	require.NoError(t, err)
 
 	// Create client for testing
@@ -293,3 +894,49 @@ func setRequiredAttributes(logs plog.Logs) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+// memoryStorage is a storage client that keeps its data in a map.
+type memoryStorage struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{data: make(map[string][]byte)}
+}
+
+func (m *memoryStorage) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[key], nil
+}
+
+func (m *memoryStorage) Set(_ context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func (m *memoryStorage) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memoryStorage) Batch(ctx context.Context, ops ...*storage.Operation) error {
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value, _ = m.Get(ctx, op.Key)
+		case storage.Set:
+			_ = m.Set(ctx, op.Key, op.Value)
+		case storage.Delete:
+			_ = m.Delete(ctx, op.Key)
+		}
+	}
+	return nil
+}
+
+func (m *memoryStorage) Close(context.Context) error { return nil }
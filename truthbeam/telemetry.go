@@ -0,0 +1,198 @@
+package truthbeam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/applier"
+	"github.com/complytime/complybeacon/truthbeam/internal/client"
+	"github.com/complytime/complybeacon/truthbeam/internal/metadata"
+)
+
+// registerTelemetry registers the processor metrics with the collector
+// meter provider for clients, keyed by backend name. Metrics of named
+// backends carry a compass.backend attribute. The returned registration
+// must be unregistered on shutdown.
+func registerTelemetry(meterProvider metric.MeterProvider, clients map[string]*client.CacheableClient) (metric.Registration, error) {
+	meter := meterProvider.Meter(metadata.ScopeName)
+
+	collapsed, err := meter.Int64ObservableCounter("truthbeam.enrichment.collapsed_calls",
+		metric.WithDescription("Enrichment lookups answered by a Compass call already in flight for the same policy"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collapsed calls counter: %w", err)
+	}
+
+	retries, err := meter.Int64ObservableCounter("truthbeam.compass.retries",
+		metric.WithDescription("Compass calls retried after a failure"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retries counter: %w", err)
+	}
+
+	breakerState, err := meter.Int64ObservableGauge("truthbeam.compass.circuit_breaker.state",
+		metric.WithDescription("State of the Compass circuit breaker: 0 closed, 1 open, 2 half-open"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create circuit breaker state gauge: %w", err)
+	}
+
+	cacheHits, err := meter.Int64ObservableCounter("truthbeam.cache.hits",
+		metric.WithDescription("Enrichment lookups answered by the in-memory cache"),
+		metric.WithUnit("{lookup}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache hits counter: %w", err)
+	}
+
+	cacheMisses, err := meter.Int64ObservableCounter("truthbeam.cache.misses",
+		metric.WithDescription("Enrichment lookups not found in the in-memory cache"),
+		metric.WithUnit("{lookup}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache misses counter: %w", err)
+	}
+
+	cacheEvictions, err := meter.Int64ObservableCounter("truthbeam.cache.evictions",
+		metric.WithDescription("Entries evicted from the in-memory cache because of its capacity or their TTL"),
+		metric.WithUnit("{entry}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache evictions counter: %w", err)
+	}
+
+	cacheSize, err := meter.Int64ObservableGauge("truthbeam.cache.size",
+		metric.WithDescription("Approximate number of entries in the in-memory cache"),
+		metric.WithUnit("{entry}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache size gauge: %w", err)
+	}
+
+	return meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		for name, cacheableClient := range clients {
+			var opts []metric.ObserveOption
+			if name != "" {
+				opts = append(opts, metric.WithAttributes(attribute.String("compass.backend", name)))
+			}
+			observer.ObserveInt64(collapsed, cacheableClient.CollapsedCalls(), opts...)
+			observer.ObserveInt64(retries, cacheableClient.Retries(), opts...)
+			observer.ObserveInt64(breakerState, int64(cacheableClient.BreakerState()), opts...)
+			if stats, ok := cacheableClient.CacheStats(); ok {
+				observer.ObserveInt64(cacheHits, int64(stats.Hits), opts...)
+				observer.ObserveInt64(cacheMisses, int64(stats.Misses), opts...)
+				observer.ObserveInt64(cacheEvictions, int64(stats.Evictions), opts...)
+				observer.ObserveInt64(cacheSize, int64(stats.Size), opts...)
+			}
+		}
+		return nil
+	}, collapsed, retries, breakerState, cacheHits, cacheMisses, cacheEvictions, cacheSize)
+}
+
+// processorMetrics holds the instruments recorded while processing records.
+type processorMetrics struct {
+	records            metric.Int64Counter
+	extractionFailures metric.Int64Counter
+	compassDuration    metric.Float64Histogram
+	compassErrors      metric.Int64Counter
+	heldDropped        metric.Int64Counter
+}
+
+// newProcessorMetrics creates the instruments recorded while processing
+// records with the collector meter provider.
+func newProcessorMetrics(meterProvider metric.MeterProvider) (*processorMetrics, error) {
+	meter := meterProvider.Meter(metadata.ScopeName)
+	m := &processorMetrics{}
+	var err error
+
+	m.records, err = meter.Int64Counter("truthbeam.records",
+		metric.WithDescription("Records processed, by enrichment status"),
+		metric.WithUnit("{record}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create records counter: %w", err)
+	}
+
+	m.extractionFailures, err = meter.Int64Counter("truthbeam.extraction.failures",
+		metric.WithDescription("Records whose lookup attributes could not be extracted, by missing or empty attribute"),
+		metric.WithUnit("{record}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extraction failures counter: %w", err)
+	}
+
+	m.compassDuration, err = meter.Float64Histogram("truthbeam.compass.duration",
+		metric.WithDescription("Duration of Compass calls, including failed calls and retries"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compass duration histogram: %w", err)
+	}
+
+	m.compassErrors, err = meter.Int64Counter("truthbeam.compass.errors",
+		metric.WithDescription("Failed Compass calls, by response status code or transport failure"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compass errors counter: %w", err)
+	}
+
+	m.heldDropped, err = meter.Int64Counter("truthbeam.fail_closed.dropped",
+		metric.WithDescription("Held records dropped because the retry queue was full"),
+		metric.WithUnit("{record}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dropped held records counter: %w", err)
+	}
+	return m, nil
+}
+
+// recordEnrichment counts a processed record by its enrichment status.
+func (m *processorMetrics) recordEnrichment(ctx context.Context, status client.ComplianceEnrichmentStatus) {
+	m.records.Add(ctx, 1, metric.WithAttributes(attribute.String(applier.COMPLIANCE_ENRICHMENT_STATUS, string(status))))
+}
+
+// recordHeldDropped counts held records dropped from a full retry queue.
+func (m *processorMetrics) recordHeldDropped(ctx context.Context, dropped int) {
+	m.heldDropped.Add(ctx, int64(dropped))
+}
+
+// recordExtractionFailure counts a record that could not be enriched once
+// for every missing or empty lookup attribute.
+func (m *processorMetrics) recordExtractionFailure(ctx context.Context, err error) {
+	var extractionErr *applier.ExtractionError
+	if !errors.As(err, &extractionErr) {
+		return
+	}
+	for _, attr := range extractionErr.Attributes {
+		m.extractionFailures.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("attribute", attr),
+			attribute.Bool("empty", extractionErr.Empty),
+		))
+	}
+}
+
+// observeCompassCall records the duration of a Compass call and, when it
+// failed, its status code. Failures without a response are recorded with
+// the "transport" error type.
+func (m *processorMetrics) observeCompassCall(ctx context.Context, elapsed time.Duration, err error) {
+	m.compassDuration.Record(ctx, elapsed.Seconds())
+	if err == nil {
+		return
+	}
+	errorType := attribute.String("error.type", "transport")
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) {
+		errorType = attribute.String("error.type", strconv.Itoa(statusErr.StatusCode))
+	}
+	m.compassErrors.Add(ctx, 1, metric.WithAttributes(errorType))
+}
@@ -0,0 +1,127 @@
+package truthbeam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+
+	"github.com/complytime/complybeacon/truthbeam/internal/applier"
+	"github.com/complytime/complybeacon/truthbeam/internal/client"
+	"github.com/complytime/complybeacon/truthbeam/internal/metadata"
+)
+
+func TestRegisterTelemetry(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	baseClient, err := client.NewClient("http://localhost:8081")
+	require.NoError(t, err)
+	cacheableClient, err := client.NewCacheableClient(baseClient, zap.NewNop(), 0, 0)
+	require.NoError(t, err)
+
+	registration, err := registerTelemetry(meterProvider, map[string]*client.CacheableClient{"": cacheableClient})
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 7)
+
+	metrics := map[string]metricdata.Metrics{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	for _, name := range []string{
+		"truthbeam.enrichment.collapsed_calls", "truthbeam.compass.retries",
+		"truthbeam.cache.hits", "truthbeam.cache.misses", "truthbeam.cache.evictions",
+	} {
+		sum, ok := metrics[name].Data.(metricdata.Sum[int64])
+		require.True(t, ok, name)
+		require.Len(t, sum.DataPoints, 1)
+		assert.Equal(t, int64(0), sum.DataPoints[0].Value)
+	}
+	gauge, ok := metrics["truthbeam.compass.circuit_breaker.state"].Data.(metricdata.Gauge[int64])
+	require.True(t, ok)
+	require.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, int64(client.BreakerClosed), gauge.DataPoints[0].Value)
+	gauge, ok = metrics["truthbeam.cache.size"].Data.(metricdata.Gauge[int64])
+	require.True(t, ok)
+	require.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, int64(0), gauge.DataPoints[0].Value)
+
+	require.NoError(t, registration.Unregister())
+	rm = metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	assert.Empty(t, rm.ScopeMetrics)
+}
+
+func TestProcessorMetrics(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	reader := sdkmetric.NewManualReader()
+	settings := processortest.NewNopSettings(component.MustNewType("test"))
+	settings.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	cfg := &Config{ClientConfig: confighttp.NewDefaultClientConfig()}
+	cfg.ClientConfig.Endpoint = mockServer.URL
+	require.NoError(t, cfg.Validate())
+	processor, err := newTruthBeamProcessor(cfg, settings)
+	require.NoError(t, err)
+	require.NoError(t, processor.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() { require.NoError(t, processor.Shutdown(context.Background())) }()
+
+	// One record Compass fails to enrich, and one without a rule ID.
+	logs := createTestLogs()
+	setRequiredAttributes(logs)
+	invalid := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().AppendEmpty()
+	invalid.Attributes().PutStr(applier.POLICY_ENGINE_NAME, "test-source")
+	invalid.Attributes().PutStr(applier.POLICY_EVALUATION_RESULT, "Passed")
+	_, err = processor.processLogs(context.Background(), logs)
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Metrics{}
+	for _, scope := range rm.ScopeMetrics {
+		if scope.Scope.Name != metadata.ScopeName {
+			continue
+		}
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+
+	counts := func(name, key string) map[string]int64 {
+		sum, ok := metrics[name].Data.(metricdata.Sum[int64])
+		require.True(t, ok, name)
+		values := map[string]int64{}
+		for _, dp := range sum.DataPoints {
+			value, _ := dp.Attributes.Value(attribute.Key(key))
+			values[value.Emit()] += dp.Value
+		}
+		return values
+	}
+	assert.Equal(t, map[string]int64{"Skipped": 1, "Unknown": 1}, counts("truthbeam.records", applier.COMPLIANCE_ENRICHMENT_STATUS))
+	assert.Equal(t, map[string]int64{applier.POLICY_RULE_ID: 1}, counts("truthbeam.extraction.failures", "attribute"))
+	assert.Equal(t, map[string]int64{"503": 1}, counts("truthbeam.compass.errors", "error.type"))
+
+	duration, ok := metrics["truthbeam.compass.duration"].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, duration.DataPoints, 1)
+	assert.Equal(t, uint64(1), duration.DataPoints[0].Count)
+}